@@ -1,209 +0,0 @@
-package main
-
-import (
-	"context"
-	"database/sql"
-	"errors"
-	"fmt"
-	"net/http"
-	"os"
-	"os/signal"
-	"syscall"
-	"time"
-
-	cliflags "github.com/cosmos/cosmos-sdk/client/flags"
-	sdk "github.com/cosmos/cosmos-sdk/types"
-	"github.com/golang-migrate/migrate/v4"
-	migratep "github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
-	"github.com/jessevdk/go-flags"
-	_ "github.com/lib/pq"
-	"github.com/sirupsen/logrus"
-	"golang.org/x/sync/errgroup"
-	"google.golang.org/grpc"
-
-	tokentypes "github.com/TessorNetwork/furya/x/token/types"
-	"github.com/TessorNetwork/go-broadcaster"
-	"github.com/TessorNetwork/logrus/sentry"
-
-	"github.com/TessorNetwork/vulcan/internal/blockchain"
-	"github.com/TessorNetwork/vulcan/internal/health"
-	"github.com/TessorNetwork/vulcan/internal/referral"
-	"github.com/TessorNetwork/vulcan/internal/storage/postgres"
-)
-
-// nolint:lll,gochecknoglobals
-var opts = struct {
-	Postgres                   string `long:"postgres" env:"POSTGRES" default:"host=localhost port=5432 user=postgres password=root sslmode=disable" description:"postgres dsn"`
-	PostgresMaxOpenConnections int    `long:"postgres.max_open_connections" env:"POSTGRES_MAX_OPEN_CONNECTIONS" default:"0" description:"postgres maximal open connections count, 0 means unlimited"`
-	PostgresMaxIdleConnections int    `long:"postgres.max_idle_connections" env:"POSTGRES_MAX_IDLE_CONNECTIONS" default:"5" description:"postgres maximal idle connections count"`
-	PostgresMigrations         string `long:"postgres.migrations" env:"POSTGRES_MIGRATIONS" default:"migrations/postgres" description:"postgres migrations directory"`
-
-	BlockchainNode               string `long:"blockchain.node" env:"BLOCKCHAIN_NODE" default:"http://zeus.testnet.furya.xyz:26657" description:"furya node address"`
-	BlockchainFrom               string `long:"blockchain.from" env:"BLOCKCHAIN_FROM" description:"furya account name to send stakes" required:"true"`
-	BlockchainTxMemo             string `long:"blockchain.tx_memo" env:"BLOCKCHAIN_TX_MEMO" description:"furya tx's memo'"`
-	BlockchainChainID            string `long:"blockchain.chain_id" env:"BLOCKCHAIN_CHAIN_ID" default:"testnet" description:"furya chain id"`
-	BlockchainClientHome         string `long:"blockchain.client_home" env:"BLOCKCHAIN_CLIENT_HOME" default:"~/.furyacli" description:"furyacli home directory"`
-	BlockchainKeyringBackend     string `long:"blockchain.keyring_backend" env:"BLOCKCHAIN_KEYRING_BACKEND" default:"test" description:"furyacli keyring backend"`
-	BlockchainKeyringPromptInput string `long:"blockchain.keyring_prompt_input" env:"BLOCKCHAIN_KEYRING_PROMPT_INPUT" description:"furyacli keyring prompt input"`
-	BlockchainGas                uint64 `long:"blockchain.gas" env:"BLOCKCHAIN_GAS" default:"10" description:"gas amount"`
-	BlockchainFee                string `long:"blockchain.fee" env:"BLOCKCHAIN_FEE" default:"1ufury" description:"transaction fee"`
-	BlockchainGRPCNodeURL        string `long:"blockchain.grpc_node_url" env:"BLOCKCHAIN_GRPC_NODE_URL" default:"hera.mainnet.furya.xyz:9090" description:"GRPC endpoint URL"`
-
-	ReferralThresholdPDV  string `long:"referral.threshold_pdv" env:"REFERRAL_THRESHOLD_PDV" default:"0.000100" description:"how many PDV a user should obtain to get a referral reward'"`
-	ReferralThresholdDays int    `long:"referral.threshold_days" env:"REFERRAL_THRESHOLD_DAYS" default:"30" description:"how many days a user should wait to get a referral reward'"`
-
-	LogLevel  string `long:"log.level" env:"LOG_LEVEL" default:"info" description:"Log level" choice:"debug" choice:"info" choice:"warning" choice:"error"`
-	SentryDSN string `long:"sentry.dsn" env:"SENTRY_DSN" description:"sentry dsn"`
-}{}
-
-var errTerminated = errors.New("terminated")
-
-func main() {
-	parser := flags.NewParser(&opts, flags.Default)
-	parser.ShortDescription = "Vulcan"
-	parser.LongDescription = "Vulcan"
-
-	_, err := parser.Parse()
-
-	if err != nil {
-		if flagsErr, ok := err.(*flags.Error); ok && flagsErr.Type == flags.ErrHelp {
-			parser.WriteHelp(os.Stdout)
-			os.Exit(0)
-		}
-		logrus.WithError(err).Fatal("error occurred while parsing flags")
-	}
-
-	lvl, _ := logrus.ParseLevel(opts.LogLevel) // err will always be nil
-	logrus.SetLevel(lvl)
-
-	logrus.Info("service started")
-	logrus.Infof("%+v", opts)
-
-	if opts.SentryDSN != "" {
-		hook, err := sentry.NewHook(sentry.Options{
-			Dsn:              opts.SentryDSN,
-			AttachStacktrace: true,
-			Release:          health.GetVersion(),
-		}, logrus.PanicLevel, logrus.FatalLevel, logrus.ErrorLevel)
-
-		if err != nil {
-			logrus.WithError(err).Fatal("failed to init sentry")
-		}
-
-		logrus.AddHook(hook)
-	} else {
-		logrus.Info("empty sentry dsn")
-		logrus.Warn("skip sentry initialization")
-	}
-
-	ctx, cancel := context.WithCancel(context.Background())
-
-	gr, _ := errgroup.WithContext(context.Background())
-	gr.Go(func() error {
-		nativeNodeConn, err := grpc.Dial(
-			opts.BlockchainGRPCNodeURL,
-			grpc.WithInsecure(),
-		)
-		if err != nil {
-			logrus.WithError(err).Fatal("failed to create grpc conn to native node")
-		}
-
-		referral.NewRewarder(
-			postgres.New(
-				mustGetDB()),
-			blockchain.New(mustGetBroadcaster()),
-			tokentypes.NewQueryClient(nativeNodeConn),
-			referral.NewConfig(sdk.MustNewFurFromStr(opts.ReferralThresholdPDV), opts.ReferralThresholdDays),
-		).Run(ctx, time.Hour)
-		return nil
-	})
-
-	gr.Go(func() error {
-		sigs := make(chan os.Signal, 1)
-		signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
-
-		s := <-sigs
-
-		logrus.Infof("terminating by %s signal", s)
-
-		cancel()
-
-		return errTerminated
-	})
-
-	logrus.Info("service started")
-
-	if err := gr.Wait(); err != nil && !errors.Is(err, errTerminated) && !errors.Is(err, http.ErrServerClosed) {
-		logrus.WithError(err).Fatal("service unexpectedly closed")
-	}
-}
-
-func mustGetDB() *sql.DB {
-	db, err := sql.Open("postgres", opts.Postgres)
-	if err != nil {
-		logrus.WithError(err).Fatal("failed to create postgres connection")
-	}
-	db.SetMaxOpenConns(opts.PostgresMaxOpenConnections)
-	db.SetMaxIdleConns(opts.PostgresMaxIdleConnections)
-
-	if err := db.PingContext(context.Background()); err != nil {
-		logrus.WithError(err).Fatal("failed to ping postgres")
-	}
-
-	driver, err := migratep.WithInstance(db, &migratep.Config{})
-	if err != nil {
-		logrus.WithError(err).Fatal("failed to create database migrate driver")
-	}
-
-	migrator, err := migrate.NewWithDatabaseInstance(fmt.Sprintf("file://%s", opts.PostgresMigrations), "postgres", driver)
-	if err != nil {
-		logrus.WithError(err).Fatal("failed to create migrator")
-	}
-
-	switch v, d, err := migrator.Version(); err {
-	case nil:
-		logrus.Infof("database version %d with dirty state %t", v, d)
-	case migrate.ErrNilVersion:
-		logrus.Info("database version: nil")
-	default:
-		logrus.WithError(err).Fatal("failed to get version")
-	}
-
-	switch err := migrator.Up(); err {
-	case nil:
-		logrus.Info("database was migrated")
-	case migrate.ErrNoChange:
-		logrus.Info("database is up-to-date")
-	default:
-		logrus.WithError(err).Fatal("failed to migrate db")
-	}
-
-	return db
-}
-
-func mustGetBroadcaster() broadcaster.Broadcaster {
-	fee, err := sdk.ParseCoinNormalized(opts.BlockchainFee)
-	if err != nil {
-		logrus.WithError(err).Error("failed to parse fee")
-	}
-
-	b, err := broadcaster.New(broadcaster.Config{
-		KeyringRootDir:     opts.BlockchainClientHome,
-		KeyringBackend:     opts.BlockchainKeyringBackend,
-		KeyringPromptInput: opts.BlockchainKeyringPromptInput,
-		NodeURI:            opts.BlockchainNode,
-		BroadcastMode:      cliflags.BroadcastSync,
-		From:               opts.BlockchainFrom,
-		ChainID:            opts.BlockchainChainID,
-		Gas:                opts.BlockchainGas,
-		GasAdjust:          1.2,
-		Fees:               sdk.Coins{fee},
-	})
-
-	if err != nil {
-		logrus.WithError(err).Fatal("failed to create main broadcaster")
-	}
-
-	return b
-}
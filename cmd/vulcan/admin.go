@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/sirupsen/logrus"
+
+	"github.com/TessorNetwork/vulcan/internal/service"
+	"github.com/TessorNetwork/vulcan/internal/storage/postgres"
+)
+
+type adminCommand struct {
+	Ban            adminBanCommand            `command:"ban" description:"Ban the referrer"`
+	Unban          adminUnbanCommand          `command:"unban" description:"Unban the referrer"`
+	ReplayDelivery adminReplayDeliveryCommand `command:"replay-delivery" description:"Replay the dead partner webhook delivery"`
+}
+
+type adminBanCommand struct {
+	Reason        string `long:"reason" description:"ban reason"`
+	CancelPending bool   `long:"cancel-pending" description:"cancel the referrer's pending referrals"`
+
+	Args struct {
+		Address string `positional-arg-name:"address" required:"true"`
+	} `positional-args:"yes"`
+}
+
+// Execute bans the referrer.
+func (c *adminBanCommand) Execute([]string) error {
+	cancelled, err := newAdminService().BanReferrer(context.Background(), c.Args.Address, c.Reason, c.CancelPending)
+	if err != nil {
+		return err
+	}
+
+	logrus.WithField("cancelled", cancelled).Info("done")
+
+	return nil
+}
+
+type adminUnbanCommand struct {
+	Reason string `long:"reason" description:"unban reason"`
+
+	Args struct {
+		Address string `positional-arg-name:"address" required:"true"`
+	} `positional-args:"yes"`
+}
+
+// Execute unbans the referrer.
+func (c *adminUnbanCommand) Execute([]string) error {
+	return newAdminService().UnbanReferrer(context.Background(), c.Args.Address, c.Reason)
+}
+
+type adminReplayDeliveryCommand struct {
+	Args struct {
+		ID int `positional-arg-name:"id" required:"true"`
+	} `positional-args:"yes"`
+}
+
+// Execute schedules the dead delivery for immediate redelivery, it is sent by the serve command.
+func (c *adminReplayDeliveryCommand) Execute([]string) error {
+	if err := newAdminService().ReplayWebhookDelivery(context.Background(), c.Args.ID); err != nil {
+		return fmt.Errorf("failed to replay delivery %d: %w", c.Args.ID, err)
+	}
+
+	return nil
+}
+
+// newAdminService returns the service for administrative methods, they use only the storage.
+func newAdminService() service.Service {
+	return service.New(postgres.New(mustGetDB()), nil, nil, nil, sdk.ZeroInt(), "", mustGetReferralConfig(), "", nil)
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	cliflags "github.com/cosmos/cosmos-sdk/client/flags"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/TessorNetwork/vulcan/internal/blockchain"
+	"github.com/TessorNetwork/vulcan/internal/config"
+	"github.com/TessorNetwork/vulcan/internal/distribution"
+	"github.com/TessorNetwork/vulcan/internal/storage/postgres"
+)
+
+// nolint:lll
+type distributeCommand struct {
+	Run       string `long:"run" env:"DISTRIBUTE_RUN" default:"initial" description:"name of the distribution run, payouts recorded by the run with the same name aren't sent again"`
+	CSV       string `long:"csv" env:"DISTRIBUTE_CSV" description:"path to CSV file with rows address[,amount], confirmed accounts are used if empty"`
+	Amount    int64  `long:"amount" env:"DISTRIBUTE_AMOUNT" default:"10000000" description:"ufury amount sent to every address unless the CSV row sets it"`
+	BatchSize int    `long:"batch-size" env:"DISTRIBUTE_BATCH_SIZE" default:"10" description:"number of transfers in one transaction"`
+	DryRun    bool   `long:"dry-run" env:"DISTRIBUTE_DRY_RUN" description:"print totals without sending anything"`
+	Resolve   string `long:"resolve" env:"DISTRIBUTE_RESOLVE" choice:"sent" choice:"failed" description:"mark sending payouts of the memo sent or failed after checking the transaction with the memo on chain, failed payouts are sent by the next run"`
+	Memo      string `long:"memo" env:"DISTRIBUTE_MEMO" description:"memo of the transaction of sending payouts to resolve"`
+}
+
+func (c *distributeCommand) validate(errs *config.ValidationError) {
+	errs.Check(c.Run != "", "run", "is required")
+	errs.Check(c.Amount > 0, "amount", "should be positive")
+	errs.Check(c.BatchSize > 0, "batch-size", "should be positive")
+	errs.Check(c.Resolve == "" || c.Memo != "", "memo", "is required to resolve")
+	errs.Check(c.DryRun || c.Resolve != "" || opts.BlockchainFrom != "", "blockchain.from", "is required")
+}
+
+// Execute sends stakes to confirmed accounts or accounts from the CSV file.
+// The interrupted or partially failed run is resumed by the next execution with the same run name.
+func (c *distributeCommand) Execute([]string) error {
+	db := mustGetDB()
+	checkMigrations(db)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
+	defer cancel()
+
+	if c.Resolve != "" {
+		n, err := distribution.New(postgres.New(db), nil, c.BatchSize).Resolve(ctx, c.Run, c.Memo, c.Resolve == "sent")
+		if err != nil {
+			return fmt.Errorf("failed to resolve: %w", err)
+		}
+
+		fmt.Printf("run: %s\nmemo: %s\nresolved: %d\n", c.Run, c.Memo, n)
+
+		return nil
+	}
+
+	recipients, err := c.getRecipients(ctx, sqlx.NewDb(db, "postgres"))
+	if err != nil {
+		return err
+	}
+
+	if c.DryRun {
+		report, err := distribution.New(postgres.New(db), nil, c.BatchSize).Plan(ctx, c.Run, recipients)
+		if err != nil {
+			return fmt.Errorf("failed to plan distribution: %w", err)
+		}
+
+		c.print(report)
+
+		return nil
+	}
+
+	bc := blockchain.New(mustGetBroadcaster(cliflags.BroadcastBlock))
+
+	report, err := distribution.New(postgres.New(db), bc, c.BatchSize).Run(ctx, c.Run, recipients)
+	if err != nil {
+		return fmt.Errorf("failed to distribute: %w", err)
+	}
+
+	c.print(report)
+
+	if report.Sending.Count > 0 {
+		return fmt.Errorf("%d payouts may have been sent, check transactions with their memos on chain "+
+			"and resolve them with --resolve", report.Sending.Count)
+	}
+
+	if report.Pending.Count > 0 {
+		return fmt.Errorf("%d payouts aren't sent, run the command again to resume", report.Pending.Count)
+	}
+
+	return nil
+}
+
+func (c *distributeCommand) getRecipients(ctx context.Context, db *sqlx.DB) ([]distribution.Recipient, error) {
+	amount := sdk.NewInt(c.Amount)
+
+	if c.CSV != "" {
+		f, err := os.Open(c.CSV)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open csv: %w", err)
+		}
+		defer f.Close() // nolint: errcheck
+
+		return distribution.ReadCSV(f, amount)
+	}
+
+	aa, err := getConfirmedAddresses(ctx, db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get addresses: %w", err)
+	}
+
+	recipients := make([]distribution.Recipient, len(aa))
+	for i, v := range aa {
+		recipients[i] = distribution.Recipient{Address: v, Amount: amount}
+	}
+
+	return recipients, nil
+}
+
+func (c *distributeCommand) print(report *distribution.Report) {
+	fmt.Printf("run: %s\ndry run: %t\nrecipients: %d\ninvalid: %d\nsent: %s\npending: %s\nfailed: %s\nsending: %s\n",
+		c.Run, c.DryRun, report.Recipients, report.Invalid, report.Sent, report.Pending, report.Failed, report.Sending)
+
+	for _, v := range report.SendingMemos {
+		fmt.Printf("sending memo: %s\n", v)
+	}
+}
+
+func getConfirmedAddresses(ctx context.Context, db *sqlx.DB) ([]string, error) {
+	var aa []string
+	if err := db.SelectContext(ctx, &aa, `
+		SELECT DISTINCT address FROM request WHERE confirmed_at IS NOT NULL ORDER BY address
+	`); err != nil {
+		return nil, err
+	}
+
+	return aa, nil
+}
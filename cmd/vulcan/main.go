@@ -8,110 +8,197 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
-	"strings"
 	"syscall"
 	"time"
 
 	cliflags "github.com/cosmos/cosmos-sdk/client/flags"
 	sdk "github.com/cosmos/cosmos-sdk/types"
-	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
-	"github.com/go-chi/chi"
-	"github.com/golang-migrate/migrate/v4"
-	migratep "github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/jessevdk/go-flags"
-	"github.com/johntdyer/slackrus"
 	_ "github.com/lib/pq"
 	"github.com/sirupsen/logrus"
-	"golang.org/x/sync/errgroup"
-	"google.golang.org/grpc"
 
 	"github.com/TessorNetwork/go-broadcaster"
 	"github.com/TessorNetwork/logrus/sentry"
-	"github.com/TessorNetwork/vulcan/internal/blockchain"
+	"github.com/TessorNetwork/vulcan/internal/config"
+	"github.com/TessorNetwork/vulcan/internal/events"
 	"github.com/TessorNetwork/vulcan/internal/health"
-	"github.com/TessorNetwork/vulcan/internal/mail/gmail"
 	"github.com/TessorNetwork/vulcan/internal/referral"
-	"github.com/TessorNetwork/vulcan/internal/server"
-	"github.com/TessorNetwork/vulcan/internal/service"
-	"github.com/TessorNetwork/vulcan/internal/storage/postgres"
-	"github.com/TessorNetwork/vulcan/internal/supply"
+	"github.com/TessorNetwork/vulcan/internal/storage"
+	"github.com/TessorNetwork/vulcan/internal/tracing"
+	"github.com/TessorNetwork/vulcan/internal/webhooks"
 )
 
+// opts are options shared by all commands, command specific options are defined by commands.
 // nolint:lll,gochecknoglobals
 var opts = struct {
-	Host            string        `long:"http.host" env:"HTTP_HOST" default:"0.0.0.0" description:"IP to listen on"`
-	Port            int           `long:"http.port" env:"HTTP_PORT" default:"8080" description:"port to listen on for insecure connections, defaults to a random value"`
-	RequestTimeout  time.Duration `long:"http.request-timeout" env:"HTTP_REQUEST_TIMEOUT" default:"45s" description:"request processing timeout"`
-	RecaptchaSecret string        `long:"http.recaptcha_secret" env:"HTTP_RECAPTCHA_SECRET" required:"true" description:"recaptcha secret"`
-
-	Postgres                   string `long:"postgres" env:"POSTGRES" default:"host=localhost port=5432 user=postgres password=root sslmode=disable" description:"postgres dsn"`
-	PostgresMaxOpenConnections int    `long:"postgres.max_open_connections" env:"POSTGRES_MAX_OPEN_CONNECTIONS" default:"0" description:"postgres maximal open connections count, 0 means unlimited"`
-	PostgresMaxIdleConnections int    `long:"postgres.max_idle_connections" env:"POSTGRES_MAX_IDLE_CONNECTIONS" default:"5" description:"postgres maximal idle connections count"`
-	PostgresMigrations         string `long:"postgres.migrations" env:"POSTGRES_MIGRATIONS" default:"migrations/postgres" description:"postgres migrations directory"`
-
-	MandrillAPIKey                        string `long:"mandrill.api_key" env:"MANDRILL_API_KEY" description:"mandrillapp.com api key" required:"true"`
-	MandrillVerificationEmailSubject      string `long:"mandrill.verification_email_subject" env:"MANDRILL_VERIFICATION_EMAIL_SUBJECT" default:"furya.xyz - Verification" description:"subject for verification emails"`
-	MandrillVerificationEmailTemplateName string `long:"mandrill.verification_email_template_name" env:"MANDRILL_VERIFICATION_EMAIL_TEMPLATE_NAME" description:"mandrill's verification template to be sent" required:"true"`
-	MandrillWelcomeEmailSubject           string `long:"mandrill.welcome_email_subject" env:"MANDRILL_WELCOME_EMAIL_SUBJECT" default:"furya.xyz - Verified" description:"subject for welcome emails"`
-	MandrillWelcomeEmailTemplateName      string `long:"mandrill.welcome_email_template_name" env:"MANDRILL_WELCOME_EMAIL_TEMPLATE_NAME" description:"mandrill's welcome template to be sent" required:"true"`
-	MandrillFromName                      string `long:"mandrill.from_name" env:"MANDRILL_FROM_NAME" default:"furya.xyz" description:"name for emails sender"`
-	MandrillFromEmail                     string `long:"mandrill.from_email" env:"MANDRILL_FROM_EMAIL" default:"noreply@furyaev.com" description:"email for emails sender"`
-
-	GmailVerificationEmailSubject string `long:"gmail.verification_email_subject" env:"GMAIL_VERIFICATION_EMAIL_SUBJECT" default:"Furya - Verification" description:"subject for verification emails"`
-	GmailWelcomeEmailSubject      string `long:"gmail.welcome_email_subject" env:"GMAIL_WELCOME_EMAIL_SUBJECT" default:"Furya - Verified" description:"subject for welcome emails"`
-	GmailFromName                 string `long:"gmail.from_name" env:"GMAIL_FROM_NAME" default:"Furya" description:"name for emails sender"`
-	GmailFromEmail                string `long:"gmail.from_email" env:"GMAIL_FROM_EMAIL" default:"no-reply@furyaev.com" description:"email for emails sender"`
-	GmailFromPassword             string `long:"gmail.from_password" env:"GMAIL_FROM_PASSWORD" default:"" description:"password for emails sender"`
-	GmailSMTPHost                 string `long:"gmail.smtp_host" env:"GMAIL_SMTP_HOST" default:"smtp.gmail.com" description:"SMTP host"`
-	GmailSMTPPort                 int    `long:"gmail.smtp_port" env:"GMAIL_SMTP_PORT" default:"587" description:"SMTP port"`
+	Config string `long:"config" env:"CONFIG" description:"path to YAML config file, environment variables and flags override its values"`
+
+	Postgres                      string        `long:"postgres" env:"POSTGRES" default:"host=localhost port=5432 user=postgres password=root sslmode=disable" description:"postgres dsn" secret:"true"`
+	PostgresMaxOpenConnections    int           `long:"postgres.max_open_connections" env:"POSTGRES_MAX_OPEN_CONNECTIONS" default:"0" description:"postgres maximal open connections count, 0 means unlimited"`
+	PostgresMaxIdleConnections    int           `long:"postgres.max_idle_connections" env:"POSTGRES_MAX_IDLE_CONNECTIONS" default:"5" description:"postgres maximal idle connections count"`
+	PostgresMigrationsLockTimeout time.Duration `long:"postgres.migrations_lock_timeout" env:"POSTGRES_MIGRATIONS_LOCK_TIMEOUT" default:"1m" description:"how long migrations wait for another process migrating the database"`
+	MigrateOnStart                bool          `long:"migrate-on-start" env:"MIGRATE_ON_START" description:"apply up migrations on start of serve and rewarder"`
 
 	BlockchainNode               string `long:"blockchain.node" env:"BLOCKCHAIN_NODE" default:"http://zeus.testnet.furya.xyz:26657" description:"furya node address"`
-	BlockchainFrom               string `long:"blockchain.from" env:"BLOCKCHAIN_FROM" description:"furya account name to send stakes" required:"true"`
+	BlockchainFrom               string `long:"blockchain.from" env:"BLOCKCHAIN_FROM" description:"furya account name to send stakes, required by commands sending transactions"`
 	BlockchainTxMemo             string `long:"blockchain.tx_memo" env:"BLOCKCHAIN_TX_MEMO" description:"furya tx's memo'"`
 	BlockchainChainID            string `long:"blockchain.chain_id" env:"BLOCKCHAIN_CHAIN_ID" default:"testnet" description:"furya chain id"`
 	BlockchainClientHome         string `long:"blockchain.client_home" env:"BLOCKCHAIN_CLIENT_HOME" default:"~/.furyacli" description:"furyacli home directory"`
 	BlockchainKeyringBackend     string `long:"blockchain.keyring_backend" env:"BLOCKCHAIN_KEYRING_BACKEND" default:"test" description:"furyacli keyring backend"`
-	BlockchainKeyringPromptInput string `long:"blockchain.keyring_prompt_input" env:"BLOCKCHAIN_KEYRING_PROMPT_INPUT" description:"furyacli keyring prompt input"`
+	BlockchainKeyringPromptInput string `long:"blockchain.keyring_prompt_input" env:"BLOCKCHAIN_KEYRING_PROMPT_INPUT" description:"furyacli keyring prompt input" secret:"true"`
 	BlockchainGas                uint64 `long:"blockchain.gas" env:"BLOCKCHAIN_GAS" default:"1000" description:"gas amount"`
 	BlockchainFee                string `long:"blockchain.fee" env:"BLOCKCHAIN_FEE" default:"5000ufury" description:"transaction fee"`
+	BlockchainGRPCNodeURL        string `long:"blockchain.grpc_node_url" env:"BLOCKCHAIN_GRPC_NODE_URL" default:"hera.mainnet.furya.xyz:9090" description:"GRPC endpoint URL"`
 
-	LogLevel  string `long:"log.level" env:"LOG_LEVEL" default:"info" description:"Log level" choice:"debug" choice:"info" choice:"warning" choice:"error"`
-	SentryDSN string `long:"sentry.dsn" env:"SENTRY_DSN" description:"sentry dsn"`
+	ReferralThresholdPDV       string   `long:"referral.threshold_pdv" env:"REFERRAL_THRESHOLD_PDV" default:"0.000100" description:"how many PDV a user should obtain to get a referral reward'"`
+	ReferralThresholdDays      int      `long:"referral.threshold_days" env:"REFERRAL_THRESHOLD_DAYS" default:"30" description:"how many days a user should wait to get a referral reward'"`
+	ReferralUpperLevelPercents []string `long:"referral.upper_level_percents" env:"REFERRAL_UPPER_LEVEL_PERCENTS" env-delim:"," description:"percents of the sender reward for the sender's referrer, the referrer's referrer and so on, upper level rewards are disabled if empty"`
+
+	SlackHookURL string   `long:"slack.hook-url" env:"SLACK_HOOK_URL" description:"slack hook url, events aren't sent to slack if empty" secret:"true"`
+	SlackChannel string   `long:"slack.channel" env:"SLACK_CHANNEL" description:"slack channel, the hook's default channel is used if empty"`
+	SlackEvents  []string `long:"slack.events" env:"SLACK_EVENTS" env-delim:"," default:"dloan_created" default:"reward_paid" description:"event types sent to slack"`
 
-	InitialStakes int64 `long:"blockchain.initial_stakes" env:"BLOCKCHAIN_INITIAL_STAKES" default:"1000000" description:"stakes count to be sent"`
+	EventsWebhookURL    string        `long:"events.webhook_url" env:"EVENTS_WEBHOOK_URL" description:"url events are posted to, the webhook is disabled if empty"`
+	EventsWebhookSecret string        `long:"events.webhook_secret" env:"EVENTS_WEBHOOK_SECRET" description:"secret to sign webhook requests with" secret:"true"`
+	EventsStore         bool          `long:"events.store" env:"EVENTS_STORE" description:"store events to postgres"`
+	EventsTimeout       time.Duration `long:"events.timeout" env:"EVENTS_TIMEOUT" default:"10s" description:"event delivery timeout"`
 
-	ReferralThresholdPDV  string `long:"referral.threshold_pdv" env:"REFERRAL_THRESHOLD_PDV" default:"0.000100" description:"how many PDV a user should obtain to get a referral reward'"`
-	ReferralThresholdDays int    `long:"referral.threshold_days" env:"REFERRAL_THRESHOLD_DAYS" default:"30" description:"how many days a user should wait to get a referral reward'"`
+	HealthCacheTTL time.Duration `long:"health.cache_ttl" env:"HEALTH_CACHE_TTL" default:"5s" description:"how long readiness check results are reused"`
+	HealthTimeout  time.Duration `long:"health.timeout" env:"HEALTH_TIMEOUT" default:"5s" description:"timeout of every readiness check"`
 
-	SupplyNativeNode string `long:"supply.native_node" env:"SUPPLY_NATIVE_NODE" default:"https://zeus.testnet.furya.xyz" description:"native rest node address"`
-	SupplyERC20Node  string `long:"supply.erc20_node" env:"SUPPLY_ERC20_NODE" default:"" description:"erc20 node address"`
+	TracingEndpoint    string  `long:"tracing.endpoint" env:"TRACING_ENDPOINT" description:"host:port of the OTLP/HTTP collector spans are exported to, tracing is disabled if empty"`
+	TracingInsecure    bool    `long:"tracing.insecure" env:"TRACING_INSECURE" description:"export spans over HTTP instead of HTTPS"`
+	TracingSampleRatio float64 `long:"tracing.sample_ratio" env:"TRACING_SAMPLE_RATIO" default:"1" description:"ratio of traced requests and rewarder runs, from 0 to 1"`
 
-	SlackHookURL string `long:"slack.hook-url" env:"SLACK_HOOK_URL" description:"slack hook url"`
-	SlackChannel string `long:"slack.channel" env:"SLACK_CHANNEL" default:"alerts-dloan" description:"slack channel"`
+	LogLevel  string `long:"log.level" env:"LOG_LEVEL" default:"info" description:"Log level" choice:"debug" choice:"info" choice:"warning" choice:"error"`
+	SentryDSN string `long:"sentry.dsn" env:"SENTRY_DSN" description:"sentry dsn" secret:"true"`
 }{}
 
 var errTerminated = errors.New("terminated")
 
+// serviceBroadcastMode is used by the long running commands, they don't wait for the block.
+const serviceBroadcastMode = cliflags.BroadcastSync
+
 func main() {
-	parser := flags.NewParser(&opts, flags.Default)
+	parser := flags.NewParser(&opts, flags.HelpFlag|flags.PassDoubleDash)
 	parser.ShortDescription = "Vulcan"
 	parser.LongDescription = "Vulcan"
 
-	_, err := parser.Parse()
-	if err != nil {
-		if flagsErr, ok := err.(*flags.Error); ok && flagsErr.Type == flags.ErrHelp {
-			parser.WriteHelp(os.Stdout)
-			os.Exit(0)
+	mustAddCommand(parser, "serve", "Run the API server", &serveCommand{})
+	mustAddCommand(parser, "rewarder", "Run the referral rewarder", &rewarderCommand{})
+	mustAddCommand(parser, "migrate", "Manage postgres migrations", &migrateCommand{})
+	mustAddCommand(parser, "distribute", "Send initial stakes to confirmed accounts", &distributeCommand{})
+	mustAddCommand(parser, "admin", "Administrative tools", &adminCommand{})
+
+	parser.CommandHandler = func(command flags.Commander, args []string) error {
+		if err := validate(command); err != nil {
+			return err
 		}
-		logrus.WithError(err).Fatal("error occurred while parsing flags")
+
+		setup(command)
+
+		shutdown := mustInitTracing(parser.Active.Name)
+		defer shutdown()
+
+		return command.Execute(args)
+	}
+
+	if err := config.LoadSecretFiles(parser); err != nil {
+		exit(err)
+	}
+
+	if path := configPath(); path != "" {
+		if err := config.LoadFile(parser, path); err != nil {
+			exit(err)
+		}
+	}
+
+	if _, err := parser.Parse(); err != nil {
+		var validationErr config.ValidationError
+		if flagsErr, ok := err.(*flags.Error); ok {
+			if flagsErr.Type == flags.ErrHelp {
+				fmt.Println(err)
+				os.Exit(0)
+			}
+			exit(err)
+		} else if errors.As(err, &validationErr) {
+			exit(err)
+		}
+		logrus.WithError(err).Fatal("command failed")
+	}
+}
+
+// exit reports errors found before logging is configured.
+func exit(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
+
+// configPath returns the config path before the parsing, since the config file sets defaults of options.
+func configPath() string {
+	var pre struct {
+		Config string `long:"config" env:"CONFIG"`
 	}
 
+	flags.NewParser(&pre, flags.IgnoreUnknown).ParseArgs(os.Args[1:]) // nolint: errcheck
+
+	return pre.Config
+}
+
+func mustAddCommand(parser *flags.Parser, name, description string, data interface{}) {
+	if _, err := parser.AddCommand(name, description, description, data); err != nil {
+		logrus.WithError(err).Fatalf("failed to add %s command", name)
+	}
+}
+
+// validator is implemented by commands validating their options.
+type validator interface {
+	validate(errs *config.ValidationError)
+}
+
+// validate checks the common options and options of the command, all found errors are returned.
+func validate(command flags.Commander) error {
+	var errs config.ValidationError
+
+	errs.Check(opts.Postgres != "", "postgres", "is required")
+	errs.Check(opts.PostgresMaxOpenConnections >= 0, "postgres.max_open_connections", "should not be negative")
+	errs.Check(opts.PostgresMaxIdleConnections >= 0, "postgres.max_idle_connections", "should not be negative")
+	errs.Check(opts.PostgresMigrationsLockTimeout > 0, "postgres.migrations_lock_timeout", "should be positive")
+
+	_, err := sdk.ParseCoinNormalized(opts.BlockchainFee)
+	errs.Add("blockchain.fee", err)
+
+	_, err = sdk.NewFurFromStr(opts.ReferralThresholdPDV)
+	errs.Add("referral.threshold_pdv", err)
+	errs.Check(opts.ReferralThresholdDays >= 0, "referral.threshold_days", "should not be negative")
+	_, err = referral.ParseUpperLevelRewardPercents(opts.ReferralUpperLevelPercents)
+	errs.Add("referral.upper_level_percents", err)
+
+	_, err = events.ParseTypes(opts.SlackEvents)
+	errs.Add("slack.events", err)
+	errs.Check(opts.EventsWebhookURL == "" || opts.EventsWebhookSecret != "", "events.webhook_secret",
+		"is required if events.webhook_url is set")
+	errs.Check(opts.EventsTimeout > 0, "events.timeout", "should be positive")
+
+	errs.Check(opts.HealthCacheTTL >= 0, "health.cache_ttl", "should not be negative")
+	errs.Check(opts.HealthTimeout > 0, "health.timeout", "should be positive")
+
+	errs.Check(opts.TracingSampleRatio >= 0 && opts.TracingSampleRatio <= 1, "tracing.sample_ratio", "should be in [0, 1]")
+
+	if v, ok := command.(validator); ok {
+		v.validate(&errs)
+	}
+
+	return errs.Err()
+}
+
+// setup configures logging and sentry before the command is executed.
+func setup(command flags.Commander) {
 	lvl, _ := logrus.ParseLevel(opts.LogLevel) // err will always be nil
 	logrus.SetLevel(lvl)
 
-	logrus.Info("service started")
-	logrus.Infof("%+v", opts)
+	logrus.Infof("%s", config.Redact(&opts))
+	logrus.Infof("%s", config.Redact(command))
 
 	if opts.SentryDSN != "" {
 		hook, err := sentry.NewHook(sentry.Options{
@@ -129,100 +216,76 @@ func main() {
 		logrus.Info("empty sentry dsn")
 		logrus.Warn("skip sentry initialization")
 	}
+}
 
-	if opts.SlackHookURL != "" && opts.SlackChannel != "" {
-		logrus.AddHook(&slackrus.SlackrusHook{
-			HookURL:        opts.SlackHookURL,
-			AcceptedLevels: []logrus.Level{logrus.InfoLevel},
-			Channel:        opts.SlackChannel,
-			IconEmoji:      ":bread:",
-			Username:       "vulcan",
-			Filters: []slackrus.Filter{
-				func(entry *logrus.Entry) bool {
-					return entry.Data["sender"] == "slack"
-				},
-			},
-		})
-	}
-
-	r := chi.NewMux()
-
-	db := mustGetDB()
-
-	mailSender := gmail.New(&gmail.Config{
-		VerificationSubject: opts.GmailVerificationEmailSubject,
-		WelcomeSubject:      opts.GmailWelcomeEmailSubject,
-		FromName:            opts.GmailFromName,
-		FromEmail:           opts.GmailFromEmail,
-		FromPassword:        opts.GmailFromPassword,
-
-		SMTPPort: opts.GmailSMTPPort,
-		SMTPHost: opts.GmailSMTPHost,
+// mustInitTracing sets up tracing of the command, the returned function flushes spans left in the exporter.
+func mustInitTracing(command string) func() {
+	shutdown, err := tracing.Init(context.Background(), tracing.Config{
+		Endpoint:    opts.TracingEndpoint,
+		Insecure:    opts.TracingInsecure,
+		SampleRatio: opts.TracingSampleRatio,
+		ServiceName: "vulcan-" + command,
+		Version:     health.GetVersion(),
 	})
-
-	nativeNodeConn, err := grpc.Dial(
-		opts.SupplyNativeNode,
-		grpc.WithInsecure(),
-	)
 	if err != nil {
-		logrus.WithError(err).Fatal("failed to create grpc conn to native node")
+		logrus.WithError(err).Fatal("failed to init tracing")
 	}
 
-	sup := supply.New(banktypes.NewQueryClient(nativeNodeConn), opts.SupplyERC20Node)
-	bc := mustGetBroadcaster()
-
-	rc := referral.NewConfig(sdk.MustNewFurFromStr(opts.ReferralThresholdPDV), opts.ReferralThresholdDays)
-
-	server.SetupRouter(
-		service.New(
-			postgres.New(db),
-			mailSender,
-			blockchain.New(bc),
-			sdk.NewInt(opts.InitialStakes),
-			opts.BlockchainTxMemo,
-			rc,
-			opts.RecaptchaSecret,
-		),
-		sup,
-		r,
-		opts.RequestTimeout,
-		strings.Contains(opts.BlockchainNode, "testnet"),
-	)
-
-	health.SetupRouter(r,
-		health.SubjectPinger("postgres", db.PingContext),
-		health.SubjectPinger("blockchain", bc.PingContext),
-		health.SubjectPinger("supply", sup.PingContext),
-	)
-
-	srv := http.Server{
-		Addr:    fmt.Sprintf("%s:%d", opts.Host, opts.Port),
-		Handler: r,
+	return func() {
+		if err := shutdown(context.Background()); err != nil {
+			logrus.WithError(err).Error("failed to shutdown tracing")
+		}
 	}
+}
 
-	gr, _ := errgroup.WithContext(context.Background())
-	gr.Go(srv.ListenAndServe)
+// waitForSignal blocks until the termination signal, then cancels the context and shuts the servers down.
+func waitForSignal(cancel context.CancelFunc, servers ...*http.Server) error {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
 
-	gr.Go(func() error {
-		sigs := make(chan os.Signal, 1)
-		signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
+	s := <-sigs
 
-		s := <-sigs
+	logrus.Infof("terminating by %s signal", s)
 
-		logrus.Infof("terminating by %s signal", s)
+	// stop background jobs, errgroup waits for the work in progress
+	cancel()
 
+	for _, srv := range servers {
 		if err := srv.Shutdown(context.Background()); err != nil {
 			logrus.WithError(err).Error("failed to gracefully shutdown server")
 		}
+	}
 
-		return errTerminated
-	})
+	return errTerminated
+}
+
+func isTerminated(err error) bool {
+	return err == nil || errors.Is(err, errTerminated) || errors.Is(err, http.ErrServerClosed)
+}
 
-	logrus.Info("service started")
+func mustGetEventBus(s storage.Storage) *events.Bus {
+	client := &http.Client{Timeout: opts.EventsTimeout}
 
-	if err := gr.Wait(); err != nil && !errors.Is(err, errTerminated) && !errors.Is(err, http.ErrServerClosed) {
-		logrus.WithError(err).Fatal("service unexpectedly closed")
+	// partners' webhook subscriptions, deliveries are sent by the serve command
+	sinks := []events.Sink{webhooks.NewSink(s)}
+	if opts.SlackHookURL != "" {
+		types, err := events.ParseTypes(opts.SlackEvents)
+		if err != nil {
+			logrus.WithError(err).Fatal("failed to parse slack events")
+		}
+		sinks = append(sinks, events.Filter(events.NewSlackSink(client, opts.SlackHookURL, opts.SlackChannel), types...))
+	}
+	if opts.EventsWebhookURL != "" {
+		if opts.EventsWebhookSecret == "" {
+			logrus.Fatal("events webhook secret is required")
+		}
+		sinks = append(sinks, events.NewWebhookSink(client, opts.EventsWebhookURL, []byte(opts.EventsWebhookSecret)))
 	}
+	if opts.EventsStore {
+		sinks = append(sinks, events.NewStorageSink(s))
+	}
+
+	return events.NewBus(opts.EventsTimeout, sinks...)
 }
 
 func mustGetDB() *sql.DB {
@@ -237,38 +300,14 @@ func mustGetDB() *sql.DB {
 		logrus.WithError(err).Fatal("failed to ping postgres")
 	}
 
-	driver, err := migratep.WithInstance(db, &migratep.Config{})
-	if err != nil {
-		logrus.WithError(err).Fatal("failed to create database migrate driver")
-	}
-
-	migrator, err := migrate.NewWithDatabaseInstance(fmt.Sprintf("file://%s", opts.PostgresMigrations), "postgres", driver)
-	if err != nil {
-		logrus.WithError(err).Fatal("failed to create migrator")
-	}
-
-	switch v, d, err := migrator.Version(); err {
-	case nil:
-		logrus.Infof("database version %d with dirty state %t", v, d)
-	case migrate.ErrNilVersion:
-		logrus.Info("database version: nil")
-	default:
-		logrus.WithError(err).Fatal("failed to get version")
-	}
-
-	switch err := migrator.Up(); err {
-	case nil:
-		logrus.Info("database was migrated")
-	case migrate.ErrNoChange:
-		logrus.Info("database is up-to-date")
-	default:
-		logrus.WithError(err).Fatal("failed to migrate db")
-	}
-
 	return db
 }
 
-func mustGetBroadcaster() broadcaster.Broadcaster {
+func mustGetBroadcaster(mode string) broadcaster.Broadcaster {
+	if opts.BlockchainFrom == "" {
+		logrus.Fatal("blockchain.from is required")
+	}
+
 	fee, err := sdk.ParseCoinNormalized(opts.BlockchainFee)
 	if err != nil {
 		logrus.WithError(err).Error("failed to parse fee")
@@ -279,7 +318,7 @@ func mustGetBroadcaster() broadcaster.Broadcaster {
 		KeyringBackend:     opts.BlockchainKeyringBackend,
 		KeyringPromptInput: opts.BlockchainKeyringPromptInput,
 		NodeURI:            opts.BlockchainNode,
-		BroadcastMode:      cliflags.BroadcastSync,
+		BroadcastMode:      mode,
 		From:               opts.BlockchainFrom,
 		ChainID:            opts.BlockchainChainID,
 		Gas:                opts.BlockchainGas,
@@ -293,3 +332,15 @@ func mustGetBroadcaster() broadcaster.Broadcaster {
 
 	return b
 }
+
+func mustGetReferralConfig() referral.Config {
+	rc := referral.NewConfig(sdk.MustNewFurFromStr(opts.ReferralThresholdPDV), opts.ReferralThresholdDays)
+
+	percents, err := referral.ParseUpperLevelRewardPercents(opts.ReferralUpperLevelPercents)
+	if err != nil {
+		logrus.WithError(err).Fatal("failed to parse upper level reward percents")
+	}
+	rc.UpperLevelRewardPercents = percents
+
+	return rc
+}
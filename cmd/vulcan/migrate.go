@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/sirupsen/logrus"
+
+	"github.com/TessorNetwork/vulcan/internal/storage/postgres"
+)
+
+type migrateCommand struct {
+	Up     migrateUpCommand     `command:"up" description:"Apply all up migrations"`
+	Down   migrateDownCommand   `command:"down" description:"Apply N down migrations"`
+	Force  migrateForceCommand  `command:"force" description:"Set the version without running migrations and clear the dirty state"`
+	Status migrateStatusCommand `command:"status" description:"Print the applied and the latest versions"`
+}
+
+type migrateUpCommand struct{}
+
+// Execute applies all up migrations.
+func (migrateUpCommand) Execute([]string) error {
+	return withMigrator(up)
+}
+
+type migrateDownCommand struct {
+	Args struct {
+		N uint `positional-arg-name:"N" required:"true"`
+	} `positional-args:"yes"`
+}
+
+// Execute applies N down migrations.
+func (c *migrateDownCommand) Execute([]string) error {
+	if c.Args.N == 0 {
+		return fmt.Errorf("N should be positive")
+	}
+
+	return withMigrator(func(migrator *migrate.Migrate) error {
+		if err := migrator.Steps(-int(c.Args.N)); err != nil {
+			return fmt.Errorf("failed to migrate db down: %w", err)
+		}
+
+		logrus.Infof("%d migrations were reverted", c.Args.N)
+
+		return nil
+	})
+}
+
+type migrateForceCommand struct {
+	Args struct {
+		Version int `positional-arg-name:"version" required:"true"`
+	} `positional-args:"yes"`
+}
+
+// Execute sets the version, it is used to recover the dirty state after the failed migration is fixed manually.
+func (c *migrateForceCommand) Execute([]string) error {
+	return withMigrator(func(migrator *migrate.Migrate) error {
+		if err := migrator.Force(c.Args.Version); err != nil {
+			return fmt.Errorf("failed to force version: %w", err)
+		}
+
+		logrus.Infof("version %d is forced", c.Args.Version)
+
+		return nil
+	})
+}
+
+type migrateStatusCommand struct{}
+
+// Execute prints the applied and the latest versions.
+func (migrateStatusCommand) Execute([]string) error {
+	db := mustGetDB()
+	defer db.Close() // nolint: errcheck
+
+	version, dirty, err := postgres.GetMigrationVersion(context.Background(), db)
+	if err != nil {
+		return err
+	}
+
+	latest, err := postgres.LatestMigration()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("version: %d\ndirty: %t\nlatest: %d\n", version, dirty, latest)
+
+	return nil
+}
+
+// withMigrator runs f holding the migration lock, so concurrent processes don't migrate at once.
+func withMigrator(f func(migrator *migrate.Migrate) error) error {
+	// the migrator closes its db, so it doesn't share the storage one
+	db, err := sql.Open("postgres", opts.Postgres)
+	if err != nil {
+		return fmt.Errorf("failed to create postgres connection: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), opts.PostgresMigrationsLockTimeout)
+	defer cancel()
+
+	unlock, err := postgres.LockMigrations(ctx, db)
+	if err != nil {
+		db.Close() // nolint: errcheck
+		return err
+	}
+
+	migrator, err := postgres.NewMigrator(db)
+	if err != nil {
+		unlock()
+		db.Close() // nolint: errcheck
+		return err
+	}
+
+	defer func() {
+		unlock()
+		migrator.Close() // nolint: errcheck
+	}()
+
+	return f(migrator)
+}
+
+func up(migrator *migrate.Migrate) error {
+	switch v, d, err := migrator.Version(); {
+	case err == nil:
+		logrus.Infof("database version %d with dirty state %t", v, d)
+	case errors.Is(err, migrate.ErrNilVersion):
+		logrus.Info("database version: nil")
+	default:
+		return fmt.Errorf("failed to get version: %w", err)
+	}
+
+	var dirtyErr migrate.ErrDirty
+	switch err := migrator.Up(); {
+	case err == nil:
+		logrus.Info("database was migrated")
+	case errors.Is(err, migrate.ErrNoChange):
+		logrus.Info("database is up-to-date")
+	case errors.As(err, &dirtyErr):
+		return fmt.Errorf("database is dirty at version %d, fix the failed migration and run `migrate force`: %w",
+			dirtyErr.Version, err)
+	default:
+		return fmt.Errorf("failed to migrate db: %w", err)
+	}
+
+	return nil
+}
+
+// checkMigrations applies up migrations if migrate-on-start is set, otherwise it only reports the database version.
+func checkMigrations(db *sql.DB) {
+	if opts.MigrateOnStart {
+		if err := withMigrator(up); err != nil {
+			logrus.WithError(err).Fatal("failed to migrate db")
+		}
+		return
+	}
+
+	version, dirty, err := postgres.GetMigrationVersion(context.Background(), db)
+	if err != nil {
+		logrus.WithError(err).Error("failed to get database version")
+		return
+	}
+
+	latest, err := postgres.LatestMigration()
+	if err != nil {
+		logrus.WithError(err).Error("failed to get latest migration")
+		return
+	}
+
+	logger := logrus.WithFields(logrus.Fields{
+		"version": version,
+		"dirty":   dirty,
+		"latest":  latest,
+	})
+
+	switch {
+	case dirty:
+		logger.Error("database is dirty, fix the failed migration and run `migrate force`")
+	case version < latest:
+		logger.Warn("database has pending migrations, run `migrate up`")
+	case version > latest:
+		logger.Warn("database is newer than the binary")
+	default:
+		logger.Info("database is up-to-date")
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc"
+
+	tokentypes "github.com/TessorNetwork/furya/x/token/types"
+
+	"github.com/TessorNetwork/vulcan/internal/blockchain"
+	"github.com/TessorNetwork/vulcan/internal/config"
+	"github.com/TessorNetwork/vulcan/internal/events"
+	"github.com/TessorNetwork/vulcan/internal/health"
+	"github.com/TessorNetwork/vulcan/internal/metrics"
+	"github.com/TessorNetwork/vulcan/internal/referral"
+	"github.com/TessorNetwork/vulcan/internal/storage/postgres"
+)
+
+// nolint:lll
+type rewarderCommand struct {
+	Host string `long:"http.host" env:"HTTP_HOST" default:"0.0.0.0" description:"IP to listen on"`
+	Port int    `long:"http.port" env:"HTTP_PORT" default:"8080" description:"port to listen on for metrics and health requests"`
+
+	RewarderInterval time.Duration `long:"rewarder.interval" env:"REWARDER_INTERVAL" default:"1h" description:"how often referrals are checked, the rewarder isn't ready if the last successful run is older than two intervals"`
+}
+
+func (c *rewarderCommand) validate(errs *config.ValidationError) {
+	errs.Check(c.Port >= 0 && c.Port <= 65535, "http.port", "should be in [0, 65535]")
+	errs.Check(c.RewarderInterval > 0, "rewarder.interval", "should be positive")
+	errs.Check(opts.BlockchainFrom != "", "blockchain.from", "is required")
+}
+
+// Execute runs the referral rewarder with metrics and health endpoints.
+func (c *rewarderCommand) Execute([]string) error {
+	db := mustGetDB()
+	checkMigrations(db)
+
+	bus := mustGetEventBus(postgres.New(db))
+	defer bus.Close()
+
+	rewarder, err := newRewarder(db, blockchain.New(mustGetBroadcaster(serviceBroadcastMode)), bus)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	gr, _ := errgroup.WithContext(context.Background())
+	gr.Go(func() error {
+		// returns after the reward in progress is finished
+		rewarder.Run(ctx, c.RewarderInterval)
+		return nil
+	})
+
+	r := chi.NewMux()
+	r.Handle("/metrics", metrics.Handler())
+	health.SetupRouter(r, health.NewChecker(opts.HealthCacheTTL, opts.HealthTimeout,
+		health.Critical("postgres", db.PingContext),
+		health.Check{Name: "rewarder", Pinger: rewarder, Critical: true},
+	))
+
+	srv := http.Server{
+		Addr:    fmt.Sprintf("%s:%d", c.Host, c.Port),
+		Handler: r,
+	}
+	gr.Go(srv.ListenAndServe)
+
+	gr.Go(func() error {
+		return waitForSignal(cancel, &srv)
+	})
+
+	logrus.Info("service started")
+
+	if err := gr.Wait(); !isTerminated(err) {
+		return fmt.Errorf("service unexpectedly closed: %w", err)
+	}
+
+	return nil
+}
+
+func newRewarder(db *sql.DB, bc blockchain.Blockchain, publisher events.Publisher) (*referral.Rewarder, error) {
+	nativeNodeConn, err := grpc.Dial(
+		opts.BlockchainGRPCNodeURL,
+		grpc.WithInsecure(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create grpc conn to native node: %w", err)
+	}
+
+	return referral.NewRewarder(
+		postgres.New(db),
+		bc,
+		tokentypes.NewQueryClient(nativeNodeConn),
+		publisher,
+		mustGetReferralConfig(),
+	), nil
+}
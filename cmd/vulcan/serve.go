@@ -0,0 +1,237 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	"github.com/go-chi/chi"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc"
+
+	"github.com/TessorNetwork/vulcan/internal/auth"
+	"github.com/TessorNetwork/vulcan/internal/blockchain"
+	"github.com/TessorNetwork/vulcan/internal/config"
+	"github.com/TessorNetwork/vulcan/internal/health"
+	"github.com/TessorNetwork/vulcan/internal/mail/gmail"
+	"github.com/TessorNetwork/vulcan/internal/metrics"
+	"github.com/TessorNetwork/vulcan/internal/server"
+	"github.com/TessorNetwork/vulcan/internal/service"
+	"github.com/TessorNetwork/vulcan/internal/storage/postgres"
+	"github.com/TessorNetwork/vulcan/internal/supply"
+	"github.com/TessorNetwork/vulcan/internal/webhooks"
+)
+
+// nolint:lll
+type serveCommand struct {
+	Host            string        `long:"http.host" env:"HTTP_HOST" default:"0.0.0.0" description:"IP to listen on"`
+	Port            int           `long:"http.port" env:"HTTP_PORT" default:"8080" description:"port to listen on for insecure connections, defaults to a random value"`
+	RequestTimeout  time.Duration `long:"http.request-timeout" env:"HTTP_REQUEST_TIMEOUT" default:"45s" description:"request processing timeout"`
+	RecaptchaSecret string        `long:"http.recaptcha_secret" env:"HTTP_RECAPTCHA_SECRET" required:"true" description:"recaptcha secret" secret:"true"`
+	AdminToken      string        `long:"http.admin_token" env:"HTTP_ADMIN_TOKEN" description:"bearer token for admin endpoints, admin endpoints are disabled if empty" secret:"true"`
+	SessionSecret   string        `long:"http.session_secret" env:"HTTP_SESSION_SECRET" description:"secret to sign session tokens with, account endpoints are public if empty" secret:"true"`
+	SessionTTL      time.Duration `long:"http.session_ttl" env:"HTTP_SESSION_TTL" default:"1h" description:"session token lifetime"`
+	NonceSecret     string        `long:"http.nonce_secret" env:"HTTP_NONCE_SECRET" description:"secret to sign login and installation nonces with, a random one is generated if empty, so nonces are valid only within the instance until it restarts" secret:"true"`
+
+	MandrillAPIKey                        string `long:"mandrill.api_key" env:"MANDRILL_API_KEY" description:"mandrillapp.com api key" required:"true" secret:"true"`
+	MandrillVerificationEmailSubject      string `long:"mandrill.verification_email_subject" env:"MANDRILL_VERIFICATION_EMAIL_SUBJECT" default:"furya.xyz - Verification" description:"subject for verification emails"`
+	MandrillVerificationEmailTemplateName string `long:"mandrill.verification_email_template_name" env:"MANDRILL_VERIFICATION_EMAIL_TEMPLATE_NAME" description:"mandrill's verification template to be sent" required:"true"`
+	MandrillWelcomeEmailSubject           string `long:"mandrill.welcome_email_subject" env:"MANDRILL_WELCOME_EMAIL_SUBJECT" default:"furya.xyz - Verified" description:"subject for welcome emails"`
+	MandrillWelcomeEmailTemplateName      string `long:"mandrill.welcome_email_template_name" env:"MANDRILL_WELCOME_EMAIL_TEMPLATE_NAME" description:"mandrill's welcome template to be sent" required:"true"`
+	MandrillFromName                      string `long:"mandrill.from_name" env:"MANDRILL_FROM_NAME" default:"furya.xyz" description:"name for emails sender"`
+	MandrillFromEmail                     string `long:"mandrill.from_email" env:"MANDRILL_FROM_EMAIL" default:"noreply@furyaev.com" description:"email for emails sender"`
+
+	GmailVerificationEmailSubject string `long:"gmail.verification_email_subject" env:"GMAIL_VERIFICATION_EMAIL_SUBJECT" default:"Furya - Verification" description:"subject for verification emails"`
+	GmailWelcomeEmailSubject      string `long:"gmail.welcome_email_subject" env:"GMAIL_WELCOME_EMAIL_SUBJECT" default:"Furya - Verified" description:"subject for welcome emails"`
+	GmailFromName                 string `long:"gmail.from_name" env:"GMAIL_FROM_NAME" default:"Furya" description:"name for emails sender"`
+	GmailFromEmail                string `long:"gmail.from_email" env:"GMAIL_FROM_EMAIL" default:"no-reply@furyaev.com" description:"email for emails sender"`
+	GmailFromPassword             string `long:"gmail.from_password" env:"GMAIL_FROM_PASSWORD" default:"" description:"password for emails sender" secret:"true"`
+	GmailSMTPHost                 string `long:"gmail.smtp_host" env:"GMAIL_SMTP_HOST" default:"smtp.gmail.com" description:"SMTP host"`
+	GmailSMTPPort                 int    `long:"gmail.smtp_port" env:"GMAIL_SMTP_PORT" default:"587" description:"SMTP port"`
+
+	InitialStakes int64 `long:"blockchain.initial_stakes" env:"BLOCKCHAIN_INITIAL_STAKES" default:"1000000" description:"stakes count to be sent"`
+
+	SupplyNativeNode   string        `long:"supply.native_node" env:"SUPPLY_NATIVE_NODE" default:"https://zeus.testnet.furya.xyz" description:"native rest node address"`
+	SupplyERC20Node    string        `long:"supply.erc20_node" env:"SUPPLY_ERC20_NODE" default:"" description:"ethereum node address, used if supply.config is empty"`
+	SupplyPollInterval time.Duration `long:"supply.poll_interval" env:"SUPPLY_POLL_INTERVAL" default:"1h" description:"how often the supply is refreshed"`
+	SupplyPollTimeout  time.Duration `long:"supply.poll_timeout" env:"SUPPLY_POLL_TIMEOUT" default:"1m" description:"supply refresh timeout"`
+	SupplyStaleness    time.Duration `long:"supply.staleness" env:"SUPPLY_STALENESS" default:"3h" description:"supply is unavailable if it isn't updated for longer than the duration, 0 means no limit"`
+	SupplyConfig       string        `long:"supply.config" env:"SUPPLY_CONFIG" default:"" description:"path to YAML file with EVM chains and accounts excluded from the circulating supply, the ethereum token without the reserved and locked balances is used if empty"`
+
+	WebhooksPollInterval time.Duration `long:"webhooks.poll_interval" env:"WEBHOOKS_POLL_INTERVAL" default:"10s" description:"how often pending partner webhook deliveries are sent"`
+	WebhooksBatchSize    int           `long:"webhooks.batch_size" env:"WEBHOOKS_BATCH_SIZE" default:"20" description:"maximal number of partner webhook deliveries sent concurrently"`
+	WebhooksMaxAttempts  int           `long:"webhooks.max_attempts" env:"WEBHOOKS_MAX_ATTEMPTS" default:"10" description:"number of attempts after which the delivery becomes dead"`
+	WebhooksMinBackoff   time.Duration `long:"webhooks.min_backoff" env:"WEBHOOKS_MIN_BACKOFF" default:"30s" description:"delay after the first failed attempt, doubles with every next one"`
+	WebhooksMaxBackoff   time.Duration `long:"webhooks.max_backoff" env:"WEBHOOKS_MAX_BACKOFF" default:"6h" description:"maximal delay between attempts"`
+	WebhooksTimeout      time.Duration `long:"webhooks.timeout" env:"WEBHOOKS_TIMEOUT" default:"10s" description:"partner webhook request timeout"`
+
+	WithRewarder     bool          `long:"with-rewarder" env:"WITH_REWARDER" description:"run the referral rewarder in the same process"`
+	RewarderInterval time.Duration `long:"rewarder.interval" env:"REWARDER_INTERVAL" default:"1h" description:"how often referrals are checked, the rewarder isn't ready if the last successful run is older than two intervals"`
+}
+
+func (c *serveCommand) validate(errs *config.ValidationError) {
+	errs.Check(c.Port >= 0 && c.Port <= 65535, "http.port", "should be in [0, 65535]")
+	errs.Check(c.RequestTimeout > 0, "http.request-timeout", "should be positive")
+	errs.Check(c.SessionTTL > 0, "http.session_ttl", "should be positive")
+	errs.Check(c.GmailSMTPPort > 0 && c.GmailSMTPPort <= 65535, "gmail.smtp_port", "should be in [1, 65535]")
+	errs.Check(c.InitialStakes > 0, "blockchain.initial_stakes", "should be positive")
+	errs.Check(c.SupplyPollInterval > 0, "supply.poll_interval", "should be positive")
+	errs.Check(c.SupplyPollTimeout > 0, "supply.poll_timeout", "should be positive")
+	errs.Check(c.SupplyStaleness >= 0, "supply.staleness", "should not be negative")
+	errs.Check(c.WebhooksPollInterval > 0, "webhooks.poll_interval", "should be positive")
+	errs.Check(c.WebhooksBatchSize > 0, "webhooks.batch_size", "should be positive")
+	errs.Check(c.WebhooksMaxAttempts > 0, "webhooks.max_attempts", "should be positive")
+	errs.Check(c.WebhooksMinBackoff > 0, "webhooks.min_backoff", "should be positive")
+	errs.Check(c.WebhooksMaxBackoff >= c.WebhooksMinBackoff, "webhooks.max_backoff", "should not be less than webhooks.min_backoff")
+	errs.Check(c.WebhooksTimeout > 0, "webhooks.timeout", "should be positive")
+	errs.Check(!c.WithRewarder || c.RewarderInterval > 0, "rewarder.interval", "should be positive")
+	errs.Check(opts.BlockchainFrom != "", "blockchain.from", "is required")
+}
+
+// Execute runs the API server, the supply poller and the partner webhooks dispatcher.
+func (c *serveCommand) Execute([]string) error {
+	db := mustGetDB()
+	checkMigrations(db)
+
+	r := chi.NewMux()
+
+	mailSender := gmail.New(&gmail.Config{
+		VerificationSubject: c.GmailVerificationEmailSubject,
+		WelcomeSubject:      c.GmailWelcomeEmailSubject,
+		FromName:            c.GmailFromName,
+		FromEmail:           c.GmailFromEmail,
+		FromPassword:        c.GmailFromPassword,
+
+		SMTPPort: c.GmailSMTPPort,
+		SMTPHost: c.GmailSMTPHost,
+	})
+
+	nativeNodeConn, err := grpc.Dial(
+		c.SupplyNativeNode,
+		grpc.WithInsecure(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create grpc conn to native node: %w", err)
+	}
+
+	supplyConfig := supply.DefaultConfig(c.SupplyERC20Node)
+	if c.SupplyConfig != "" {
+		supplyConfig, err = supply.LoadConfig(c.SupplyConfig)
+		if err != nil {
+			return fmt.Errorf("failed to load supply config: %w", err)
+		}
+	}
+
+	sup := supply.New(banktypes.NewQueryClient(nativeNodeConn), supplyConfig, c.SupplyStaleness, postgres.New(db))
+	bc := mustGetBroadcaster(serviceBroadcastMode)
+	rc := mustGetReferralConfig()
+
+	bus := mustGetEventBus(postgres.New(db))
+	defer bus.Close()
+
+	var sessions *auth.Sessions
+	if c.SessionSecret != "" {
+		sessions = auth.NewSessions([]byte(c.SessionSecret), c.SessionTTL)
+	}
+
+	nonceSecret := []byte(c.NonceSecret)
+	if len(nonceSecret) == 0 {
+		nonceSecret = make([]byte, 32)
+		if _, err := rand.Read(nonceSecret); err != nil {
+			return fmt.Errorf("failed to generate nonce secret: %w", err)
+		}
+		logrus.Warn("nonce secret is empty, nonces are valid only within the instance until it restarts")
+	}
+
+	server.SetupRouter(
+		service.WithTracing(service.New(
+			postgres.New(db),
+			mailSender,
+			blockchain.New(bc),
+			bus,
+			sdk.NewInt(c.InitialStakes),
+			opts.BlockchainTxMemo,
+			rc,
+			c.RecaptchaSecret,
+			auth.NewNonces(nonceSecret),
+		)),
+		sup,
+		r,
+		c.RequestTimeout,
+		strings.Contains(opts.BlockchainNode, "testnet"),
+		c.AdminToken,
+		sessions,
+	)
+
+	checks := []health.Check{
+		health.Critical("postgres", db.PingContext),
+		health.Critical("blockchain", bc.PingContext),
+		// stale supply affects only supply endpoints, the supply reports the status of its sources
+		{Name: "supply", Pinger: sup},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	gr, _ := errgroup.WithContext(context.Background())
+
+	if c.WithRewarder {
+		rewarder, err := newRewarder(db, blockchain.New(bc), bus)
+		if err != nil {
+			cancel()
+			return err
+		}
+
+		// the API doesn't depend on the rewarder, so its failure only degrades the status
+		checks = append(checks, health.Check{Name: "rewarder", Pinger: rewarder})
+
+		gr.Go(func() error {
+			// returns after the reward in progress is finished
+			rewarder.Run(ctx, c.RewarderInterval)
+			return nil
+		})
+	}
+
+	health.SetupRouter(r, health.NewChecker(opts.HealthCacheTTL, opts.HealthTimeout, checks...))
+	r.Handle("/metrics", metrics.Handler())
+
+	srv := http.Server{
+		Addr:    fmt.Sprintf("%s:%d", c.Host, c.Port),
+		Handler: r,
+	}
+
+	gr.Go(srv.ListenAndServe)
+
+	gr.Go(func() error {
+		sup.Run(ctx, c.SupplyPollInterval, c.SupplyPollTimeout)
+		return nil
+	})
+
+	gr.Go(func() error {
+		webhooks.NewDispatcher(postgres.New(db), webhooks.Config{
+			BatchSize:   c.WebhooksBatchSize,
+			MaxAttempts: c.WebhooksMaxAttempts,
+			MinBackoff:  c.WebhooksMinBackoff,
+			MaxBackoff:  c.WebhooksMaxBackoff,
+			Timeout:     c.WebhooksTimeout,
+		}).Run(ctx, c.WebhooksPollInterval)
+		return nil
+	})
+
+	gr.Go(func() error {
+		return waitForSignal(cancel, &srv)
+	})
+
+	logrus.Info("service started")
+
+	if err := gr.Wait(); !isTerminated(err) {
+		return fmt.Errorf("service unexpectedly closed: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+// Package auth contains wallet-signature authentication helpers.
+package auth
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// ErrInvalidSignature is returned when the signature doesn't match the message or the address.
+var ErrInvalidSignature = fmt.Errorf("invalid signature")
+
+// signDoc is ADR-036 sign doc. Fields are declared in alphabetical order to get canonical JSON.
+type signDoc struct {
+	AccountNumber string    `json:"account_number"`
+	ChainID       string    `json:"chain_id"`
+	Fee           signFee   `json:"fee"`
+	Memo          string    `json:"memo"`
+	Msgs          []signMsg `json:"msgs"`
+	Sequence      string    `json:"sequence"`
+}
+
+type signFee struct {
+	Amount []struct{} `json:"amount"`
+	Gas    string     `json:"gas"`
+}
+
+type signMsg struct {
+	Type  string        `json:"type"`
+	Value signMsgValues `json:"value"`
+}
+
+type signMsgValues struct {
+	Data   string `json:"data"`
+	Signer string `json:"signer"`
+}
+
+// GetADR036SignBytes returns bytes of ADR-036 sign doc for the arbitrary data signed by the signer.
+func GetADR036SignBytes(signer string, data []byte) []byte {
+	b, _ := json.Marshal(signDoc{ // nolint:errchkjson
+		AccountNumber: "0",
+		ChainID:       "",
+		Fee:           signFee{Amount: []struct{}{}, Gas: "0"},
+		Memo:          "",
+		Msgs: []signMsg{{
+			Type: "sign/MsgSignData",
+			Value: signMsgValues{
+				Data:   base64.StdEncoding.EncodeToString(data),
+				Signer: signer,
+			},
+		}},
+		Sequence: "0",
+	})
+
+	return b
+}
+
+// VerifyADR036 checks the data is signed by the address' key according to ADR-036.
+func VerifyADR036(address string, pubKey, signature, data []byte) error {
+	if len(pubKey) != secp256k1.PubKeySize {
+		return fmt.Errorf("%w: invalid public key", ErrInvalidSignature)
+	}
+
+	pk := &secp256k1.PubKey{Key: pubKey}
+
+	if sdk.AccAddress(pk.Address()).String() != address {
+		return fmt.Errorf("%w: public key doesn't belong to address", ErrInvalidSignature)
+	}
+
+	if !pk.VerifySignature(GetADR036SignBytes(address, data), signature) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetADR036SignBytes(t *testing.T) {
+	require.Equal(t,
+		`{"account_number":"0","chain_id":"","fee":{"amount":[],"gas":"0"},"memo":"",`+
+			`"msgs":[{"type":"sign/MsgSignData","value":{"data":"aGVsbG8=","signer":"furya1signer"}}],"sequence":"0"}`,
+		string(GetADR036SignBytes("furya1signer", []byte("hello"))),
+	)
+}
+
+func TestVerifyADR036(t *testing.T) {
+	pk := secp256k1.GenPrivKey()
+	address := sdk.AccAddress(pk.PubKey().Address()).String()
+
+	sig, err := pk.Sign(GetADR036SignBytes(address, []byte("nonce")))
+	require.NoError(t, err)
+
+	require.NoError(t, VerifyADR036(address, pk.PubKey().Bytes(), sig, []byte("nonce")))
+
+	require.True(t, errors.Is(VerifyADR036(address, pk.PubKey().Bytes(), sig, []byte("other")), ErrInvalidSignature))
+	require.True(t, errors.Is(VerifyADR036(address, []byte{1, 2, 3}, sig, []byte("nonce")), ErrInvalidSignature))
+
+	other := secp256k1.GenPrivKey()
+	require.True(t, errors.Is(VerifyADR036(address, other.PubKey().Bytes(), sig, []byte("nonce")), ErrInvalidSignature))
+}
+
+func TestSessions(t *testing.T) {
+	s := NewSessions([]byte("secret"), time.Hour)
+
+	token, expiresAt := s.Issue("address")
+	require.True(t, expiresAt.After(time.Now()))
+
+	address, err := s.Verify(token)
+	require.NoError(t, err)
+	require.Equal(t, "address", address)
+
+	_, err = NewSessions([]byte("another"), time.Hour).Verify(token)
+	require.True(t, errors.Is(err, ErrInvalidToken))
+
+	_, err = s.Verify(token + "x")
+	require.True(t, errors.Is(err, ErrInvalidToken))
+
+	_, err = s.Verify("garbage")
+	require.True(t, errors.Is(err, ErrInvalidToken))
+
+	expired, _ := NewSessions([]byte("secret"), -time.Hour).Issue("address")
+	_, err = s.Verify(expired)
+	require.True(t, errors.Is(err, ErrInvalidToken))
+}
+
+func TestNonces(t *testing.T) {
+	n := NewNonces([]byte("secret"))
+
+	nonce, expiresAt, err := n.Issue("address", "login", time.Minute)
+	require.NoError(t, err)
+	require.True(t, expiresAt.After(time.Now()))
+
+	exp, err := n.Verify("address", "login", nonce)
+	require.NoError(t, err)
+	require.Equal(t, expiresAt, exp)
+
+	other, _, err := n.Issue("address", "login", time.Minute)
+	require.NoError(t, err)
+	require.NotEqual(t, nonce, other)
+
+	_, err = NewNonces([]byte("another")).Verify("address", "login", nonce)
+	require.True(t, errors.Is(err, ErrInvalidNonce))
+
+	_, err = n.Verify("another", "login", nonce)
+	require.True(t, errors.Is(err, ErrInvalidNonce))
+
+	_, err = n.Verify("address", "install", nonce)
+	require.True(t, errors.Is(err, ErrInvalidNonce))
+
+	_, err = n.Verify("address", "login", nonce+"x")
+	require.True(t, errors.Is(err, ErrInvalidNonce))
+
+	_, err = n.Verify("address", "login", "garbage")
+	require.True(t, errors.Is(err, ErrInvalidNonce))
+
+	expired, _, err := n.Issue("address", "login", -time.Minute)
+	require.NoError(t, err)
+	_, err = n.Verify("address", "login", expired)
+	require.True(t, errors.Is(err, ErrInvalidNonce))
+}
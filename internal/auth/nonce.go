@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const nonceBytesSize = 16
+
+// ErrInvalidNonce is returned when the nonce is malformed, forged, issued for another address or purpose, or expired.
+var ErrInvalidNonce = fmt.Errorf("invalid nonce")
+
+// Nonces issues and verifies HMAC signed nonces, so issued nonces aren't stored and anybody can request them.
+type Nonces struct {
+	secret []byte
+}
+
+// NewNonces creates a new instance of Nonces.
+func NewNonces(secret []byte) *Nonces {
+	return &Nonces{
+		secret: secret,
+	}
+}
+
+// Issue returns a nonce for the address and the purpose and its expiration time.
+func (n *Nonces) Issue(address, purpose string, ttl time.Duration) (string, time.Time, error) {
+	b := make([]byte, nonceBytesSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	expiresAt := time.Now().Add(ttl).UTC().Truncate(time.Second)
+
+	payload := fmt.Sprintf("%d.%s", expiresAt.Unix(), hex.EncodeToString(b))
+
+	return payload + "." + encode(n.sign(address, purpose, payload)), expiresAt, nil
+}
+
+// Verify checks the nonce is issued for the address and the purpose and returns its expiration time.
+func (n *Nonces) Verify(address, purpose, nonce string) (time.Time, error) {
+	parts := strings.Split(nonce, ".")
+	if len(parts) != 3 {
+		return time.Time{}, ErrInvalidNonce
+	}
+
+	payload := parts[0] + "." + parts[1]
+
+	mac, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return time.Time{}, ErrInvalidNonce
+	}
+
+	if !hmac.Equal(mac, n.sign(address, purpose, payload)) {
+		return time.Time{}, ErrInvalidNonce
+	}
+
+	exp, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil || time.Now().Unix() >= exp {
+		return time.Time{}, ErrInvalidNonce
+	}
+
+	return time.Unix(exp, 0).UTC(), nil
+}
+
+func (n *Nonces) sign(address, purpose, payload string) []byte {
+	h := hmac.New(sha256.New, n.secret)
+	h.Write([]byte(address + "|" + purpose + "|" + payload)) // nolint:errcheck
+	return h.Sum(nil)
+}
@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidToken is returned when the session token is malformed, forged or expired.
+var ErrInvalidToken = fmt.Errorf("invalid token")
+
+// Sessions issues and verifies HMAC signed session tokens.
+type Sessions struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+// NewSessions creates a new instance of Sessions.
+func NewSessions(secret []byte, ttl time.Duration) *Sessions {
+	return &Sessions{
+		secret: secret,
+		ttl:    ttl,
+	}
+}
+
+// Issue returns a token for the address and its expiration time.
+func (s *Sessions) Issue(address string) (string, time.Time) {
+	expiresAt := time.Now().Add(s.ttl).UTC().Truncate(time.Second)
+
+	payload := fmt.Sprintf("%s|%d", address, expiresAt.Unix())
+
+	return encode([]byte(payload)) + "." + encode(s.sign(payload)), expiresAt
+}
+
+// Verify returns the address the token was issued for.
+func (s *Sessions) Verify(token string) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return "", ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return "", ErrInvalidToken
+	}
+
+	mac, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", ErrInvalidToken
+	}
+
+	if !hmac.Equal(mac, s.sign(string(payload))) {
+		return "", ErrInvalidToken
+	}
+
+	fields := strings.Split(string(payload), "|")
+	if len(fields) != 2 {
+		return "", ErrInvalidToken
+	}
+
+	exp, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil || time.Now().Unix() >= exp {
+		return "", ErrInvalidToken
+	}
+
+	return fields[0], nil
+}
+
+func (s *Sessions) sign(payload string) []byte {
+	h := hmac.New(sha256.New, s.secret)
+	h.Write([]byte(payload)) // nolint:errcheck
+	return h.Sum(nil)
+}
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
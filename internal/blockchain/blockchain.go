@@ -2,15 +2,22 @@
 package blockchain
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/avast/retry-go"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/TessorNetwork/furya/config"
 	"github.com/TessorNetwork/go-broadcaster"
+
+	"github.com/TessorNetwork/vulcan/internal/metrics"
+	"github.com/TessorNetwork/vulcan/internal/tracing"
 )
 
 //go:generate mockgen -destination=./mock/blockchain.go -package=mock -source=blockchain.go
@@ -31,7 +38,8 @@ type Stake struct {
 
 // Blockchain is interface for interacting with the blockchain.
 type Blockchain interface {
-	SendStakes(stakes []Stake, memo string) error
+	// SendStakes sends stakes in one transaction. ctx is used only for tracing, the broadcast isn't interrupted by it.
+	SendStakes(ctx context.Context, stakes []Stake, memo string) error
 }
 
 type blockchain struct {
@@ -46,12 +54,21 @@ func New(b broadcaster.Broadcaster) Blockchain {
 }
 
 // SendStakes ...
-func (b blockchain) SendStakes(stakes []Stake, memo string) error {
+func (b blockchain) SendStakes(ctx context.Context, stakes []Stake, memo string) (err error) {
+	ctx, span := tracing.Start(ctx, "blockchain.SendStakes", trace.WithAttributes(
+		attribute.Int("stakes", len(stakes)),
+		attribute.String("memo", memo),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	var reason string
+
 	sendStakes := func() error {
 		messages := make([]sdk.Msg, len(stakes))
 		for idx, stake := range stakes {
 			to, err := sdk.AccAddressFromBech32(stake.Address)
 			if err != nil {
+				reason = "invalid_address"
 				return fmt.Errorf("%w: %s", ErrInvalidAddress, stake.Address)
 			}
 
@@ -60,16 +77,60 @@ func (b blockchain) SendStakes(stakes []Stake, memo string) error {
 				Amount: stake.Amount,
 			}})
 			if err := messages[idx].ValidateBasic(); err != nil {
+				reason = "invalid_message"
 				return err
 			}
 		}
 
-		if _, err := b.b.Broadcast(messages, memo); err != nil {
+		if err := b.broadcast(ctx, messages, memo); err != nil {
+			reason = getBroadcastFailureReason(err)
 			return fmt.Errorf("failed to broadcast msg: %w", err)
 		}
 
 		return nil
 	}
 
-	return retry.Do(sendStakes, retry.Attempts(3))
+	if err := retry.Do(sendStakes,
+		retry.Attempts(3),
+		retry.LastErrorOnly(true),
+		retry.RetryIf(isRetryable),
+	); err != nil {
+		metrics.IncBroadcastFailures(reason)
+		return err
+	}
+
+	return nil
+}
+
+// broadcast broadcasts the messages within the span of the attempt.
+func (b blockchain) broadcast(ctx context.Context, messages []sdk.Msg, memo string) (err error) {
+	_, span := tracing.Start(ctx, "broadcaster.Broadcast", trace.WithSpanKind(trace.SpanKindClient))
+	defer func() { tracing.End(span, err) }()
+
+	resp, err := b.b.Broadcast(messages, memo)
+	if resp != nil {
+		span.SetAttributes(attribute.String("tx_hash", resp.TxHash))
+	}
+
+	return err
+}
+
+// isRetryable returns true if the transaction is rejected by the node, so it can't be included.
+// Other failures, e.g. timeouts, aren't retried since the transaction may be included and the stakes paid twice.
+func isRetryable(err error) bool {
+	return strings.Contains(err.Error(), "account sequence mismatch")
+}
+
+// getBroadcastFailureReason returns the metrics reason of the broadcast error.
+func getBroadcastFailureReason(err error) string {
+	switch {
+	case errors.Is(err, broadcaster.ErrTxInMempoolCache):
+		return "tx_in_mempool_cache"
+	case strings.Contains(err.Error(), "insufficient funds"):
+		return "insufficient_funds"
+	case strings.Contains(err.Error(), "account sequence mismatch"):
+		return "sequence_mismatch"
+	default:
+		return "broadcast"
+	}
 }
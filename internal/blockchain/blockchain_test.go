@@ -0,0 +1,62 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/TessorNetwork/go-broadcaster"
+)
+
+const (
+	testFrom = "furya1zyvp7f3dxsa5yj2s2a0x2mrn02qc3ruk39eenv"
+	testTo   = "furya1yg5nqde7g4x9xknpdphhvlvy3wffng98sd0cc7"
+)
+
+type testBroadcaster struct {
+	broadcaster.Broadcaster
+	errs  []error
+	memos []string
+}
+
+func (b *testBroadcaster) From() sdk.AccAddress {
+	addr, _ := sdk.AccAddressFromBech32(testFrom)
+	return addr
+}
+
+func (b *testBroadcaster) Broadcast(_ []sdk.Msg, memo string) (*sdk.TxResponse, error) {
+	b.memos = append(b.memos, memo)
+
+	err := b.errs[0]
+	b.errs = b.errs[1:]
+	return &sdk.TxResponse{}, err
+}
+
+func TestBlockchain_SendStakes(t *testing.T) {
+	stakes := []Stake{{Address: testTo, Amount: sdk.NewInt(10)}}
+
+	t.Run("sequence mismatch is retried", func(t *testing.T) {
+		b := &testBroadcaster{errs: []error{errors.New("account sequence mismatch, expected 2, got 1"), nil}}
+
+		require.NoError(t, New(b).SendStakes(context.Background(), stakes, "memo"))
+		require.Equal(t, []string{"memo", "memo"}, b.memos)
+	})
+
+	t.Run("timeout isn't retried", func(t *testing.T) {
+		b := &testBroadcaster{errs: []error{errors.New("timed out waiting for tx to be included in a block"), nil}}
+
+		require.Error(t, New(b).SendStakes(context.Background(), stakes, "memo"))
+		require.Len(t, b.memos, 1)
+	})
+
+	t.Run("invalid address", func(t *testing.T) {
+		b := &testBroadcaster{}
+
+		err := New(b).SendStakes(context.Background(), []Stake{{Address: "furya1invalid", Amount: sdk.NewInt(10)}}, "")
+		require.True(t, errors.Is(err, ErrInvalidAddress))
+		require.Empty(t, b.memos)
+	})
+}
@@ -5,6 +5,7 @@
 package mock
 
 import (
+	context "context"
 	blockchain "github.com/TessorNetwork/vulcan/internal/blockchain"
 	gomock "github.com/golang/mock/gomock"
 	reflect "reflect"
@@ -34,15 +35,15 @@ func (m *MockBlockchain) EXPECT() *MockBlockchainMockRecorder {
 }
 
 // SendStakes mocks base method
-func (m *MockBlockchain) SendStakes(stakes []blockchain.Stake, memo string) error {
+func (m *MockBlockchain) SendStakes(ctx context.Context, stakes []blockchain.Stake, memo string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SendStakes", stakes, memo)
+	ret := m.ctrl.Call(m, "SendStakes", ctx, stakes, memo)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // SendStakes indicates an expected call of SendStakes
-func (mr *MockBlockchainMockRecorder) SendStakes(stakes, memo interface{}) *gomock.Call {
+func (mr *MockBlockchainMockRecorder) SendStakes(ctx, stakes, memo interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendStakes", reflect.TypeOf((*MockBlockchain)(nil).SendStakes), stakes, memo)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendStakes", reflect.TypeOf((*MockBlockchain)(nil).SendStakes), ctx, stakes, memo)
 }
@@ -0,0 +1,258 @@
+// Package config loads command line options from YAML files and secret files, validates and redacts them.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/jessevdk/go-flags"
+	"gopkg.in/yaml.v2"
+)
+
+// SecretTag is the struct tag marking options which values shouldn't be logged, e.g. `secret:"true"`.
+const SecretTag = "secret"
+
+// FileEnvSuffix is the suffix of environment variables containing a path to the option value, e.g. SENTRY_DSN_FILE.
+const FileEnvSuffix = "_FILE"
+
+const redacted = "***"
+
+// ErrUnknownOption is returned when the config file contains a key which isn't an option.
+var ErrUnknownOption = fmt.Errorf("unknown option")
+
+// ErrInvalidValue is returned when the config file value doesn't fit the option.
+var ErrInvalidValue = fmt.Errorf("invalid value")
+
+// LoadFile sets defaults of the parser options from the YAML file, so environment variables and flags override them.
+// Keys are long names of options of all commands, nested maps are joined with dots,
+// e.g. `http: {port: 8080}` is the same as `http.port: 8080`. Lists set slice options.
+func LoadFile(parser *flags.Parser, path string) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	var raw map[interface{}]interface{}
+	if err := yaml.Unmarshal(b, &raw); err != nil {
+		return fmt.Errorf("failed to unmarshal config: %w", err)
+	}
+
+	values := make(map[string][]string)
+	if err := flatten("", raw, values); err != nil {
+		return err
+	}
+
+	options := optionsByLongName(parser)
+
+	var errs ValidationError
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		list, ok := options[k]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: %w", k, ErrUnknownOption))
+			continue
+		}
+
+		for _, o := range list {
+			if len(values[k]) > 1 && o.Field().Type.Kind() != reflect.Slice {
+				errs = append(errs, fmt.Errorf("%s: %w: list is given", k, ErrInvalidValue))
+				break
+			}
+			o.Default = values[k]
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+func flatten(prefix string, raw map[interface{}]interface{}, out map[string][]string) error {
+	for k, v := range raw {
+		key := fmt.Sprint(k)
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+
+		switch v := v.(type) {
+		case map[interface{}]interface{}:
+			if err := flatten(key, v, out); err != nil {
+				return err
+			}
+		case []interface{}:
+			list := make([]string, len(v))
+			for i, item := range v {
+				if _, ok := item.(map[interface{}]interface{}); ok {
+					return fmt.Errorf("%s: %w: list of maps is given", key, ErrInvalidValue)
+				}
+				list[i] = fmt.Sprint(item)
+			}
+			out[key] = list
+		case nil:
+			out[key] = []string{""}
+		default:
+			out[key] = []string{fmt.Sprint(v)}
+		}
+	}
+
+	return nil
+}
+
+// LoadSecretFiles sets environment variables of the parser options from files named by *_FILE variables,
+// e.g. HTTP_ADMIN_TOKEN is read from the path in HTTP_ADMIN_TOKEN_FILE. The trailing newline is trimmed.
+// The variable itself has priority over the file.
+func LoadSecretFiles(parser *flags.Parser) error {
+	for _, list := range optionsByLongName(parser) {
+		for _, o := range list {
+			key := o.EnvDefaultKey
+			if key == "" {
+				continue
+			}
+
+			path, ok := os.LookupEnv(key + FileEnvSuffix)
+			if !ok {
+				continue
+			}
+
+			if _, ok := os.LookupEnv(key); ok {
+				continue
+			}
+
+			b, err := ioutil.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("failed to read %s%s: %w", key, FileEnvSuffix, err)
+			}
+
+			if err := os.Setenv(key, strings.TrimRight(string(b), "\r\n")); err != nil {
+				return fmt.Errorf("failed to set %s: %w", key, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// optionsByLongName returns options of the parser and all its commands, the same name may be used by several commands.
+func optionsByLongName(parser *flags.Parser) map[string][]*flags.Option {
+	out := make(map[string][]*flags.Option)
+
+	var walkGroup func(g *flags.Group)
+	walkGroup = func(g *flags.Group) {
+		for _, o := range g.Options() {
+			if o.LongName != "" {
+				out[o.LongName] = append(out[o.LongName], o)
+			}
+		}
+		for _, v := range g.Groups() {
+			walkGroup(v)
+		}
+	}
+
+	var walkCommand func(c *flags.Command)
+	walkCommand = func(c *flags.Command) {
+		walkGroup(c.Group)
+		for _, v := range c.Commands() {
+			walkCommand(v)
+		}
+	}
+
+	walkCommand(parser.Command)
+
+	return out
+}
+
+// Redact returns the struct in the %+v format with non-empty values of secret fields replaced.
+func Redact(v interface{}) string {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return fmt.Sprintf("%+v", v)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("{")
+
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		if i > 0 {
+			sb.WriteString(" ")
+		}
+
+		f := rt.Field(i)
+		sb.WriteString(f.Name)
+		sb.WriteString(":")
+
+		switch {
+		case f.PkgPath != "":
+			// unexported
+			sb.WriteString("?")
+		case f.Tag.Get(SecretTag) == "true" && !rv.Field(i).IsZero():
+			sb.WriteString(redacted)
+		case f.Type.Kind() == reflect.Struct:
+			sb.WriteString(Redact(rv.Field(i).Interface()))
+		default:
+			fmt.Fprintf(&sb, "%v", rv.Field(i).Interface())
+		}
+	}
+
+	sb.WriteString("}")
+
+	return sb.String()
+}
+
+// ValidationError contains all errors found by the validation.
+type ValidationError []error
+
+// Error ...
+func (e ValidationError) Error() string {
+	lines := make([]string, len(e))
+	for i, v := range e {
+		lines[i] = "  - " + v.Error()
+	}
+
+	return fmt.Sprintf("invalid configuration:\n%s", strings.Join(lines, "\n"))
+}
+
+// Is reports whether any of the errors matches the target.
+func (e ValidationError) Is(target error) bool {
+	for _, v := range e {
+		if errors.Is(v, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Check adds the error for the option if the condition is false.
+func (e *ValidationError) Check(ok bool, option, format string, args ...interface{}) {
+	if !ok {
+		*e = append(*e, fmt.Errorf("%s: %s", option, fmt.Sprintf(format, args...)))
+	}
+}
+
+// Add adds the error for the option if it isn't nil.
+func (e *ValidationError) Add(option string, err error) {
+	if err != nil {
+		*e = append(*e, fmt.Errorf("%s: %w", option, err))
+	}
+}
+
+// Err returns the error if there are any.
+func (e ValidationError) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
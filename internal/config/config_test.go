@@ -0,0 +1,220 @@
+package config
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jessevdk/go-flags"
+	"github.com/stretchr/testify/require"
+)
+
+type testOptions struct {
+	Host     string        `long:"http.host" env:"TEST_HTTP_HOST" default:"0.0.0.0"`
+	Port     int           `long:"http.port" env:"TEST_HTTP_PORT" default:"8080"`
+	Timeout  time.Duration `long:"http.timeout" env:"TEST_HTTP_TIMEOUT" default:"10s"`
+	Events   []string      `long:"events" env:"TEST_EVENTS" env-delim:","`
+	Password string        `long:"password" env:"TEST_PASSWORD" secret:"true"`
+}
+
+type testCommand struct {
+	Port int    `long:"http.port" env:"TEST_HTTP_PORT" default:"9090"`
+	Name string `long:"name" env:"TEST_NAME"`
+}
+
+func (testCommand) Execute([]string) error {
+	return nil
+}
+
+func writeFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func newTestParser(t *testing.T) (*flags.Parser, *testOptions, *testCommand) {
+	var (
+		opts testOptions
+		cmd  testCommand
+	)
+
+	parser := flags.NewParser(&opts, flags.None)
+	_, err := parser.AddCommand("test", "", "", &cmd)
+	require.NoError(t, err)
+
+	return parser, &opts, &cmd
+}
+
+func TestLoadFile(t *testing.T) {
+	t.Run("nested", func(t *testing.T) {
+		parser, opts, cmd := newTestParser(t)
+
+		require.NoError(t, LoadFile(parser, writeFile(t, `
+http:
+  host: localhost
+  port: 8000
+  timeout: 1m
+events: [a, b]
+name: test
+`)))
+
+		_, err := parser.ParseArgs([]string{"test"})
+		require.NoError(t, err)
+
+		require.Equal(t, "localhost", opts.Host)
+		require.Equal(t, 8000, opts.Port)
+		require.Equal(t, time.Minute, opts.Timeout)
+		require.Equal(t, []string{"a", "b"}, opts.Events)
+		require.Equal(t, 8000, cmd.Port)
+		require.Equal(t, "test", cmd.Name)
+	})
+
+	t.Run("flat", func(t *testing.T) {
+		parser, opts, _ := newTestParser(t)
+
+		require.NoError(t, LoadFile(parser, writeFile(t, `http.port: 8000`)))
+
+		_, err := parser.ParseArgs([]string{"test"})
+		require.NoError(t, err)
+
+		require.Equal(t, "0.0.0.0", opts.Host)
+		require.Equal(t, 8000, opts.Port)
+	})
+
+	t.Run("env and flags override", func(t *testing.T) {
+		parser, opts, _ := newTestParser(t)
+
+		require.NoError(t, os.Setenv("TEST_HTTP_HOST", "127.0.0.1"))
+		defer os.Unsetenv("TEST_HTTP_HOST") // nolint: errcheck
+
+		require.NoError(t, LoadFile(parser, writeFile(t, `
+http:
+  host: localhost
+  port: 8000
+  timeout: 1m
+`)))
+
+		_, err := parser.ParseArgs([]string{"--http.timeout=5s", "test"})
+		require.NoError(t, err)
+
+		require.Equal(t, "127.0.0.1", opts.Host)
+		require.Equal(t, 8000, opts.Port)
+		require.Equal(t, 5*time.Second, opts.Timeout)
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		parser, _, _ := newTestParser(t)
+
+		err := LoadFile(parser, writeFile(t, `
+http:
+  hots: localhost
+unknown: 1
+`))
+		require.True(t, errors.Is(err, ErrUnknownOption))
+		require.Contains(t, err.Error(), "http.hots")
+		require.Contains(t, err.Error(), "unknown")
+	})
+
+	t.Run("list for scalar", func(t *testing.T) {
+		parser, _, _ := newTestParser(t)
+
+		err := LoadFile(parser, writeFile(t, `http.port: [1, 2]`))
+		require.True(t, errors.Is(err, ErrInvalidValue))
+	})
+
+	t.Run("invalid yaml", func(t *testing.T) {
+		parser, _, _ := newTestParser(t)
+
+		require.Error(t, LoadFile(parser, writeFile(t, `http: [`)))
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		parser, _, _ := newTestParser(t)
+
+		err := LoadFile(parser, filepath.Join(t.TempDir(), "missing.yaml"))
+		require.True(t, errors.Is(err, os.ErrNotExist))
+	})
+}
+
+func TestLoadSecretFiles(t *testing.T) {
+	path := writeFile(t, "secret\n")
+
+	require.NoError(t, os.Setenv("TEST_PASSWORD_FILE", path))
+	defer os.Unsetenv("TEST_PASSWORD_FILE") // nolint: errcheck
+	defer os.Unsetenv("TEST_PASSWORD")      // nolint: errcheck
+
+	t.Run("file", func(t *testing.T) {
+		parser, opts, _ := newTestParser(t)
+
+		require.NoError(t, LoadSecretFiles(parser))
+
+		_, err := parser.ParseArgs([]string{"test"})
+		require.NoError(t, err)
+		require.Equal(t, "secret", opts.Password)
+	})
+
+	t.Run("env has priority", func(t *testing.T) {
+		parser, opts, _ := newTestParser(t)
+
+		require.NoError(t, os.Setenv("TEST_PASSWORD", "env"))
+		require.NoError(t, LoadSecretFiles(parser))
+
+		_, err := parser.ParseArgs([]string{"test"})
+		require.NoError(t, err)
+		require.Equal(t, "env", opts.Password)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		parser, _, _ := newTestParser(t)
+
+		require.NoError(t, os.Unsetenv("TEST_PASSWORD"))
+		require.NoError(t, os.Setenv("TEST_PASSWORD_FILE", filepath.Join(t.TempDir(), "missing")))
+
+		require.Error(t, LoadSecretFiles(parser))
+	})
+}
+
+func TestRedact(t *testing.T) {
+	type nested struct {
+		Token string `secret:"true"`
+		Name  string
+	}
+
+	v := struct {
+		Password string `secret:"true"`
+		Empty    string `secret:"true"`
+		Port     int
+		Nested   nested
+		hidden   string
+	}{
+		Password: "password",
+		Port:     8080,
+		Nested:   nested{Token: "token", Name: "name"},
+		hidden:   "hidden",
+	}
+
+	require.Equal(t, "{Password:*** Empty: Port:8080 Nested:{Token:*** Name:name} hidden:?}", Redact(&v))
+	require.Equal(t, "1", Redact(1))
+}
+
+func TestValidationError(t *testing.T) {
+	var errs ValidationError
+	require.NoError(t, errs.Err())
+
+	errs.Check(true, "a", "should be positive")
+	errs.Add("b", nil)
+	require.NoError(t, errs.Err())
+
+	testErr := errors.New("test")
+
+	errs.Check(false, "a", "should be at least %d", 1)
+	errs.Add("b", testErr)
+
+	err := errs.Err()
+	require.Error(t, err)
+	require.True(t, errors.Is(errs[1], testErr))
+	require.Equal(t, "invalid configuration:\n  - a: should be at least 1\n  - b: test", err.Error())
+}
@@ -0,0 +1,416 @@
+// Package distribution sends stakes to a list of recipients and records every payout, so an interrupted or
+// partially failed distribution is resumed without paying anybody twice. A payout is recorded as sending before
+// its transaction is broadcast, a payout with unknown broadcast result isn't sent again until it's resolved.
+package distribution
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/TessorNetwork/vulcan/internal/blockchain"
+	"github.com/TessorNetwork/vulcan/internal/storage"
+)
+
+// ErrInvalidCSV is returned when the CSV row can't be parsed.
+var ErrInvalidCSV = fmt.Errorf("invalid csv")
+
+// ErrNothingToResolve is returned when the run doesn't have sending items with the memo.
+var ErrNothingToResolve = fmt.Errorf("nothing to resolve")
+
+// Recipient ...
+type Recipient struct {
+	Address string
+	Amount  sdk.Int
+}
+
+// Total is a number of payouts and their sum in ufury.
+type Total struct {
+	Count  int
+	Amount sdk.Int
+}
+
+func (t *Total) add(amount sdk.Int) {
+	t.Count++
+	t.Amount = t.Amount.Add(amount)
+}
+
+// String ...
+func (t Total) String() string {
+	return fmt.Sprintf("%d (%sufury)", t.Count, t.Amount)
+}
+
+// Report summarizes the distribution run.
+type Report struct {
+	// Recipients is the number of valid recipients in the input.
+	Recipients int
+	// Invalid is the number of skipped input entries: invalid addresses and amounts, duplicates.
+	Invalid int
+	// Sent are payouts sent by this and previous runs.
+	Sent Total
+	// Pending are payouts left to be sent.
+	Pending Total
+	// Failed are pending payouts which failed the last attempt.
+	Failed Total
+	// Sending are payouts which may have been sent, they should be resolved by Resolve.
+	Sending Total
+	// SendingMemos are memos of the transactions of sending payouts.
+	SendingMemos []string
+}
+
+func newReport() *Report {
+	return &Report{
+		Sent:    Total{Amount: sdk.ZeroInt()},
+		Pending: Total{Amount: sdk.ZeroInt()},
+		Failed:  Total{Amount: sdk.ZeroInt()},
+		Sending: Total{Amount: sdk.ZeroInt()},
+	}
+}
+
+func (r *Report) add(item *storage.DistributionItem) {
+	switch item.Status {
+	case storage.SentDistributionItemStatus:
+		r.Sent.add(item.Amount)
+	case storage.SendingDistributionItemStatus:
+		r.Sending.add(item.Amount)
+		if !contains(r.SendingMemos, item.Memo.String) {
+			r.SendingMemos = append(r.SendingMemos, item.Memo.String)
+		}
+	case storage.FailedDistributionItemStatus:
+		r.Failed.add(item.Amount)
+		r.Pending.add(item.Amount)
+	default:
+		r.Pending.add(item.Amount)
+	}
+}
+
+// Distributor sends stakes in batches, one transaction per batch.
+type Distributor struct {
+	storage   storage.Storage
+	bc        blockchain.Blockchain
+	batchSize int
+}
+
+// New returns a new instance of Distributor.
+func New(s storage.Storage, bc blockchain.Blockchain, batchSize int) *Distributor {
+	return &Distributor{
+		storage:   s,
+		bc:        bc,
+		batchSize: batchSize,
+	}
+}
+
+// Plan returns the report of what Run would do without changing anything.
+func (d *Distributor) Plan(ctx context.Context, name string, recipients []Recipient) (*Report, error) {
+	valid, invalid := validate(recipients)
+
+	var items []*storage.DistributionItem
+	switch run, err := d.storage.GetDistributionRun(ctx, name); {
+	case err == nil:
+		if items, err = d.storage.GetDistributionItems(ctx, run.ID); err != nil {
+			return nil, fmt.Errorf("failed to get items: %w", err)
+		}
+	case errors.Is(err, storage.ErrNotFound):
+	default:
+		return nil, fmt.Errorf("failed to get run: %w", err)
+	}
+
+	report := newReport()
+	report.Recipients, report.Invalid = len(valid), invalid
+
+	known := make(map[string]bool, len(items))
+	for _, v := range items {
+		known[v.Address] = true
+		report.add(v)
+	}
+
+	for _, v := range valid {
+		if !known[v.Address] {
+			report.Pending.add(v.Amount)
+		}
+	}
+
+	return report, nil
+}
+
+// Run sends stakes to recipients the run with the given name hasn't paid yet, the run is created if it doesn't exist.
+// Items recorded by previous runs are sent even if they aren't in recipients anymore, their amounts aren't changed.
+// A batch which failed to broadcast is kept sending, since its transaction may be included anyway,
+// e.g. on a timeout. Sending items aren't sent again until Resolve. Run stops between batches when ctx is done.
+func (d *Distributor) Run(ctx context.Context, name string, recipients []Recipient) (*Report, error) {
+	valid, invalid := validate(recipients)
+
+	run, err := d.storage.CreateDistributionRun(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create run: %w", err)
+	}
+
+	logger := log.WithField("run", run.Name)
+
+	if len(valid) > 0 {
+		newItems := make([]storage.DistributionItem, len(valid))
+		for i, v := range valid {
+			newItems[i] = storage.DistributionItem{Address: v.Address, Amount: v.Amount}
+		}
+
+		n, err := d.storage.CreateDistributionItems(ctx, run.ID, newItems)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create items: %w", err)
+		}
+
+		logger.WithField("count", n).Info("new items created")
+	}
+
+	items, err := d.storage.GetDistributionItems(ctx, run.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get items: %w", err)
+	}
+
+	var todo []*storage.DistributionItem
+	for _, v := range items {
+		switch v.Status {
+		case storage.SentDistributionItemStatus:
+		case storage.SendingDistributionItemStatus:
+			logger.WithFields(log.Fields{"id": v.ID, "memo": v.Memo.String}).Warn("item is sending, resolve it")
+		default:
+			todo = append(todo, v)
+		}
+	}
+
+	logger.WithField("count", len(todo)).Info("items to be sent")
+
+	for len(todo) > 0 && ctx.Err() == nil {
+		n := d.batchSize
+		if n > len(todo) {
+			n = len(todo)
+		}
+
+		if err := d.send(ctx, run, todo[:n]); err != nil {
+			return nil, err
+		}
+
+		todo = todo[n:]
+	}
+
+	if ctx.Err() != nil {
+		logger.WithField("left", len(todo)).Warn("distribution is interrupted")
+	}
+
+	report := newReport()
+	report.Recipients, report.Invalid = len(valid), invalid
+	for _, v := range items {
+		report.add(v)
+	}
+
+	if report.Pending.Count == 0 && report.Sending.Count == 0 && !run.FinishedAt.Valid {
+		// later runs of the same name may still add items, e.g. for newly confirmed addresses
+		if err := d.storage.SetDistributionRunFinished(ctx, run.ID); err != nil {
+			return nil, fmt.Errorf("failed to finish run: %w", err)
+		}
+	}
+
+	return report, nil
+}
+
+// send broadcasts the batch and records the result. It returns an error only if the result can't be recorded.
+// The batch is recorded as sending before the broadcast, so it isn't sent again if the process crashes.
+// The statuses are recorded with the background context, so the sent batch is recorded even if ctx is done.
+func (d *Distributor) send(ctx context.Context, run *storage.DistributionRun, batch []*storage.DistributionItem) error {
+	ids := make([]int, len(batch))
+	stakes := make([]blockchain.Stake, len(batch))
+	for i, v := range batch {
+		ids[i] = v.ID
+		stakes[i] = blockchain.Stake{Address: v.Address, Amount: v.Amount}
+	}
+
+	// an item is in one batch per attempt, so the first item and its attempt make the memo unique
+	memo := fmt.Sprintf("distribution:%d:%d:%d", run.ID, batch[0].ID, batch[0].Attempts+1)
+
+	if err := d.storage.SetDistributionItemsSending(context.Background(), ids, memo); err != nil {
+		return fmt.Errorf("failed to mark items %v sending: %w", ids, err)
+	}
+
+	for _, v := range batch {
+		v.Attempts++
+		v.Memo = sql.NullString{String: memo, Valid: true}
+	}
+
+	logger := log.WithFields(log.Fields{
+		"ids":  ids,
+		"memo": memo,
+	})
+
+	status := storage.SentDistributionItemStatus
+
+	if err := d.bc.SendStakes(ctx, stakes, memo); err != nil {
+		logger.WithError(err).Error("failed to send batch")
+
+		if errors.Is(err, blockchain.ErrInvalidAddress) {
+			// nothing is broadcast
+			if err := d.storage.SetDistributionItemsFailed(context.Background(), ids, err.Error()); err != nil {
+				return fmt.Errorf("failed to mark items %v failed: %w", ids, err)
+			}
+
+			status = storage.FailedDistributionItemStatus
+		} else {
+			// the transaction may be included despite the error, e.g. on a timeout, so the batch is kept sending
+			if err := d.storage.SetDistributionItemsError(context.Background(), ids, err.Error()); err != nil {
+				return fmt.Errorf("failed to record error of items %v: %w", ids, err)
+			}
+
+			status = storage.SendingDistributionItemStatus
+		}
+	} else if err := d.storage.SetDistributionItemsSent(context.Background(), ids); err != nil {
+		// the batch is kept sending, so it isn't paid again by the next run
+		return fmt.Errorf("items %v are sent with memo %s but failed to mark them sent, resolve them: %w",
+			ids, memo, err)
+	}
+
+	for _, v := range batch {
+		v.Status = status
+	}
+
+	logger.WithField("status", status).Info("batch processed")
+
+	return nil
+}
+
+// Resolve marks sending items of the run broadcast with the memo sent or failed after the operator has checked
+// whether the transaction with the memo is on chain. Failed items are sent again by the next Run.
+// It returns the number of resolved items.
+func (d *Distributor) Resolve(ctx context.Context, name, memo string, sent bool) (int, error) {
+	run, err := d.storage.GetDistributionRun(ctx, name)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get run: %w", err)
+	}
+
+	items, err := d.storage.GetDistributionItems(ctx, run.ID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get items: %w", err)
+	}
+
+	var ids []int
+	for _, v := range items {
+		if v.Status == storage.SendingDistributionItemStatus && v.Memo.String == memo {
+			ids = append(ids, v.ID)
+		}
+	}
+
+	if len(ids) == 0 {
+		return 0, fmt.Errorf("%w: no sending items with memo %s", ErrNothingToResolve, memo)
+	}
+
+	if sent {
+		err = d.storage.SetDistributionItemsSent(ctx, ids)
+	} else {
+		err = d.storage.SetDistributionItemsFailed(ctx, ids, "transaction isn't found on chain")
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to resolve items %v: %w", ids, err)
+	}
+
+	log.WithFields(log.Fields{
+		"run":  name,
+		"memo": memo,
+		"ids":  ids,
+		"sent": sent,
+	}).Info("items resolved")
+
+	return len(ids), nil
+}
+
+func contains(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// validate returns valid recipients and the number of invalid ones. Invalid recipients are logged and skipped.
+func validate(recipients []Recipient) ([]Recipient, int) {
+	var (
+		valid   = make([]Recipient, 0, len(recipients))
+		invalid int
+		seen    = make(map[string]bool, len(recipients))
+	)
+
+	for _, v := range recipients {
+		logger := log.WithField("address", v.Address)
+
+		if _, err := sdk.AccAddressFromBech32(v.Address); err != nil {
+			logger.WithError(err).Warn("invalid address is skipped")
+			invalid++
+			continue
+		}
+
+		if v.Amount.IsNil() || !v.Amount.IsPositive() {
+			logger.Warn("non-positive amount is skipped")
+			invalid++
+			continue
+		}
+
+		if seen[v.Address] {
+			logger.Warn("duplicate address is skipped")
+			invalid++
+			continue
+		}
+
+		seen[v.Address] = true
+		valid = append(valid, v)
+	}
+
+	return valid, invalid
+}
+
+// ReadCSV reads recipients from rows "address[,amount]", amount is in ufury and defaults to the given one.
+// The header row "address,amount" is optional. Addresses aren't validated here, Run skips invalid ones.
+func ReadCSV(r io.Reader, amount sdk.Int) ([]Recipient, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var recipients []Recipient
+	for n := 1; ; n++ {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidCSV, err)
+		}
+
+		if n == 1 && strings.EqualFold(strings.TrimSpace(row[0]), "address") {
+			continue
+		}
+
+		if len(row) > 2 {
+			return nil, fmt.Errorf("%w: row %d: expected address and optional amount", ErrInvalidCSV, n)
+		}
+
+		recipient := Recipient{
+			Address: strings.TrimSpace(row[0]),
+			Amount:  amount,
+		}
+
+		if len(row) == 2 && strings.TrimSpace(row[1]) != "" {
+			v, ok := sdk.NewIntFromString(strings.TrimSpace(row[1]))
+			if !ok {
+				return nil, fmt.Errorf("%w: row %d: invalid amount %s", ErrInvalidCSV, n, row[1])
+			}
+			recipient.Amount = v
+		}
+
+		recipients = append(recipients, recipient)
+	}
+
+	return recipients, nil
+}
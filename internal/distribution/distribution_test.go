@@ -0,0 +1,375 @@
+package distribution
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/TessorNetwork/vulcan/internal/blockchain"
+	blockchainmock "github.com/TessorNetwork/vulcan/internal/blockchain/mock"
+	"github.com/TessorNetwork/vulcan/internal/storage"
+	storagemock "github.com/TessorNetwork/vulcan/internal/storage/mock"
+)
+
+const (
+	address1 = "furya1zyvp7f3dxsa5yj2s2a0x2mrn02qc3ruk39eenv"
+	address2 = "furya1yg5nqde7g4x9xknpdphhvlvy3wffng98sd0cc7"
+	address3 = "furya1xvayzjz02ewkg6mj0xqg0r54nj364vdc4r43h2"
+)
+
+func TestReadCSV(t *testing.T) {
+	recipients, err := ReadCSV(strings.NewReader(`address,amount
+furya1a
+furya1b, 20
+furya1c,
+`), sdk.NewInt(10))
+	require.NoError(t, err)
+	require.Equal(t, []Recipient{
+		{Address: "furya1a", Amount: sdk.NewInt(10)},
+		{Address: "furya1b", Amount: sdk.NewInt(20)},
+		{Address: "furya1c", Amount: sdk.NewInt(10)},
+	}, recipients)
+
+	recipients, err = ReadCSV(strings.NewReader("furya1a\n"), sdk.NewInt(10))
+	require.NoError(t, err)
+	require.Len(t, recipients, 1)
+
+	_, err = ReadCSV(strings.NewReader("furya1a\nfurya1b,abc\n"), sdk.NewInt(10))
+	require.True(t, errors.Is(err, ErrInvalidCSV))
+	require.Contains(t, err.Error(), "row 2")
+
+	_, err = ReadCSV(strings.NewReader("furya1a,1,2\n"), sdk.NewInt(10))
+	require.True(t, errors.Is(err, ErrInvalidCSV))
+}
+
+func Test_validate(t *testing.T) {
+	valid, invalid := validate([]Recipient{
+		{Address: address1, Amount: sdk.NewInt(10)},
+		{Address: "furya1invalid", Amount: sdk.NewInt(10)},
+		{Address: address2, Amount: sdk.ZeroInt()},
+		{Address: address1, Amount: sdk.NewInt(20)},
+		{Address: address3},
+	})
+
+	require.Equal(t, []Recipient{{Address: address1, Amount: sdk.NewInt(10)}}, valid)
+	require.Equal(t, 4, invalid)
+}
+
+func TestDistributor_Plan(t *testing.T) {
+	recipients := []Recipient{
+		{Address: address1, Amount: sdk.NewInt(10)},
+		{Address: address2, Amount: sdk.NewInt(10)},
+		{Address: address3, Amount: sdk.NewInt(10)},
+		{Address: "invalid", Amount: sdk.NewInt(10)},
+	}
+
+	t.Run("new", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		st := storagemock.NewMockStorage(ctrl)
+		st.EXPECT().GetDistributionRun(gomock.Any(), "initial").Return(nil, storage.ErrNotFound)
+
+		report, err := New(st, nil, 10).Plan(context.Background(), "initial", recipients)
+		require.NoError(t, err)
+		require.Equal(t, 3, report.Recipients)
+		require.Equal(t, 1, report.Invalid)
+		require.Equal(t, 0, report.Sent.Count)
+		require.Equal(t, 3, report.Pending.Count)
+		require.Equal(t, "30", report.Pending.Amount.String())
+	})
+
+	t.Run("resumed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		st := storagemock.NewMockStorage(ctrl)
+		st.EXPECT().GetDistributionRun(gomock.Any(), "initial").Return(&storage.DistributionRun{ID: 1}, nil)
+		st.EXPECT().GetDistributionItems(gomock.Any(), 1).Return([]*storage.DistributionItem{
+			{ID: 1, Address: address1, Amount: sdk.NewInt(5), Status: storage.SentDistributionItemStatus},
+			{ID: 2, Address: address2, Amount: sdk.NewInt(5), Status: storage.FailedDistributionItemStatus},
+		}, nil)
+
+		report, err := New(st, nil, 10).Plan(context.Background(), "initial", recipients)
+		require.NoError(t, err)
+		require.Equal(t, 1, report.Sent.Count)
+		require.Equal(t, "5", report.Sent.Amount.String())
+		require.Equal(t, 2, report.Pending.Count)
+		require.Equal(t, "15", report.Pending.Amount.String())
+		require.Equal(t, 1, report.Failed.Count)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		st := storagemock.NewMockStorage(ctrl)
+		st.EXPECT().GetDistributionRun(gomock.Any(), "initial").Return(nil, fmt.Errorf("test"))
+
+		_, err := New(st, nil, 10).Plan(context.Background(), "initial", recipients)
+		require.Error(t, err)
+	})
+}
+
+func TestDistributor_Run(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	st := storagemock.NewMockStorage(ctrl)
+	bc := blockchainmock.NewMockBlockchain(ctrl)
+
+	recipients := []Recipient{
+		{Address: address2, Amount: sdk.NewInt(10)},
+		{Address: address3, Amount: sdk.NewInt(10)},
+		{Address: "invalid", Amount: sdk.NewInt(10)},
+	}
+
+	st.EXPECT().CreateDistributionRun(gomock.Any(), "initial").
+		Return(&storage.DistributionRun{ID: 1, Name: "initial"}, nil)
+	st.EXPECT().CreateDistributionItems(gomock.Any(), 1, []storage.DistributionItem{
+		{Address: address2, Amount: sdk.NewInt(10)},
+		{Address: address3, Amount: sdk.NewInt(10)},
+	}).Return(1, nil)
+	st.EXPECT().GetDistributionItems(gomock.Any(), 1).Return([]*storage.DistributionItem{
+		{ID: 1, Address: address1, Amount: sdk.NewInt(5), Status: storage.SentDistributionItemStatus},
+		{ID: 2, Address: address2, Amount: sdk.NewInt(5), Status: storage.FailedDistributionItemStatus, Attempts: 1},
+		{ID: 3, Address: address3, Amount: sdk.NewInt(10), Status: storage.PendingDistributionItemStatus},
+	}, nil)
+
+	gomock.InOrder(
+		st.EXPECT().SetDistributionItemsSending(gomock.Any(), []int{2}, "distribution:1:2:2").Return(nil),
+		bc.EXPECT().SendStakes(gomock.Any(), []blockchain.Stake{{Address: address2, Amount: sdk.NewInt(5)}},
+			"distribution:1:2:2").Return(fmt.Errorf("timed out waiting for tx to be included in a block")),
+		// the transaction may be included, so the item isn't failed
+		st.EXPECT().SetDistributionItemsError(gomock.Any(), []int{2}, "timed out waiting for tx to be included in a block").
+			Return(nil),
+		st.EXPECT().SetDistributionItemsSending(gomock.Any(), []int{3}, "distribution:1:3:1").Return(nil),
+		bc.EXPECT().SendStakes(gomock.Any(), []blockchain.Stake{{Address: address3, Amount: sdk.NewInt(10)}},
+			"distribution:1:3:1").Return(nil),
+		st.EXPECT().SetDistributionItemsSent(gomock.Any(), []int{3}).Return(nil),
+	)
+
+	report, err := New(st, bc, 1).Run(context.Background(), "initial", recipients)
+	require.NoError(t, err)
+	require.Equal(t, 2, report.Recipients)
+	require.Equal(t, 1, report.Invalid)
+	require.Equal(t, 2, report.Sent.Count)
+	require.Equal(t, "15", report.Sent.Amount.String())
+	require.Equal(t, 0, report.Pending.Count)
+	require.Equal(t, 0, report.Failed.Count)
+	require.Equal(t, 1, report.Sending.Count)
+	require.Equal(t, []string{"distribution:1:2:2"}, report.SendingMemos)
+}
+
+func TestDistributor_Run_Sending(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	st := storagemock.NewMockStorage(ctrl)
+	bc := blockchainmock.NewMockBlockchain(ctrl)
+
+	sending := func(id int, address, memo string) *storage.DistributionItem {
+		return &storage.DistributionItem{ID: id, Address: address, Amount: sdk.NewInt(10), Attempts: 1,
+			Status: storage.SendingDistributionItemStatus, Memo: sql.NullString{String: memo, Valid: true}}
+	}
+
+	st.EXPECT().CreateDistributionRun(gomock.Any(), "initial").Return(&storage.DistributionRun{ID: 1}, nil)
+	st.EXPECT().GetDistributionItems(gomock.Any(), 1).Return([]*storage.DistributionItem{
+		sending(1, address1, "distribution:1:1:1"),
+		sending(2, address2, "distribution:1:1:1"),
+		sending(3, address3, "distribution:1:3:1"),
+	}, nil)
+
+	// sending items aren't sent again and the run isn't finished until they are resolved
+	report, err := New(st, bc, 10).Run(context.Background(), "initial", nil)
+	require.NoError(t, err)
+	require.Equal(t, 0, report.Pending.Count)
+	require.Equal(t, 3, report.Sending.Count)
+	require.Equal(t, "30", report.Sending.Amount.String())
+	require.Equal(t, []string{"distribution:1:1:1", "distribution:1:3:1"}, report.SendingMemos)
+}
+
+func TestDistributor_Run_InvalidAddress(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	st := storagemock.NewMockStorage(ctrl)
+	bc := blockchainmock.NewMockBlockchain(ctrl)
+
+	st.EXPECT().CreateDistributionRun(gomock.Any(), "initial").Return(&storage.DistributionRun{ID: 1}, nil)
+	st.EXPECT().GetDistributionItems(gomock.Any(), 1).Return([]*storage.DistributionItem{
+		{ID: 1, Address: address1, Amount: sdk.NewInt(10)},
+	}, nil)
+
+	err := fmt.Errorf("%w: %s", blockchain.ErrInvalidAddress, address1)
+	gomock.InOrder(
+		st.EXPECT().SetDistributionItemsSending(gomock.Any(), []int{1}, "distribution:1:1:1").Return(nil),
+		bc.EXPECT().SendStakes(gomock.Any(), gomock.Any(), "distribution:1:1:1").Return(err),
+		// nothing is broadcast, so the item is retried by the next run
+		st.EXPECT().SetDistributionItemsFailed(gomock.Any(), []int{1}, err.Error()).Return(nil),
+	)
+
+	report, err := New(st, bc, 10).Run(context.Background(), "initial", nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, report.Pending.Count)
+	require.Equal(t, 1, report.Failed.Count)
+	require.Equal(t, 0, report.Sending.Count)
+}
+
+func TestDistributor_Run_Finished(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	st := storagemock.NewMockStorage(ctrl)
+	bc := blockchainmock.NewMockBlockchain(ctrl)
+
+	st.EXPECT().CreateDistributionRun(gomock.Any(), "initial").Return(&storage.DistributionRun{ID: 1}, nil)
+	st.EXPECT().CreateDistributionItems(gomock.Any(), 1, gomock.Any()).Return(2, nil)
+	st.EXPECT().GetDistributionItems(gomock.Any(), 1).Return([]*storage.DistributionItem{
+		{ID: 1, Address: address1, Amount: sdk.NewInt(10)},
+		{ID: 2, Address: address2, Amount: sdk.NewInt(10)},
+		{ID: 3, Address: address3, Amount: sdk.NewInt(10)},
+	}, nil)
+	st.EXPECT().SetDistributionItemsSending(gomock.Any(), []int{1, 2}, "distribution:1:1:1").Return(nil)
+	bc.EXPECT().SendStakes(gomock.Any(), []blockchain.Stake{
+		{Address: address1, Amount: sdk.NewInt(10)},
+		{Address: address2, Amount: sdk.NewInt(10)},
+	}, "distribution:1:1:1").Return(nil)
+	st.EXPECT().SetDistributionItemsSent(gomock.Any(), []int{1, 2}).Return(nil)
+	st.EXPECT().SetDistributionItemsSending(gomock.Any(), []int{3}, "distribution:1:3:1").Return(nil)
+	bc.EXPECT().SendStakes(gomock.Any(), []blockchain.Stake{{Address: address3, Amount: sdk.NewInt(10)}},
+		"distribution:1:3:1").Return(nil)
+	st.EXPECT().SetDistributionItemsSent(gomock.Any(), []int{3}).Return(nil)
+	st.EXPECT().SetDistributionRunFinished(gomock.Any(), 1).Return(nil)
+
+	report, err := New(st, bc, 2).Run(context.Background(), "initial", []Recipient{
+		{Address: address1, Amount: sdk.NewInt(10)},
+		{Address: address2, Amount: sdk.NewInt(10)},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 3, report.Sent.Count)
+	require.Equal(t, 0, report.Pending.Count)
+}
+
+func TestDistributor_Run_Interrupted(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	st := storagemock.NewMockStorage(ctrl)
+	bc := blockchainmock.NewMockBlockchain(ctrl)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	st.EXPECT().CreateDistributionRun(gomock.Any(), "initial").Return(&storage.DistributionRun{ID: 1}, nil)
+	st.EXPECT().GetDistributionItems(gomock.Any(), 1).Return([]*storage.DistributionItem{
+		{ID: 1, Address: address1, Amount: sdk.NewInt(10)},
+		{ID: 2, Address: address2, Amount: sdk.NewInt(10)},
+	}, nil)
+	st.EXPECT().SetDistributionItemsSending(gomock.Any(), []int{1}, "distribution:1:1:1").Return(nil)
+	bc.EXPECT().SendStakes(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(context.Context, []blockchain.Stake, string) error {
+		cancel()
+		return nil
+	})
+	st.EXPECT().SetDistributionItemsSent(gomock.Any(), []int{1}).Return(nil)
+
+	report, err := New(st, bc, 1).Run(ctx, "initial", nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, report.Sent.Count)
+	require.Equal(t, 1, report.Pending.Count)
+}
+
+func TestDistributor_Run_NotRecorded(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	st := storagemock.NewMockStorage(ctrl)
+	bc := blockchainmock.NewMockBlockchain(ctrl)
+
+	st.EXPECT().CreateDistributionRun(gomock.Any(), "initial").Return(&storage.DistributionRun{ID: 1}, nil)
+	st.EXPECT().GetDistributionItems(gomock.Any(), 1).Return([]*storage.DistributionItem{
+		{ID: 1, Address: address1, Amount: sdk.NewInt(10)},
+		{ID: 2, Address: address2, Amount: sdk.NewInt(10)},
+	}, nil)
+	st.EXPECT().SetDistributionItemsSending(gomock.Any(), []int{1}, "distribution:1:1:1").Return(nil)
+	bc.EXPECT().SendStakes(gomock.Any(), gomock.Any(), "distribution:1:1:1").Return(nil)
+	st.EXPECT().SetDistributionItemsSent(gomock.Any(), []int{1}).Return(sql.ErrConnDone)
+
+	// stops, otherwise the unrecorded payouts pile up; the items stay sending, so they aren't paid again
+	_, err := New(st, bc, 1).Run(context.Background(), "initial", nil)
+	require.True(t, errors.Is(err, sql.ErrConnDone))
+	require.Contains(t, err.Error(), "distribution:1:1:1")
+}
+
+func TestDistributor_Run_SendingNotRecorded(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	st := storagemock.NewMockStorage(ctrl)
+	bc := blockchainmock.NewMockBlockchain(ctrl)
+
+	st.EXPECT().CreateDistributionRun(gomock.Any(), "initial").Return(&storage.DistributionRun{ID: 1}, nil)
+	st.EXPECT().GetDistributionItems(gomock.Any(), 1).Return([]*storage.DistributionItem{
+		{ID: 1, Address: address1, Amount: sdk.NewInt(10)},
+	}, nil)
+	st.EXPECT().SetDistributionItemsSending(gomock.Any(), []int{1}, "distribution:1:1:1").Return(sql.ErrConnDone)
+
+	// nothing is broadcast unless the attempt is recorded
+	_, err := New(st, bc, 1).Run(context.Background(), "initial", nil)
+	require.True(t, errors.Is(err, sql.ErrConnDone))
+}
+
+func TestDistributor_Resolve(t *testing.T) {
+	items := []*storage.DistributionItem{
+		{ID: 1, Status: storage.SentDistributionItemStatus, Memo: sql.NullString{String: "distribution:1:1:1", Valid: true}},
+		{ID: 2, Status: storage.SendingDistributionItemStatus, Memo: sql.NullString{String: "distribution:1:2:1", Valid: true}},
+		{ID: 3, Status: storage.SendingDistributionItemStatus, Memo: sql.NullString{String: "distribution:1:2:1", Valid: true}},
+		{ID: 4, Status: storage.SendingDistributionItemStatus, Memo: sql.NullString{String: "distribution:1:4:1", Valid: true}},
+	}
+
+	t.Run("sent", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		st := storagemock.NewMockStorage(ctrl)
+		st.EXPECT().GetDistributionRun(gomock.Any(), "initial").Return(&storage.DistributionRun{ID: 1}, nil)
+		st.EXPECT().GetDistributionItems(gomock.Any(), 1).Return(items, nil)
+		st.EXPECT().SetDistributionItemsSent(gomock.Any(), []int{2, 3}).Return(nil)
+
+		n, err := New(st, nil, 10).Resolve(context.Background(), "initial", "distribution:1:2:1", true)
+		require.NoError(t, err)
+		require.Equal(t, 2, n)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		st := storagemock.NewMockStorage(ctrl)
+		st.EXPECT().GetDistributionRun(gomock.Any(), "initial").Return(&storage.DistributionRun{ID: 1}, nil)
+		st.EXPECT().GetDistributionItems(gomock.Any(), 1).Return(items, nil)
+		st.EXPECT().SetDistributionItemsFailed(gomock.Any(), []int{4}, gomock.Any()).Return(nil)
+
+		n, err := New(st, nil, 10).Resolve(context.Background(), "initial", "distribution:1:4:1", false)
+		require.NoError(t, err)
+		require.Equal(t, 1, n)
+	})
+
+	t.Run("not sending", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		st := storagemock.NewMockStorage(ctrl)
+		st.EXPECT().GetDistributionRun(gomock.Any(), "initial").Return(&storage.DistributionRun{ID: 1}, nil)
+		st.EXPECT().GetDistributionItems(gomock.Any(), 1).Return(items, nil)
+
+		_, err := New(st, nil, 10).Resolve(context.Background(), "initial", "distribution:1:1:1", false)
+		require.True(t, errors.Is(err, ErrNothingToResolve))
+	})
+}
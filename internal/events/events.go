@@ -0,0 +1,225 @@
+// Package events contains domain events and sinks delivering them, e.g. to Slack.
+package events
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+//go:generate mockgen -destination=./mock/events.go -package=mock -source=events.go
+
+// Type is a domain event type.
+type Type string
+
+// Event types.
+const (
+	RegistrationStarted Type = "registration_started"
+	Confirmed           Type = "confirmed"
+	ReferralInstalled   Type = "referral_installed"
+	RewardPaid          Type = "reward_paid"
+	DLoanCreated        Type = "dloan_created"
+)
+
+// ErrUnknownType is returned when the event type is unknown.
+var ErrUnknownType = fmt.Errorf("unknown event type")
+
+// ParseTypes parses event types, e.g. from flags.
+func ParseTypes(ss []string) ([]Type, error) {
+	out := make([]Type, len(ss))
+	for i, v := range ss {
+		switch t := Type(v); t {
+		case RegistrationStarted, Confirmed, ReferralInstalled, RewardPaid, DLoanCreated:
+			out[i] = t
+		default:
+			return nil, fmt.Errorf("%w: %s", ErrUnknownType, v)
+		}
+	}
+
+	return out, nil
+}
+
+// Event is a domain event.
+type Event interface {
+	Type() Type
+	// Summary is a human readable description of the event.
+	Summary() string
+}
+
+// RegistrationStartedEvent is published when the verification email is sent.
+type RegistrationStartedEvent struct {
+	Address      string `json:"address"`
+	ReferralCode string `json:"referralCode,omitempty"`
+}
+
+// Type ...
+func (RegistrationStartedEvent) Type() Type { return RegistrationStarted }
+
+// Summary ...
+func (e RegistrationStartedEvent) Summary() string {
+	return fmt.Sprintf("registration started by %s", e.Address)
+}
+
+// ConfirmedEvent is published when the registration is confirmed and initial stakes are sent.
+type ConfirmedEvent struct {
+	Address string  `json:"address"`
+	Stakes  sdk.Int `json:"stakes"`
+}
+
+// Type ...
+func (ConfirmedEvent) Type() Type { return Confirmed }
+
+// Summary ...
+func (e ConfirmedEvent) Summary() string {
+	return fmt.Sprintf("%s confirmed registration and got %s", e.Address, e.Stakes)
+}
+
+// ReferralInstalledEvent is published when the referral receiver installs the browser.
+type ReferralInstalledEvent struct {
+	Sender   string `json:"sender"`
+	Receiver string `json:"receiver"`
+}
+
+// Type ...
+func (ReferralInstalledEvent) Type() Type { return ReferralInstalled }
+
+// Summary ...
+func (e ReferralInstalledEvent) Summary() string {
+	return fmt.Sprintf("%s referred by %s installed the browser", e.Receiver, e.Sender)
+}
+
+// RewardPaidEvent is published when the referral rewards are sent.
+type RewardPaidEvent struct {
+	Sender         string  `json:"sender"`
+	Receiver       string  `json:"receiver"`
+	SenderReward   sdk.Int `json:"senderReward"`
+	ReceiverReward sdk.Int `json:"receiverReward"`
+}
+
+// Type ...
+func (RewardPaidEvent) Type() Type { return RewardPaid }
+
+// Summary ...
+func (e RewardPaidEvent) Summary() string {
+	return fmt.Sprintf("referral reward paid: %s got %s, %s got %s",
+		e.Sender, e.SenderReward, e.Receiver, e.ReceiverReward)
+}
+
+// DLoanCreatedEvent is published when dLoan is requested.
+type DLoanCreatedEvent struct {
+	Address   string  `json:"address"`
+	FirstName string  `json:"firstName"`
+	LastName  string  `json:"lastName"`
+	PDV       float64 `json:"pdv"`
+}
+
+// Type ...
+func (DLoanCreatedEvent) Type() Type { return DLoanCreated }
+
+// Summary ...
+func (e DLoanCreatedEvent) Summary() string {
+	return fmt.Sprintf("dLoan request from %s %s (%s) with PDV %g", e.FirstName, e.LastName, e.Address, e.PDV)
+}
+
+// Envelope is an event with its delivery metadata.
+type Envelope struct {
+	ID        string    `json:"id"`
+	Type      Type      `json:"type"`
+	CreatedAt time.Time `json:"createdAt"`
+	Data      Event     `json:"data"`
+}
+
+// NewEnvelope wraps the event with a new unique id.
+func NewEnvelope(e Event) Envelope {
+	return Envelope{
+		ID:        uuid.New().String(),
+		Type:      e.Type(),
+		CreatedAt: time.Now().UTC(),
+		Data:      e,
+	}
+}
+
+// Publisher publishes domain events.
+type Publisher interface {
+	// Publish delivers the event to sinks without blocking the caller.
+	Publish(ctx context.Context, e Event)
+}
+
+// Sink delivers events to a destination.
+type Sink interface {
+	Name() string
+	Send(ctx context.Context, e Envelope) error
+}
+
+// Bus is the Publisher delivering every event to all sinks concurrently.
+// Delivery errors are logged, a failed sink doesn't affect other sinks.
+type Bus struct {
+	sinks   []Sink
+	timeout time.Duration
+
+	wg sync.WaitGroup
+}
+
+// NewBus returns new instance of Bus. Every delivery is limited by the timeout.
+func NewBus(timeout time.Duration, sinks ...Sink) *Bus {
+	return &Bus{
+		sinks:   sinks,
+		timeout: timeout,
+	}
+}
+
+// Publish ...
+func (b *Bus) Publish(_ context.Context, e Event) {
+	env := NewEnvelope(e)
+
+	for _, s := range b.sinks {
+		b.wg.Add(1)
+		go func(s Sink) {
+			defer b.wg.Done()
+
+			// the caller's context is usually a request one which is done before the delivery
+			ctx, cancel := context.WithTimeout(context.Background(), b.timeout)
+			defer cancel()
+
+			if err := s.Send(ctx, env); err != nil {
+				log.WithError(err).WithFields(log.Fields{
+					"sink":  s.Name(),
+					"event": env.ID,
+					"type":  env.Type,
+				}).Error("failed to deliver event")
+			}
+		}(s)
+	}
+}
+
+// Close waits for deliveries in progress.
+func (b *Bus) Close() {
+	b.wg.Wait()
+}
+
+type filteredSink struct {
+	Sink
+	types map[Type]bool
+}
+
+// Filter returns the sink receiving only events of the given types.
+func Filter(s Sink, types ...Type) Sink {
+	m := make(map[Type]bool, len(types))
+	for _, t := range types {
+		m[t] = true
+	}
+
+	return filteredSink{Sink: s, types: m}
+}
+
+func (s filteredSink) Send(ctx context.Context, e Envelope) error {
+	if !s.types[e.Type] {
+		return nil
+	}
+	return s.Sink.Send(ctx, e)
+}
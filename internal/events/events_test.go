@@ -0,0 +1,114 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+)
+
+type testSink struct {
+	name string
+	err  error
+
+	mu       sync.Mutex
+	received []Envelope
+}
+
+func (s *testSink) Name() string {
+	return s.name
+}
+
+func (s *testSink) Send(_ context.Context, e Envelope) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.received = append(s.received, e)
+	return s.err
+}
+
+func TestBus_Publish(t *testing.T) {
+	failing := &testSink{name: "failing", err: fmt.Errorf("test")}
+	ok := &testSink{name: "ok"}
+	filtered := &testSink{name: "filtered"}
+
+	bus := NewBus(time.Second, failing, ok, Filter(filtered, DLoanCreated))
+	bus.Publish(context.Background(), ConfirmedEvent{Address: "addr", Stakes: sdk.NewInt(1)})
+	bus.Publish(context.Background(), DLoanCreatedEvent{Address: "addr", PDV: 1})
+	bus.Close()
+
+	require.Len(t, failing.received, 2)
+	require.Len(t, ok.received, 2)
+	require.Len(t, filtered.received, 1)
+	require.Equal(t, DLoanCreated, filtered.received[0].Type)
+	require.NotEmpty(t, filtered.received[0].ID)
+}
+
+func TestParseTypes(t *testing.T) {
+	types, err := ParseTypes([]string{"dloan_created", "reward_paid"})
+	require.NoError(t, err)
+	require.Equal(t, []Type{DLoanCreated, RewardPaid}, types)
+
+	_, err = ParseTypes([]string{"dloan_created", "unknown"})
+	require.True(t, errors.Is(err, ErrUnknownType))
+}
+
+func TestWebhookSink_Send(t *testing.T) {
+	secret := []byte("secret")
+	e := NewEnvelope(ReferralInstalledEvent{Sender: "sender", Receiver: "receiver"})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		require.Equal(t, "sha256="+Sign(secret, body), r.Header.Get(SignatureHeader))
+		require.Equal(t, string(ReferralInstalled), r.Header.Get(EventHeader))
+		require.Equal(t, e.ID, r.Header.Get(DeliveryHeader))
+
+		var got struct {
+			ID   string                 `json:"id"`
+			Type Type                   `json:"type"`
+			Data ReferralInstalledEvent `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(body, &got))
+		require.Equal(t, e.ID, got.ID)
+		require.Equal(t, e.Type, got.Type)
+		require.Equal(t, e.Data, got.Data)
+
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	require.NoError(t, NewWebhookSink(srv.Client(), srv.URL, secret).Send(context.Background(), e))
+
+	err := NewWebhookSink(srv.Client(), srv.URL+"/fail", secret).Send(context.Background(), e)
+	require.True(t, errors.Is(err, ErrUnexpectedStatus))
+}
+
+func TestSlackSink_Send(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg slackMessage
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		require.Equal(t, slackMessage{
+			Channel:   "alerts",
+			Username:  "vulcan",
+			IconEmoji: ":bread:",
+			Text:      "dLoan request from John Doe (addr) with PDV 1.5",
+		}, msg)
+	}))
+	defer srv.Close()
+
+	require.NoError(t, NewSlackSink(srv.Client(), srv.URL, "alerts").Send(context.Background(),
+		NewEnvelope(DLoanCreatedEvent{Address: "addr", FirstName: "John", LastName: "Doe", PDV: 1.5})))
+}
@@ -0,0 +1,42 @@
+package events
+
+import (
+	"context"
+	"net/http"
+)
+
+type slackMessage struct {
+	Channel   string `json:"channel,omitempty"`
+	Username  string `json:"username"`
+	IconEmoji string `json:"icon_emoji"`
+	Text      string `json:"text"`
+}
+
+type slackSink struct {
+	client  *http.Client
+	hookURL string
+	channel string
+}
+
+// NewSlackSink returns the sink posting event summaries to the Slack incoming webhook.
+// Empty channel means the webhook's default one.
+func NewSlackSink(client *http.Client, hookURL, channel string) Sink {
+	return slackSink{
+		client:  client,
+		hookURL: hookURL,
+		channel: channel,
+	}
+}
+
+func (s slackSink) Name() string {
+	return "slack"
+}
+
+func (s slackSink) Send(ctx context.Context, e Envelope) error {
+	return postJSON(ctx, s.client, s.hookURL, slackMessage{
+		Channel:   s.channel,
+		Username:  "vulcan",
+		IconEmoji: ":bread:",
+		Text:      e.Data.Summary(),
+	}, nil)
+}
@@ -0,0 +1,40 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/TessorNetwork/vulcan/internal/storage"
+)
+
+type storageSink struct {
+	storage storage.Storage
+}
+
+// NewStorageSink returns the sink storing events to the event log.
+func NewStorageSink(s storage.Storage) Sink {
+	return storageSink{storage: s}
+}
+
+func (s storageSink) Name() string {
+	return "storage"
+}
+
+func (s storageSink) Send(ctx context.Context, e Envelope) error {
+	payload, err := json.Marshal(e.Data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	if err := s.storage.CreateEvent(ctx, storage.Event{
+		ID:        e.ID,
+		Type:      string(e.Type),
+		Payload:   payload,
+		CreatedAt: e.CreatedAt,
+	}); err != nil {
+		return fmt.Errorf("failed to create event: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// Webhook headers.
+const (
+	SignatureHeader = "X-Vulcan-Signature"
+	EventHeader     = "X-Vulcan-Event"
+	DeliveryHeader  = "X-Vulcan-Delivery"
+)
+
+// ErrUnexpectedStatus is returned when the webhook responds with non-2xx status.
+var ErrUnexpectedStatus = fmt.Errorf("unexpected status")
+
+type webhookSink struct {
+	client *http.Client
+	url    string
+	secret []byte
+}
+
+// NewWebhookSink returns the sink posting JSON envelopes to the url.
+// The body is signed with HMAC-SHA256 of the secret, the hex signature is sent as "sha256=<signature>" in SignatureHeader.
+func NewWebhookSink(client *http.Client, url string, secret []byte) Sink {
+	return webhookSink{
+		client: client,
+		url:    url,
+		secret: secret,
+	}
+}
+
+func (s webhookSink) Name() string {
+	return "webhook"
+}
+
+func (s webhookSink) Send(ctx context.Context, e Envelope) error {
+	return postJSON(ctx, s.client, s.url, e, func(r *http.Request, body []byte) {
+		r.Header.Set(SignatureHeader, "sha256="+Sign(s.secret, body))
+		r.Header.Set(EventHeader, string(e.Type))
+		r.Header.Set(DeliveryHeader, e.ID)
+	})
+}
+
+// Sign returns hex encoded HMAC-SHA256 of the body.
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body) // nolint: errcheck
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func postJSON(ctx context.Context, client *http.Client, url string, v interface{},
+	prepare func(r *http.Request, body []byte)) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal body: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	if prepare != nil {
+		prepare(req, body)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close() // nolint: errcheck
+
+	// drain the body to reuse the connection
+	io.Copy(ioutil.Discard, resp.Body) // nolint: errcheck
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("%w: %d", ErrUnexpectedStatus, resp.StatusCode)
+	}
+
+	return nil
+}
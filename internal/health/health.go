@@ -6,11 +6,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/go-chi/chi"
 	"github.com/sirupsen/logrus"
-	"golang.org/x/sync/errgroup"
 )
 
 // nolint:gochecknoglobals
@@ -19,6 +19,13 @@ var (
 	commit  = "undefined"
 )
 
+// Readiness statuses.
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded"
+	StatusFail     = "fail"
+)
+
 // GetVersion returns service's version and commit.
 func GetVersion() string {
 	return fmt.Sprintf("%s-%s", version, commit)
@@ -35,6 +42,11 @@ type Pinger interface {
 	Ping(ctx context.Context) error
 }
 
+// Reporter is implemented by pingers which report details of their state along with the check result.
+type Reporter interface {
+	Details() map[string]interface{}
+}
+
 type subjectPinger struct {
 	f func(ctx context.Context) error
 	s string
@@ -58,38 +70,178 @@ func SubjectPinger(s string, f func(ctx context.Context) error) Pinger {
 	}
 }
 
-// SetupRouter setups all pingers to /health.
-func SetupRouter(r chi.Router, p ...Pinger) {
-	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
-		ctx, _ := context.WithTimeout(r.Context(), time.Second*5) // nolint:govet
-		gr, ctx := errgroup.WithContext(ctx)
-
-		for i := range p {
-			v := p[i]
-			gr.Go(func() error {
-				if err := v.Ping(ctx); err != nil {
-					logrus.WithError(err).Error("health check failed")
-					return err
-				}
-				return nil
-			})
+// Check is a named dependency check.
+type Check struct {
+	Name   string
+	Pinger Pinger
+	// Critical check failure makes the service not ready, other failures only degrade it.
+	Critical bool
+}
+
+// Critical returns the check which failure makes the service not ready.
+func Critical(name string, f func(ctx context.Context) error) Check {
+	return Check{Name: name, Pinger: SubjectPinger(name, f), Critical: true}
+}
+
+// NonCritical returns the check which failure is only reported.
+func NonCritical(name string, f func(ctx context.Context) error) Check {
+	return Check{Name: name, Pinger: SubjectPinger(name, f)}
+}
+
+// CheckResult is the last result of the check.
+type CheckResult struct {
+	Status        string                 `json:"status"`
+	Critical      bool                   `json:"critical"`
+	LatencyMs     int64                  `json:"latencyMs"`
+	CheckedAt     time.Time              `json:"checkedAt"`
+	LastSuccessAt *time.Time             `json:"lastSuccessAt,omitempty"`
+	Error         string                 `json:"error,omitempty"`
+	Details       map[string]interface{} `json:"details,omitempty"`
+}
+
+// ReadinessResponse ...
+type ReadinessResponse struct {
+	VersionResponse
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+// Checker runs checks and caches their results, so frequent probes don't hammer dependencies.
+type Checker struct {
+	checks  []Check
+	ttl     time.Duration
+	timeout time.Duration
+
+	mu        sync.Mutex
+	results   map[string]CheckResult
+	checkedAt time.Time
+}
+
+// NewChecker returns new instance of Checker.
+// Results are reused for ttl, every check is limited by timeout.
+func NewChecker(ttl, timeout time.Duration, checks ...Check) *Checker {
+	return &Checker{
+		checks:  checks,
+		ttl:     ttl,
+		timeout: timeout,
+		results: make(map[string]CheckResult, len(checks)),
+	}
+}
+
+// Check returns readiness status and results of all checks.
+func (c *Checker) Check() (string, map[string]CheckResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.checkedAt.IsZero() || time.Since(c.checkedAt) >= c.ttl {
+		c.refresh()
+	}
+
+	status := StatusOK
+	results := make(map[string]CheckResult, len(c.results))
+	for k, v := range c.results {
+		results[k] = v
+
+		if v.Status == StatusOK {
+			continue
+		}
+		if v.Critical {
+			status = StatusFail
+		} else if status == StatusOK {
+			status = StatusDegraded
+		}
+	}
+
+	return status, results
+}
+
+// refresh runs all checks concurrently. c.mu should be locked.
+func (c *Checker) refresh() {
+	results := make([]CheckResult, len(c.checks))
+
+	var wg sync.WaitGroup
+	for i := range c.checks {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = c.run(c.checks[i])
+		}(i)
+	}
+	wg.Wait()
+
+	for i, v := range c.checks {
+		res := results[i]
+		if res.Status == StatusOK {
+			t := res.CheckedAt
+			res.LastSuccessAt = &t
+		} else if prev, ok := c.results[v.Name]; ok {
+			res.LastSuccessAt = prev.LastSuccessAt
 		}
+		c.results[v.Name] = res
+	}
+
+	c.checkedAt = time.Now()
+}
+
+func (c *Checker) run(check Check) CheckResult {
+	// the result is shared by probes, so it shouldn't depend on the request context
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.Pinger.Ping(ctx)
+
+	res := CheckResult{
+		Status:    StatusOK,
+		Critical:  check.Critical,
+		LatencyMs: time.Since(start).Milliseconds(),
+		CheckedAt: start.UTC(),
+	}
 
-		if err := gr.Wait(); err != nil {
-			data, _ := json.Marshal(struct {
-				VersionResponse
-				Error string `json:"error"`
-			}{
-				Error:           err.Error(),
-				VersionResponse: VersionResponse{Version: version, Commit: commit},
-			})
-			w.WriteHeader(http.StatusInternalServerError)
-			w.Write(data) // nolint
-
-			return
+	if err != nil {
+		// non-critical failures only degrade the service, warnings aren't sent to sentry on every probe
+		l := logrus.WithError(err).WithField("check", check.Name).WithField("critical", check.Critical)
+		if check.Critical {
+			l.Error("health check failed")
+		} else {
+			l.Warn("health check failed")
 		}
+		res.Status = StatusFail
+		res.Error = err.Error()
+	}
+
+	if r, ok := check.Pinger.(Reporter); ok {
+		res.Details = r.Details()
+	}
 
+	return res
+}
+
+// SetupRouter setups /livez, /readyz and /health.
+// /livez responds OK while the process is able to serve requests.
+// /readyz responds with results of the checks, it fails only if some critical check fails.
+// /health is an alias of /readyz kept for compatibility.
+func SetupRouter(r chi.Router, c *Checker) {
+	r.Get("/livez", func(w http.ResponseWriter, r *http.Request) {
 		data, _ := json.Marshal(VersionResponse{Version: version, Commit: commit})
 		w.Write(data) // nolint
 	})
+
+	readyz := func(w http.ResponseWriter, r *http.Request) {
+		status, results := c.Check()
+
+		data, _ := json.Marshal(ReadinessResponse{
+			VersionResponse: VersionResponse{Version: version, Commit: commit},
+			Status:          status,
+			Checks:          results,
+		})
+
+		if status == StatusFail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		w.Write(data) // nolint
+	}
+
+	r.Get("/readyz", readyz)
+	r.Get("/health", readyz)
 }
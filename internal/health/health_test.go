@@ -0,0 +1,138 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+type testPinger struct {
+	calls int32
+	err   atomic.Value
+}
+
+func (p *testPinger) Ping(context.Context) error {
+	atomic.AddInt32(&p.calls, 1)
+	if err, ok := p.err.Load().(error); ok && err != nil {
+		return err
+	}
+	return nil
+}
+
+func (p *testPinger) Details() map[string]interface{} {
+	return map[string]interface{}{"calls": atomic.LoadInt32(&p.calls)}
+}
+
+func (p *testPinger) fail(err error) {
+	p.err.Store(err)
+}
+
+func readyz(t *testing.T, r http.Handler) (int, ReadinessResponse) {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	var resp ReadinessResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return w.Code, resp
+}
+
+func TestSetupRouter(t *testing.T) {
+	db, supply := &testPinger{}, &testPinger{}
+
+	r := chi.NewRouter()
+	c := NewChecker(time.Hour, time.Second,
+		Check{Name: "postgres", Pinger: db, Critical: true},
+		Check{Name: "supply", Pinger: supply},
+	)
+	SetupRouter(r, c)
+
+	code, resp := readyz(t, r)
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, StatusOK, resp.Status)
+	require.Len(t, resp.Checks, 2)
+	require.True(t, resp.Checks["postgres"].Critical)
+	require.NotNil(t, resp.Checks["supply"].LastSuccessAt)
+	require.EqualValues(t, 1, resp.Checks["supply"].Details["calls"])
+	lastSuccess := *resp.Checks["supply"].LastSuccessAt
+
+	// cached
+	readyz(t, r)
+	require.EqualValues(t, 1, atomic.LoadInt32(&db.calls))
+
+	supply.fail(fmt.Errorf("stale"))
+	c.checkedAt = time.Time{}
+
+	code, resp = readyz(t, r)
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, StatusDegraded, resp.Status)
+	require.Equal(t, StatusFail, resp.Checks["supply"].Status)
+	require.Equal(t, "stale", resp.Checks["supply"].Error)
+	require.Equal(t, lastSuccess, *resp.Checks["supply"].LastSuccessAt)
+
+	db.fail(fmt.Errorf("down"))
+	c.checkedAt = time.Time{}
+
+	code, resp = readyz(t, r)
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.Equal(t, StatusFail, resp.Status)
+
+	// liveness doesn't depend on checks
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/livez", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.EqualValues(t, 3, atomic.LoadInt32(&db.calls))
+}
+
+type levelsHook struct {
+	mu     sync.Mutex
+	levels map[string]logrus.Level
+}
+
+func (h *levelsHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *levelsHook) Fire(e *logrus.Entry) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if check, ok := e.Data["check"].(string); ok {
+		h.levels[check] = e.Level
+	}
+	return nil
+}
+
+func TestChecker_Check_LogLevel(t *testing.T) {
+	hook := &levelsHook{levels: map[string]logrus.Level{}}
+	logger := logrus.StandardLogger()
+	hooks := logger.ReplaceHooks(logrus.LevelHooks{})
+	defer logger.ReplaceHooks(hooks)
+	logger.AddHook(hook)
+
+	db, supply := &testPinger{}, &testPinger{}
+	db.fail(fmt.Errorf("down"))
+	supply.fail(fmt.Errorf("stale"))
+
+	c := NewChecker(time.Hour, time.Second,
+		Check{Name: "postgres", Pinger: db, Critical: true},
+		Check{Name: "supply", Pinger: supply},
+	)
+
+	status, _ := c.Check()
+	require.Equal(t, StatusFail, status)
+	// only critical failures are errors reported to sentry
+	require.Equal(t, map[string]logrus.Level{
+		"postgres": logrus.ErrorLevel,
+		"supply":   logrus.WarnLevel,
+	}, hook.levels)
+}
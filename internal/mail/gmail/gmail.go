@@ -10,8 +10,17 @@ import (
 	"text/template"
 
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/TessorNetwork/vulcan/internal/mail"
+	"github.com/TessorNetwork/vulcan/internal/metrics"
+	"github.com/TessorNetwork/vulcan/internal/tracing"
+)
+
+const (
+	provider          = "gmail"
+	verificationEmail = "verification"
+	welcomeEmail      = "welcome"
 )
 
 // nolint:gochecknoglobals
@@ -51,7 +60,7 @@ func New(config *Config) mail.Sender {
 }
 
 // SendVerificationEmailAsync sends an email to account owner.
-func (s *sender) SendVerificationEmailAsync(_ context.Context, email, code string) {
+func (s *sender) SendVerificationEmailAsync(ctx context.Context, email, code string) {
 	log := logrus.WithFields(logrus.Fields{
 		"to": email,
 	})
@@ -66,19 +75,23 @@ func (s *sender) SendVerificationEmailAsync(_ context.Context, email, code strin
 	})
 
 	if err != nil {
+		metrics.IncEmails(provider, verificationEmail, metrics.OutcomeError)
 		log.WithError(err).Error("failed to execute confirm template")
 		return
 	}
 
 	go func() {
-		if err := s.sendEmail(s.config.VerificationSubject, email, body.String()); err != nil {
+		if err := s.sendEmail(ctx, s.config.VerificationSubject, email, body.String()); err != nil {
+			metrics.IncEmails(provider, verificationEmail, metrics.OutcomeError)
 			log.WithError(err).Error("failed to send email")
+			return
 		}
+		metrics.IncEmails(provider, verificationEmail, metrics.OutcomeSuccess)
 	}()
 }
 
 // SendWelcomeEmailAsync sends an welcome email in async mode.
-func (s *sender) SendWelcomeEmailAsync(_ context.Context, email string) {
+func (s *sender) SendWelcomeEmailAsync(ctx context.Context, email string) {
 	log := logrus.WithFields(logrus.Fields{
 		"to": email,
 	})
@@ -91,18 +104,26 @@ func (s *sender) SendWelcomeEmailAsync(_ context.Context, email string) {
 	})
 
 	if err != nil {
+		metrics.IncEmails(provider, welcomeEmail, metrics.OutcomeError)
 		log.WithError(err).Error("failed to execute welcome template")
 		return
 	}
 
 	go func() {
-		if err := s.sendEmail(s.config.WelcomeSubject, email, body.String()); err != nil {
+		if err := s.sendEmail(ctx, s.config.WelcomeSubject, email, body.String()); err != nil {
+			metrics.IncEmails(provider, welcomeEmail, metrics.OutcomeError)
 			log.WithError(err).Error("failed to send email")
+			return
 		}
+		metrics.IncEmails(provider, welcomeEmail, metrics.OutcomeSuccess)
 	}()
 }
 
-func (s *sender) sendEmail(subj, to, body string) error {
+// sendEmail sends the email within the span of ctx, the span may end after the span of the request.
+func (s *sender) sendEmail(ctx context.Context, subj, to, body string) (err error) {
+	_, span := tracing.Start(ctx, "gmail.SendMail", trace.WithSpanKind(trace.SpanKindClient))
+	defer func() { tracing.End(span, err) }()
+
 	headerSubj := fmt.Sprintf("Subject: %s\n", subj)
 	headerTo := fmt.Sprintf("To: %s\n", to)
 	headerMime := "MIME-version: 1.0;\nContent-Type: text/html; charset=\"UTF-8\";\n\n"
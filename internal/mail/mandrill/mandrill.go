@@ -6,8 +6,11 @@ import (
 	"errors"
 
 	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/TessorNetwork/vulcan/internal/mail"
+	"github.com/TessorNetwork/vulcan/internal/metrics"
+	"github.com/TessorNetwork/vulcan/internal/tracing"
 
 	"github.com/keighl/mandrill"
 )
@@ -15,6 +18,12 @@ import (
 const mandrillSentStatus = "sent"
 const mandrillQueuedStatus = "queued"
 
+const (
+	provider          = "mandrill"
+	verificationEmail = "verification"
+	welcomeEmail      = "welcome"
+)
+
 type sender struct {
 	config *Config
 	client *mandrill.Client
@@ -41,7 +50,7 @@ func New(client *mandrill.Client, config *Config) mail.Sender {
 }
 
 // SendVerificationEmailAsync sends an email to account owner.
-func (s *sender) SendVerificationEmailAsync(_ context.Context, email, code string) {
+func (s *sender) SendVerificationEmailAsync(ctx context.Context, email, code string) {
 	message := mandrill.Message{
 		Subject:   s.config.VerificationSubject,
 		FromEmail: s.config.FromEmail,
@@ -54,11 +63,13 @@ func (s *sender) SendVerificationEmailAsync(_ context.Context, email, code strin
 	message.AddRecipient(email, "", "to")
 
 	go func() {
-		responses, err := s.client.MessagesSendTemplate(&message, s.config.VerificationTemplateName, nil)
+		responses, err := s.sendTemplate(ctx, &message, s.config.VerificationTemplateName)
 		if err != nil {
+			metrics.IncEmails(provider, verificationEmail, metrics.OutcomeError)
 			log.WithFields(log.Fields{
 				"email": email,
 			}).WithError(err).Error("failed to send email")
+			return
 		}
 
 		for _, v := range responses {
@@ -69,14 +80,17 @@ func (s *sender) SendVerificationEmailAsync(_ context.Context, email, code strin
 					"id":     v.Id,
 					"status": v.Status,
 				}).WithError(mail.ErrMailRejected).Error("failed to send email")
+				metrics.IncEmails(provider, verificationEmail, metrics.OutcomeRejected)
 				return
 			}
 		}
+
+		metrics.IncEmails(provider, verificationEmail, metrics.OutcomeSuccess)
 	}()
 }
 
 // SendWelcomeEmailAsync sends an welcome email in async mode.
-func (s *sender) SendWelcomeEmailAsync(_ context.Context, email string) {
+func (s *sender) SendWelcomeEmailAsync(ctx context.Context, email string) {
 	message := mandrill.Message{
 		Subject:   s.config.WelcomeSubject,
 		FromEmail: s.config.FromEmail,
@@ -86,8 +100,9 @@ func (s *sender) SendWelcomeEmailAsync(_ context.Context, email string) {
 	message.AddRecipient(email, "", "to")
 
 	go func() {
-		responses, err := s.client.MessagesSendTemplate(&message, s.config.WelcomeTemplateName, nil)
+		responses, err := s.sendTemplate(ctx, &message, s.config.WelcomeTemplateName)
 		if err != nil {
+			metrics.IncEmails(provider, welcomeEmail, metrics.OutcomeError)
 			log.WithError(err).WithField("email", email).Error("failed to send welcome email")
 			return
 		}
@@ -100,8 +115,20 @@ func (s *sender) SendWelcomeEmailAsync(_ context.Context, email string) {
 					"status":           v.Status,
 					"rejection_reason": v.RejectionReason,
 				}).Errorf("failed to send welcome email")
+				metrics.IncEmails(provider, welcomeEmail, metrics.OutcomeRejected)
 				return
 			}
 		}
+
+		metrics.IncEmails(provider, welcomeEmail, metrics.OutcomeSuccess)
 	}()
 }
+
+// sendTemplate sends the message within the span of ctx, the span may end after the span of the request.
+func (s *sender) sendTemplate(ctx context.Context, message *mandrill.Message,
+	templateName string) (_ []*mandrill.Response, err error) {
+	_, span := tracing.Start(ctx, "mandrill.MessagesSendTemplate", trace.WithSpanKind(trace.SpanKindClient))
+	defer func() { tracing.End(span, err) }()
+
+	return s.client.MessagesSendTemplate(message, templateName, nil)
+}
@@ -0,0 +1,209 @@
+// Package metrics contains Prometheus metrics of the services.
+package metrics
+
+import (
+	"math/big"
+	"net/http"
+	"strconv"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "vulcan"
+
+// Outcomes of the operations.
+const (
+	OutcomeSuccess  = "success"
+	OutcomeError    = "error"
+	OutcomeRejected = "rejected"
+	OutcomeDead     = "dead"
+)
+
+// Payout kinds.
+const (
+	PayoutConfirmation       = "confirmation"
+	PayoutTestnet            = "testnet"
+	PayoutReferralSender     = "referral_sender"
+	PayoutReferralReceiver   = "referral_receiver"
+	PayoutReferralUpperLevel = "referral_upper_level"
+)
+
+// nolint:gochecknoglobals
+var (
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	registrations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "registrations_total",
+		Help:      "Registration requests by outcome.",
+	}, []string{"outcome"})
+
+	confirmations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "confirmations_total",
+		Help:      "Registration confirmations by outcome.",
+	}, []string{"outcome"})
+
+	payouts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "payouts_total",
+		Help:      "Sent payouts by kind.",
+	}, []string{"kind"})
+
+	payoutAmount = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "payout_amount_ufury_total",
+		Help:      "Sent payouts amount in ufury by kind.",
+	}, []string{"kind"})
+
+	broadcastFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "broadcast_failures_total",
+		Help:      "Failed blockchain broadcasts by reason.",
+	}, []string{"reason"})
+
+	emails = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "emails_total",
+		Help:      "Email send attempts by provider, email type and outcome.",
+	}, []string{"provider", "type", "outcome"})
+
+	rewarderRunDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "rewarder_run_duration_seconds",
+		Help:      "Referral rewarder run duration.",
+		Buckets:   prometheus.ExponentialBuckets(1, 2, 12),
+	})
+
+	rewarderLastSuccess = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "rewarder_last_success_timestamp_seconds",
+		Help:      "Unix time of the last successful referral rewarder run.",
+	})
+
+	supplyUpdatedAt = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "supply_updated_timestamp_seconds",
+		Help:      "Unix time of the last successful supply poll by source.",
+	}, []string{"source"})
+
+	supplyPollErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "supply_poll_errors_total",
+		Help:      "Failed supply polls by source.",
+	}, []string{"source"})
+
+	webhookDeliveries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_deliveries_total",
+		Help:      "Partner webhook delivery attempts by outcome.",
+	}, []string{"outcome"})
+)
+
+// nolint: gochecknoinits
+func init() {
+	prometheus.MustRegister(
+		httpRequestDuration,
+		registrations,
+		confirmations,
+		payouts,
+		payoutAmount,
+		broadcastFailures,
+		emails,
+		rewarderRunDuration,
+		rewarderLastSuccess,
+		supplyUpdatedAt,
+		supplyPollErrors,
+		webhookDeliveries,
+	)
+}
+
+// Handler returns HTTP handler exposing the metrics.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// Middleware measures requests latency by chi route pattern, so path parameters don't produce new series.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		next.ServeHTTP(ww, r)
+
+		route := "unknown"
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		httpRequestDuration.WithLabelValues(r.Method, route, strconv.Itoa(status)).Observe(time.Since(start).Seconds())
+	})
+}
+
+// IncRegistrations counts a registration request.
+func IncRegistrations(outcome string) {
+	registrations.WithLabelValues(outcome).Inc()
+}
+
+// IncConfirmations counts a registration confirmation.
+func IncConfirmations(outcome string) {
+	confirmations.WithLabelValues(outcome).Inc()
+}
+
+// AddPayout counts a sent payout.
+func AddPayout(kind string, amount sdk.Int) {
+	payouts.WithLabelValues(kind).Inc()
+
+	v, _ := new(big.Float).SetInt(amount.BigInt()).Float64()
+	payoutAmount.WithLabelValues(kind).Add(v)
+}
+
+// IncBroadcastFailures counts a failed broadcast.
+func IncBroadcastFailures(reason string) {
+	broadcastFailures.WithLabelValues(reason).Inc()
+}
+
+// IncEmails counts an email send attempt.
+func IncEmails(provider, kind, outcome string) {
+	emails.WithLabelValues(provider, kind, outcome).Inc()
+}
+
+// ObserveRewarderRun records the rewarder run duration.
+func ObserveRewarderRun(d time.Duration) {
+	rewarderRunDuration.Observe(d.Seconds())
+}
+
+// SetRewarderLastSuccess records the time of the last successful rewarder run.
+func SetRewarderLastSuccess(t time.Time) {
+	rewarderLastSuccess.Set(float64(t.Unix()))
+}
+
+// SetSupplyUpdatedAt records the last successful poll time of the supply source.
+func SetSupplyUpdatedAt(source string, t time.Time) {
+	supplyUpdatedAt.WithLabelValues(source).Set(float64(t.Unix()))
+}
+
+// IncSupplyPollErrors counts a failed poll of the supply source.
+func IncSupplyPollErrors(source string) {
+	supplyPollErrors.WithLabelValues(source).Inc()
+}
+
+// IncWebhookDeliveries counts a partner webhook delivery attempt.
+func IncWebhookDeliveries(outcome string) {
+	webhookDeliveries.WithLabelValues(outcome).Inc()
+}
@@ -0,0 +1,46 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/require"
+)
+
+func scrape(t *testing.T) string {
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	return w.Body.String()
+}
+
+func TestMiddleware(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(Middleware)
+	r.Route("/v1", func(r chi.Router) {
+		r.Get("/code/{address}", func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		})
+		r.Get("/ok", func(http.ResponseWriter, *http.Request) {})
+	})
+
+	for _, path := range []string{"/v1/code/a", "/v1/code/b", "/v1/ok"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	out := scrape(t)
+	require.Contains(t, out, `vulcan_http_request_duration_seconds_count{method="GET",route="/v1/code/{address}",status="404"} 2`)
+	require.Contains(t, out, `vulcan_http_request_duration_seconds_count{method="GET",route="/v1/ok",status="200"} 1`)
+}
+
+func TestAddPayout(t *testing.T) {
+	AddPayout(PayoutReferralReceiver, sdk.NewInt(1500000))
+	AddPayout(PayoutReferralReceiver, sdk.NewInt(500000))
+
+	out := scrape(t)
+	require.Contains(t, out, `vulcan_payouts_total{kind="referral_receiver"} 2`)
+	require.Contains(t, out, `vulcan_payout_amount_ufury_total{kind="referral_receiver"} 2e+06`)
+}
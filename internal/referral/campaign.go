@@ -0,0 +1,66 @@
+package referral
+
+import (
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"github.com/TessorNetwork/vulcan/internal/storage"
+)
+
+// ApplyCampaigns returns the sender reward changed by the most profitable campaign applicable to the referral
+// and the campaign itself. The reward is returned as is when no campaign applies.
+// Campaigns only raise the reward: a campaign not giving more than the reward, e.g. a fixed reward lower
+// than the base one, isn't applied.
+// code is the referral code the receiver registered with, the campaign applies if it was active at registeredAt.
+func ApplyCampaigns(campaigns []*storage.ReferralCampaign, sender, code string, registeredAt time.Time,
+	reward sdk.Int) (sdk.Int, *storage.ReferralCampaign) {
+	var (
+		best       *storage.ReferralCampaign
+		bestReward = reward
+	)
+
+	for _, c := range campaigns {
+		if !isCampaignApplicable(c, sender, code, registeredAt) {
+			continue
+		}
+
+		if r := getCampaignReward(c, reward); r.GT(bestReward) {
+			best, bestReward = c, r
+		}
+	}
+
+	return bestReward, best
+}
+
+func isCampaignApplicable(c *storage.ReferralCampaign, sender, code string, registeredAt time.Time) bool {
+	if registeredAt.Before(c.StartsAt) || !registeredAt.Before(c.EndsAt) {
+		return false
+	}
+
+	if len(c.Codes) == 0 && len(c.Senders) == 0 {
+		return true
+	}
+
+	return contains(c.Senders, sender) || (code != "" && contains(c.Codes, code))
+}
+
+func getCampaignReward(c *storage.ReferralCampaign, reward sdk.Int) sdk.Int {
+	switch {
+	case c.FixedReward != nil:
+		return *c.FixedReward
+	case c.Multiplier != nil:
+		return reward.ToFur().Mul(*c.Multiplier).TruncateInt()
+	default:
+		return reward
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,108 @@
+package referral
+
+import (
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/TessorNetwork/vulcan/internal/storage"
+)
+
+func TestApplyCampaigns(t *testing.T) {
+	double := sdk.MustNewFurFromStr("2")
+	triple := sdk.MustNewFurFromStr("3")
+	half := sdk.MustNewFurFromStr("0.5")
+	fixed := sdk.NewInt(25)
+	lowFixed := sdk.NewInt(5)
+
+	registeredAt := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+	startsAt, endsAt := registeredAt.Add(-24*time.Hour), registeredAt.Add(24*time.Hour)
+
+	everyone := &storage.ReferralCampaign{ID: 1, Multiplier: &double, StartsAt: startsAt, EndsAt: endsAt}
+	partner := &storage.ReferralCampaign{ID: 2, Multiplier: &triple, Codes: []string{"partner"},
+		StartsAt: startsAt, EndsAt: endsAt}
+	vip := &storage.ReferralCampaign{ID: 3, FixedReward: &fixed, Senders: []string{"vip"},
+		StartsAt: startsAt, EndsAt: endsAt}
+	lower := &storage.ReferralCampaign{ID: 4, FixedReward: &lowFixed, StartsAt: startsAt, EndsAt: endsAt}
+	halved := &storage.ReferralCampaign{ID: 5, Multiplier: &half, StartsAt: startsAt, EndsAt: endsAt}
+	finished := &storage.ReferralCampaign{ID: 6, Multiplier: &triple, StartsAt: startsAt, EndsAt: registeredAt}
+	future := &storage.ReferralCampaign{ID: 7, Multiplier: &triple, StartsAt: endsAt, EndsAt: endsAt.Add(time.Hour)}
+
+	campaigns := []*storage.ReferralCampaign{everyone, partner, vip}
+	base := sdk.NewInt(10)
+
+	tt := []struct {
+		name      string
+		campaigns []*storage.ReferralCampaign
+		sender    string
+		code      string
+		reward    sdk.Int
+		campaign  *storage.ReferralCampaign
+	}{
+		{
+			name:   "no campaigns",
+			sender: "sender",
+			reward: base,
+		},
+		{
+			name:      "everyone",
+			campaigns: campaigns,
+			sender:    "sender",
+			code:      "code",
+			reward:    sdk.NewInt(20),
+			campaign:  everyone,
+		},
+		{
+			name:      "by code",
+			campaigns: campaigns,
+			sender:    "sender",
+			code:      "partner",
+			reward:    sdk.NewInt(30),
+			campaign:  partner,
+		},
+		{
+			name:      "by sender",
+			campaigns: campaigns,
+			sender:    "vip",
+			reward:    sdk.NewInt(25),
+			campaign:  vip,
+		},
+		{
+			name:      "not applicable",
+			campaigns: []*storage.ReferralCampaign{partner, vip},
+			sender:    "sender",
+			code:      "code",
+			reward:    base,
+		},
+		{
+			name:      "lower rewards aren't applied",
+			campaigns: []*storage.ReferralCampaign{lower, halved},
+			sender:    "sender",
+			reward:    base,
+		},
+		{
+			name:      "lower reward first",
+			campaigns: []*storage.ReferralCampaign{lower, everyone},
+			sender:    "sender",
+			reward:    sdk.NewInt(20),
+			campaign:  everyone,
+		},
+		{
+			name:      "not active at registration",
+			campaigns: []*storage.ReferralCampaign{finished, future},
+			sender:    "sender",
+			reward:    base,
+		},
+	}
+
+	for i := range tt {
+		tc := tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			reward, campaign := ApplyCampaigns(tc.campaigns, tc.sender, tc.code, registeredAt, base)
+			require.Equal(t, tc.reward, reward)
+			require.Equal(t, tc.campaign, campaign)
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package referral
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	minCodeLength = 4
+	maxCodeLength = 20
+)
+
+// ErrInvalidCode is returned when a vanity referral code doesn't pass validation.
+var ErrInvalidCode = fmt.Errorf("invalid referral code")
+
+var codeRegExp = regexp.MustCompile("^[a-z0-9][a-z0-9_-]*$")
+
+// nolint:gochecknoglobals
+var reservedCodes = map[string]struct{}{
+	"admin":     {},
+	"api":       {},
+	"furya":     {},
+	"help":      {},
+	"moderator": {},
+	"null":      {},
+	"official":  {},
+	"root":      {},
+	"support":   {},
+	"system":    {},
+	"undefined": {},
+	"vulcan":    {},
+}
+
+// nolint:gochecknoglobals
+var profaneWords = []string{
+	"bitch",
+	"cunt",
+	"fuck",
+	"nazi",
+	"porn",
+	"shit",
+	"slut",
+	"whore",
+}
+
+// NormalizeCode returns the canonical form of a vanity referral code.
+func NormalizeCode(code string) string {
+	return strings.ToLower(strings.TrimSpace(code))
+}
+
+// ValidateCode checks the normalized vanity referral code.
+func ValidateCode(code string) error {
+	if len(code) < minCodeLength || len(code) > maxCodeLength {
+		return fmt.Errorf("%w: length should be between %d and %d", ErrInvalidCode, minCodeLength, maxCodeLength)
+	}
+
+	if !codeRegExp.MatchString(code) {
+		return fmt.Errorf("%w: only latin letters, digits, '-' and '_' are allowed", ErrInvalidCode)
+	}
+
+	if _, ok := reservedCodes[code]; ok {
+		return fmt.Errorf("%w: code is reserved", ErrInvalidCode)
+	}
+
+	for _, w := range profaneWords {
+		if strings.Contains(code, w) {
+			return fmt.Errorf("%w: code contains inappropriate word", ErrInvalidCode)
+		}
+	}
+
+	return nil
+}
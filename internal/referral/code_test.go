@@ -0,0 +1,44 @@
+package referral
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateCode(t *testing.T) {
+	tt := []struct {
+		code  string
+		valid bool
+	}{
+		{code: "alice", valid: true},
+		{code: "bob_2022", valid: true},
+		{code: "crypto-king", valid: true},
+		{code: "abc", valid: false},
+		{code: "abcdefghijklmnopqrstu", valid: false},
+		{code: "Alice", valid: false},
+		{code: "-alice", valid: false},
+		{code: "ali ce", valid: false},
+		{code: "алиса", valid: false},
+		{code: "admin", valid: false},
+		{code: "furya", valid: false},
+		{code: "shitcoin", valid: false},
+	}
+
+	for i := range tt {
+		tc := tt[i]
+		t.Run(tc.code, func(t *testing.T) {
+			err := ValidateCode(tc.code)
+			if tc.valid {
+				require.NoError(t, err)
+			} else {
+				require.True(t, errors.Is(err, ErrInvalidCode))
+			}
+		})
+	}
+}
+
+func TestNormalizeCode(t *testing.T) {
+	require.Equal(t, "alice", NormalizeCode(" Alice "))
+}
@@ -3,17 +3,24 @@ package referral
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"strconv"
+	"sync"
 	"time"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
 
 	tokentypes "github.com/TessorNetwork/furya/x/token/types"
 
 	"github.com/TessorNetwork/vulcan/internal/blockchain"
+	"github.com/TessorNetwork/vulcan/internal/events"
+	"github.com/TessorNetwork/vulcan/internal/metrics"
 	"github.com/TessorNetwork/vulcan/internal/storage"
+	"github.com/TessorNetwork/vulcan/internal/tracing"
 )
 
 const denominator = 6
@@ -39,6 +46,9 @@ type Config struct {
 	ReceiverReward     sdk.Int       `json:"receiverReward"`
 	SenderBonuses      []Bonus       `json:"senderBonus"`
 	SenderRewardLevels []RewardLevel `json:"senderRewardLevels"`
+	// UpperLevelRewardPercents are percents of the sender reward the upper referrers get.
+	// The first item is for the sender's referrer, the second one is for the referrer's referrer and so on.
+	UpperLevelRewardPercents []sdk.Fur `json:"upperLevelRewardPercents,omitempty"`
 }
 
 // NewConfig creates a new instance of Config.
@@ -99,52 +109,211 @@ func (c Config) GetSenderReward(confirmedReferralsCount int) sdk.Int {
 	return c.SenderRewardLevels[len(c.SenderRewardLevels)-1].Reward
 }
 
+// ErrInvalidPercent is returned when an upper level reward percent is not within (0, 100].
+var ErrInvalidPercent = fmt.Errorf("invalid percent")
+
+// ParseUpperLevelRewardPercents parses upper level reward percents, e.g. ["10", "2.5"].
+func ParseUpperLevelRewardPercents(ss []string) ([]sdk.Fur, error) {
+	out := make([]sdk.Fur, len(ss))
+	for i, v := range ss {
+		p, err := sdk.NewFurFromStr(v)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidPercent, v)
+		}
+
+		if !p.IsPositive() || p.GT(sdk.NewFur(100)) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidPercent, v)
+		}
+
+		out[i] = p
+	}
+
+	return out, nil
+}
+
+// GetUpperLevelReward returns a reward of the upper referrer at the given level.
+// Level 2 is the sender's referrer.
+func (c Config) GetUpperLevelReward(level int, senderReward sdk.Int) sdk.Int {
+	idx := level - 2
+	if idx < 0 || idx >= len(c.UpperLevelRewardPercents) {
+		return sdk.ZeroInt()
+	}
+
+	return senderReward.ToFur().Mul(c.UpperLevelRewardPercents[idx]).QuoInt64(100).TruncateInt()
+}
+
+// ErrRewarderNotStarted is returned by Ping before Run.
+var ErrRewarderNotStarted = fmt.Errorf("rewarder is not started")
+
+// ErrRewarderLag is returned by Ping when the last successful run is too old.
+var ErrRewarderLag = fmt.Errorf("rewarder lags")
+
+// ErrRewardsFailed is returned by the run when some referrals weren't checked or rewarded, the next run retries them.
+var ErrRewardsFailed = fmt.Errorf("rewards failed")
+
 // Rewarder ...
 type Rewarder struct {
 	storage storage.Storage
 	bmc     blockchain.Blockchain
 	brc     tokentypes.QueryClient
+	events  events.Publisher
 	rc      Config
+
+	mu            sync.Mutex
+	interval      time.Duration
+	startedAt     time.Time
+	running       bool
+	lastRunAt     time.Time
+	lastSuccessAt time.Time
+	lastError     string
 }
 
-// NewRewarder creates a new instance of Rewarder.
+// NewRewarder creates a new instance of Rewarder. publisher may be nil.
 func NewRewarder(s storage.Storage, b blockchain.Blockchain, brc tokentypes.QueryClient,
-	rc Config) *Rewarder {
+	publisher events.Publisher, rc Config) *Rewarder {
 	return &Rewarder{
 		storage: s,
 		bmc:     b,
 		brc:     brc,
+		events:  publisher,
 		rc:      rc,
 	}
 }
 
-// Run runs the rewarder check referral status loop.
+// Run runs the rewarder check referral status loop until the context is done.
+// The cancellation doesn't interrupt the reward in progress, Run returns when it is finished.
 func (r *Rewarder) Run(ctx context.Context, interval time.Duration) {
-	r.do(ctx)
+	r.mu.Lock()
+	r.startedAt = time.Now()
+	r.interval = interval
+	r.mu.Unlock()
 
 	ticker := time.NewTicker(interval)
-	go func(ticker *time.Ticker) {
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case <-ticker.C:
-				r.do(ctx)
-			}
+	defer ticker.Stop()
+
+	for {
+		r.run(ctx)
+
+		select {
+		case <-ctx.Done():
+			log.Info("rewarder stopped")
+			return
+		case <-ticker.C:
 		}
-	}(ticker)
+	}
 }
 
-func (r *Rewarder) do(ctx context.Context) {
-	referrals, err := r.storage.GetUnconfirmedReferralTracking(ctx, r.rc.ThresholdDays)
+func (r *Rewarder) run(ctx context.Context) {
+	r.mu.Lock()
+	r.running = true
+	r.mu.Unlock()
+
+	err := r.do(ctx)
 	if err != nil {
-		log.WithError(err).Error("failed to get unconfirmed referrals")
+		log.WithError(err).Error("rewarder run failed")
+	}
+
+	now := time.Now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.running = false
+	r.lastRunAt = now
+	if err != nil {
+		r.lastError = err.Error()
 		return
 	}
+	r.lastError = ""
+	r.lastSuccessAt = now
+	metrics.SetRewarderLastSuccess(now)
+}
+
+// Ping fails if the last successful run was more than two intervals ago,
+// i.e. the rewarder is stuck or fails continuously.
+func (r *Rewarder) Ping(context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.startedAt.IsZero() {
+		return ErrRewarderNotStarted
+	}
+
+	if lag, maxLag := r.lag(), 2*r.interval; lag > maxLag {
+		return fmt.Errorf("%w: last successful run %s ago, max lag is %s", ErrRewarderLag,
+			lag.Truncate(time.Second), maxLag)
+	}
+
+	return nil
+}
+
+// Details returns the rewarder state for the health check.
+func (r *Rewarder) Details() map[string]interface{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	details := map[string]interface{}{
+		"running": r.running,
+	}
+
+	if r.startedAt.IsZero() {
+		return details
+	}
+
+	details["lagSeconds"] = int64(r.lag().Seconds())
+	if !r.lastRunAt.IsZero() {
+		details["lastRunAt"] = r.lastRunAt.UTC()
+	}
+	if !r.lastSuccessAt.IsZero() {
+		details["lastSuccessAt"] = r.lastSuccessAt.UTC()
+	}
+	if r.lastError != "" {
+		details["lastError"] = r.lastError
+	}
+
+	return details
+}
+
+// lag returns time since the last successful run or since the start if there was no one. r.mu should be locked.
+func (r *Rewarder) lag() time.Duration {
+	if r.lastSuccessAt.IsZero() {
+		return time.Since(r.startedAt)
+	}
+	return time.Since(r.lastSuccessAt)
+}
+
+// do rewards eligible referrals. When the context is done it stops between referrals,
+// the reward in progress uses the uninterruptible context to be finished consistently.
+func (r *Rewarder) do(ctx context.Context) (err error) {
+	start := time.Now()
+	defer func() { metrics.ObserveRewarderRun(time.Since(start)) }()
+
+	ctx, span := tracing.Start(ctx, "rewarder.do")
+	defer func() { tracing.End(span, err) }()
+
+	// the leaderboard windows are sliding, so it is refreshed even if nothing was rewarded
+	defer r.refreshLeaderboard(ctx)
+
+	referrals, err := r.storage.GetUnconfirmedReferralTracking(ctx, r.rc.ThresholdDays)
+	if err != nil {
+		return fmt.Errorf("failed to get unconfirmed referrals: %w", err)
+	}
 
 	log.Infof("uncofirmed referrals count: %d", len(referrals))
 
+	campaigns, err := r.getCampaigns(ctx, referrals)
+	if err != nil {
+		return fmt.Errorf("failed to get referral campaigns: %w", err)
+	}
+
+	// referrals which may be eligible but weren't rewarded
+	var failed int
+
 	for _, ref := range referrals {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("interrupted: %w", err)
+		}
+
 		logger := r.getLogger(ref)
 
 		address, err := sdk.AccAddressFromBech32(ref.Receiver)
@@ -158,26 +327,51 @@ func (r *Rewarder) do(ctx context.Context) {
 		})
 		if err != nil {
 			logger.WithError(err).Error("failed to get PDV token balance")
+			failed++
 			continue
 		}
 
 		if resp.Balance.Fur.GT(r.rc.ThresholdPDV) {
 			count, err := r.storage.GetConfirmedReferralTrackingCount(ctx, ref.Sender)
 			if err != nil {
-				logger.WithError(err).Error("failed to get confirmed referrals count")
-				return
+				return fmt.Errorf("failed to get confirmed referrals count of %s: %w", ref.Sender, err)
+			}
+			// the payout isn't interrupted by ctx, but it belongs to the run trace
+			if err := r.reward(trace.ContextWithSpan(context.Background(), span), ref, count+1, campaigns); err != nil {
+				logger.WithError(err).Error("failed to reward")
+				failed++
 			}
-			r.reward(ctx, ref, count+1)
 		} else {
 			logger.Infof("balance %d less than threshold %d", resp.Balance.Fur, r.rc.ThresholdPDV)
 		}
 	}
+
+	if failed > 0 {
+		return fmt.Errorf("%w: %d of %d referrals", ErrRewardsFailed, failed, len(referrals))
+	}
+
+	return nil
 }
 
-func (r *Rewarder) reward(ctx context.Context, ref *storage.ReferralTracking, confirmedReferralsCount int) {
+// reward sends rewards of the referral. The referral stays unconfirmed if it fails.
+func (r *Rewarder) reward(ctx context.Context, ref *storage.ReferralTracking, confirmedReferralsCount int,
+	campaigns []*storage.ReferralCampaign) error {
 	logger := r.getLogger(ref)
 
-	senderReward := r.rc.GetSenderReward(confirmedReferralsCount)
+	code, err := r.getRegistrationReferralCode(ctx, ref, campaigns)
+	if err != nil {
+		return fmt.Errorf("failed to get registration referral code: %w", err)
+	}
+
+	senderReward, campaign := ApplyCampaigns(campaigns, ref.Sender, code, ref.RegisteredAt,
+		r.rc.GetSenderReward(confirmedReferralsCount))
+
+	var campaignID sql.NullInt64
+	if campaign != nil {
+		campaignID = sql.NullInt64{Valid: true, Int64: int64(campaign.ID)}
+		logger = logger.WithField("campaign", campaign.ID)
+	}
+
 	senderBonus := r.rc.GetSenderBonus(confirmedReferralsCount)
 	totalSenderReward := senderReward.Add(senderBonus)
 
@@ -186,9 +380,16 @@ func (r *Rewarder) reward(ctx context.Context, ref *storage.ReferralTracking, co
 		memo = "Furya referral reward with bonus"
 	}
 
+	upper, err := r.getUpperLevelReferrers(ctx, ref)
+	if err != nil {
+		return fmt.Errorf("failed to get upper level referrers: %w", err)
+	}
+
+	var upperLevelRewards []sdk.Int
+
 	if err := r.storage.InTx(ctx, func(s storage.Storage) error {
-		if err := r.storage.TransitionReferralTrackingToConfirmed(
-			ctx, ref.Receiver, totalSenderReward, r.rc.ReceiverReward); err != nil {
+		if err := s.TransitionReferralTrackingToConfirmed(
+			ctx, ref.Receiver, totalSenderReward, r.rc.ReceiverReward, campaignID); err != nil {
 			return fmt.Errorf("failed to transition referral to confirmed: %w", err)
 		}
 
@@ -197,17 +398,150 @@ func (r *Rewarder) reward(ctx context.Context, ref *storage.ReferralTracking, co
 			{Address: ref.Receiver, Amount: r.rc.ReceiverReward},
 		}
 
-		if err := r.bmc.SendStakes(stakes, memo); err != nil {
+		for i, referrer := range upper {
+			level := i + 2
+
+			if referrer.ReferralBanned {
+				continue
+			}
+
+			reward := r.rc.GetUpperLevelReward(level, senderReward)
+			if !reward.IsPositive() {
+				continue
+			}
+
+			if err := s.CreateReferralUpperLevelReward(ctx, ref.Receiver, level, referrer.Address, reward); err != nil {
+				return fmt.Errorf("failed to create level %d reward: %w", level, err)
+			}
+
+			stakes = append(stakes, blockchain.Stake{Address: referrer.Address, Amount: reward})
+			upperLevelRewards = append(upperLevelRewards, reward)
+		}
+
+		if err := r.bmc.SendStakes(ctx, stakes, memo); err != nil {
 			return fmt.Errorf("failed to send stakes: %w", err)
 		}
 
 		return nil
 	}); err != nil {
-		logger.WithError(err).Error("failed to reward")
-		return
+		return err
+	}
+
+	metrics.AddPayout(metrics.PayoutReferralSender, totalSenderReward)
+	metrics.AddPayout(metrics.PayoutReferralReceiver, r.rc.ReceiverReward)
+	for _, v := range upperLevelRewards {
+		metrics.AddPayout(metrics.PayoutReferralUpperLevel, v)
 	}
 
 	logger.Infof("rewards sent")
+
+	if r.events != nil {
+		r.events.Publish(ctx, events.RewardPaidEvent{
+			Sender:         ref.Sender,
+			Receiver:       ref.Receiver,
+			SenderReward:   totalSenderReward,
+			ReceiverReward: r.rc.ReceiverReward,
+		})
+	}
+
+	return nil
+}
+
+// getCampaigns returns campaigns active at any registration of the referrals. The payout happens
+// ThresholdDays after the registration at least, so the campaign may be already finished by then.
+// ApplyCampaigns picks campaigns active at the registration of the exact referral.
+func (r *Rewarder) getCampaigns(ctx context.Context,
+	referrals []*storage.ReferralTracking) ([]*storage.ReferralCampaign, error) {
+	if len(referrals) == 0 {
+		return nil, nil
+	}
+
+	from, to := referrals[0].RegisteredAt, referrals[0].RegisteredAt
+	for _, v := range referrals[1:] {
+		if v.RegisteredAt.Before(from) {
+			from = v.RegisteredAt
+		}
+		if v.RegisteredAt.After(to) {
+			to = v.RegisteredAt
+		}
+	}
+
+	return r.storage.GetReferralCampaignsActiveBetween(ctx, from, to)
+}
+
+// getRegistrationReferralCode returns the code the receiver registered with.
+// The code is needed only when some campaign is limited by codes.
+func (r *Rewarder) getRegistrationReferralCode(ctx context.Context, ref *storage.ReferralTracking,
+	campaigns []*storage.ReferralCampaign) (string, error) {
+	needed := false
+	for _, c := range campaigns {
+		needed = needed || len(c.Codes) > 0
+	}
+
+	if !needed {
+		return "", nil
+	}
+
+	req, err := r.storage.GetRequestByAddress(ctx, ref.Receiver)
+	if err != nil {
+		return "", err
+	}
+
+	return req.RegistrationReferralCode.String, nil
+}
+
+// getUpperLevelReferrers walks the registration referral codes chain up from the sender.
+// The first item is the sender's referrer. The chain is limited by UpperLevelRewardPercents length and stops on cycles.
+func (r *Rewarder) getUpperLevelReferrers(ctx context.Context, ref *storage.ReferralTracking) ([]*storage.Request, error) {
+	depth := len(r.rc.UpperLevelRewardPercents)
+	if depth == 0 {
+		return nil, nil
+	}
+
+	visited := map[string]bool{ref.Receiver: true, ref.Sender: true}
+	referrers := make([]*storage.Request, 0, depth)
+
+	address := ref.Sender
+	for len(referrers) < depth {
+		req, err := r.storage.GetRequestByAddress(ctx, address)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get request of %s: %w", address, err)
+		}
+
+		if !req.RegistrationReferralCode.Valid {
+			break
+		}
+
+		referrer, err := r.storage.GetRequestByOwnReferralCode(ctx, req.RegistrationReferralCode.String)
+		if err != nil {
+			if errors.Is(err, storage.ErrReferralCodeNotFound) {
+				break
+			}
+			return nil, fmt.Errorf("failed to get referrer of %s: %w", address, err)
+		}
+
+		if visited[referrer.Address] {
+			r.getLogger(ref).Warnf("referral chain cycle on %s", referrer.Address)
+			break
+		}
+		visited[referrer.Address] = true
+
+		referrers = append(referrers, referrer)
+		address = referrer.Address
+	}
+
+	return referrers, nil
+}
+
+func (r *Rewarder) refreshLeaderboard(ctx context.Context) {
+	if ctx.Err() != nil {
+		// shutting down, the next run refreshes it
+		return
+	}
+
+	if err := r.storage.RefreshReferralLeaderboard(ctx); err != nil {
+		log.WithError(err).Error("failed to refresh referral leaderboard")
+	}
 }
 
 func (r *Rewarder) getLogger(ref *storage.ReferralTracking) *log.Entry {
@@ -1,13 +1,39 @@
 package referral
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	tokentypes "github.com/TessorNetwork/furya/x/token/types"
+
+	"github.com/TessorNetwork/vulcan/internal/blockchain"
+	blockchainmock "github.com/TessorNetwork/vulcan/internal/blockchain/mock"
+	"github.com/TessorNetwork/vulcan/internal/storage"
+	storagemock "github.com/TessorNetwork/vulcan/internal/storage/mock"
 )
 
+const (
+	testSender   = "furya1zyvp7f3dxsa5yj2s2a0x2mrn02qc3ruk39eenv"
+	testReceiver = "furya1yg5nqde7g4x9xknpdphhvlvy3wffng98sd0cc7"
+)
+
+// balanceClient returns PDV balances by address.
+type balanceClient map[string]sdk.Fur
+
+func (c balanceClient) Balance(_ context.Context, in *tokentypes.BalanceRequest,
+	_ ...grpc.CallOption) (*tokentypes.BalanceResponse, error) {
+	return &tokentypes.BalanceResponse{Balance: sdk.FurProto{Fur: c[in.Address]}}, nil
+}
+
 func TestConfig_GetSenderBonus(t *testing.T) {
 	tt := []struct {
 		count int
@@ -54,3 +80,241 @@ func TestConfig_GetSenderReward(t *testing.T) {
 		})
 	}
 }
+
+func TestConfig_GetUpperLevelReward(t *testing.T) {
+	c := NewConfig(sdk.NewFur(100), 30)
+	c.UpperLevelRewardPercents = []sdk.Fur{sdk.NewFur(10), sdk.MustNewFurFromStr("2.5")}
+
+	tt := []struct {
+		level int
+		want  sdk.Int
+	}{
+		{1, sdk.NewInt(0)},
+		{2, sdk.NewInt(1000000)},
+		{3, sdk.NewInt(250000)},
+		{4, sdk.NewInt(0)},
+	}
+
+	for i := range tt {
+		tc := tt[i]
+		t.Run(fmt.Sprintf("level=%d", tc.level), func(t *testing.T) {
+			reward := c.GetUpperLevelReward(tc.level, sdk.NewInt(10000000))
+			require.Truef(t, tc.want.Equal(reward), "%s != %s", tc.want, reward)
+		})
+	}
+}
+
+func TestParseUpperLevelRewardPercents(t *testing.T) {
+	percents, err := ParseUpperLevelRewardPercents([]string{"10", "2.5"})
+	require.NoError(t, err)
+	require.Equal(t, []sdk.Fur{sdk.NewFur(10), sdk.MustNewFurFromStr("2.5")}, percents)
+
+	for _, v := range []string{"0", "-1", "101", "abc"} {
+		_, err := ParseUpperLevelRewardPercents([]string{v})
+		require.Truef(t, errors.Is(err, ErrInvalidPercent), v)
+	}
+}
+
+func TestRewarder_getUpperLevelReferrers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	request := func(address, registrationCode string) *storage.Request {
+		return &storage.Request{
+			Address:                  address,
+			OwnReferralCode:          address,
+			RegistrationReferralCode: sql.NullString{String: registrationCode, Valid: registrationCode != ""},
+		}
+	}
+
+	// receiver <- sender <- a <- b <- sender (cycle)
+	st := storagemock.NewMockStorage(ctrl)
+	st.EXPECT().GetRequestByAddress(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, address string) (*storage.Request, error) {
+			return map[string]*storage.Request{
+				"sender": request("sender", "a"),
+				"a":      request("a", "b"),
+				"b":      request("b", "sender"),
+			}[address], nil
+		}).AnyTimes()
+	st.EXPECT().GetRequestByOwnReferralCode(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, code string) (*storage.Request, error) {
+			return map[string]*storage.Request{
+				"sender": request("sender", "a"),
+				"a":      request("a", "b"),
+				"b":      request("b", "sender"),
+			}[code], nil
+		}).AnyTimes()
+
+	ref := &storage.ReferralTracking{Sender: "sender", Receiver: "receiver"}
+
+	addresses := func(rr []*storage.Request) []string {
+		out := make([]string, len(rr))
+		for i, v := range rr {
+			out[i] = v.Address
+		}
+		return out
+	}
+
+	tt := []struct {
+		name  string
+		depth int
+		want  []string
+	}{
+		{name: "disabled", depth: 0, want: []string{}},
+		{name: "depth limit", depth: 1, want: []string{"a"}},
+		{name: "cycle", depth: 5, want: []string{"a", "b"}},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			rc := NewConfig(sdk.NewFur(100), 30)
+			rc.UpperLevelRewardPercents = make([]sdk.Fur, tc.depth)
+
+			r := NewRewarder(st, nil, nil, nil, rc)
+
+			referrers, err := r.getUpperLevelReferrers(context.Background(), ref)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, addresses(referrers))
+		})
+	}
+}
+
+func TestRewarder_Run(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	st := storagemock.NewMockStorage(ctrl)
+	r := NewRewarder(st, nil, nil, nil, NewConfig(sdk.NewFur(100), 30))
+
+	require.True(t, errors.Is(r.Ping(context.Background()), ErrRewarderNotStarted))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// the run is interrupted before the first referral
+	st.EXPECT().GetUnconfirmedReferralTracking(gomock.Any(), 30).Return([]*storage.ReferralTracking{
+		{Sender: "sender", Receiver: "receiver"},
+	}, nil)
+	st.EXPECT().GetReferralCampaignsActiveBetween(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil)
+
+	r.Run(ctx, time.Hour)
+
+	require.NoError(t, r.Ping(context.Background()))
+	details := r.Details()
+	require.Equal(t, false, details["running"])
+	require.Contains(t, details["lastError"], "interrupted")
+	require.NotContains(t, details, "lastSuccessAt")
+
+	// campaigns aren't needed without referrals
+	st.EXPECT().GetUnconfirmedReferralTracking(gomock.Any(), 30).Return(nil, nil)
+
+	r.Run(ctx, time.Hour)
+
+	details = r.Details()
+	require.NotContains(t, details, "lastError")
+	require.Contains(t, details, "lastSuccessAt")
+	require.EqualValues(t, 0, details["lagSeconds"])
+}
+
+func TestRewarder_do_FinishedCampaign(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	st := storagemock.NewMockStorage(ctrl)
+	bc := blockchainmock.NewMockBlockchain(ctrl)
+	rc := NewConfig(sdk.NewFur(100), 30)
+	r := NewRewarder(st, bc, balanceClient{
+		testReceiver: sdk.NewFur(101),
+		testSender:   sdk.NewFur(1),
+	}, nil, rc)
+
+	now := time.Now()
+	registeredAt := now.Add(-40 * 24 * time.Hour)
+	other := now.Add(-35 * 24 * time.Hour)
+
+	double := sdk.MustNewFurFromStr("2")
+	// the campaign was active at the registration and finished before the payout
+	campaign := &storage.ReferralCampaign{
+		ID:         1,
+		Multiplier: &double,
+		StartsAt:   registeredAt.Add(-24 * time.Hour),
+		EndsAt:     registeredAt.Add(24 * time.Hour),
+	}
+
+	st.EXPECT().GetUnconfirmedReferralTracking(gomock.Any(), 30).Return([]*storage.ReferralTracking{
+		{Sender: testSender, Receiver: testReceiver, RegisteredAt: registeredAt},
+		// the balance isn't enough
+		{Sender: testSender, Receiver: testSender, RegisteredAt: other},
+	}, nil)
+	st.EXPECT().GetReferralCampaignsActiveBetween(gomock.Any(), registeredAt, other).
+		Return([]*storage.ReferralCampaign{campaign}, nil)
+	st.EXPECT().GetConfirmedReferralTrackingCount(gomock.Any(), testSender).Return(0, nil)
+
+	senderReward := rc.GetSenderReward(1).MulRaw(2).Add(rc.GetSenderBonus(1))
+
+	st.EXPECT().InTx(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, f func(storage.Storage) error) error {
+			return f(st)
+		})
+	st.EXPECT().TransitionReferralTrackingToConfirmed(gomock.Any(), testReceiver, senderReward, rc.ReceiverReward,
+		sql.NullInt64{Valid: true, Int64: 1}).Return(nil)
+	bc.EXPECT().SendStakes(gomock.Any(), []blockchain.Stake{
+		{Address: testSender, Amount: senderReward},
+		{Address: testReceiver, Amount: rc.ReceiverReward},
+	}, gomock.Any()).Return(nil)
+	st.EXPECT().RefreshReferralLeaderboard(gomock.Any()).Return(nil)
+
+	require.NoError(t, r.do(context.Background()))
+}
+
+func TestRewarder_run_RewardsFailed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	st := storagemock.NewMockStorage(ctrl)
+	bc := blockchainmock.NewMockBlockchain(ctrl)
+	rc := NewConfig(sdk.NewFur(100), 30)
+	r := NewRewarder(st, bc, balanceClient{testReceiver: sdk.NewFur(101)}, nil, rc)
+	r.startedAt = time.Now()
+
+	st.EXPECT().GetUnconfirmedReferralTracking(gomock.Any(), 30).Return([]*storage.ReferralTracking{
+		{Sender: testSender, Receiver: testReceiver},
+	}, nil)
+	st.EXPECT().GetReferralCampaignsActiveBetween(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil)
+	st.EXPECT().GetConfirmedReferralTrackingCount(gomock.Any(), testSender).Return(0, nil)
+	st.EXPECT().InTx(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, f func(storage.Storage) error) error {
+			return f(st)
+		})
+	st.EXPECT().TransitionReferralTrackingToConfirmed(gomock.Any(), testReceiver, gomock.Any(), gomock.Any(),
+		gomock.Any()).Return(nil)
+	bc.EXPECT().SendStakes(gomock.Any(), gomock.Any(), gomock.Any()).Return(fmt.Errorf("insufficient funds"))
+	st.EXPECT().RefreshReferralLeaderboard(gomock.Any()).Return(nil)
+
+	r.run(context.Background())
+
+	details := r.Details()
+	require.Contains(t, details["lastError"], ErrRewardsFailed.Error())
+	require.Contains(t, details["lastError"], "1 of 1")
+	require.NotContains(t, details, "lastSuccessAt")
+}
+
+func TestRewarder_Ping(t *testing.T) {
+	r := NewRewarder(nil, nil, nil, nil, NewConfig(sdk.NewFur(100), 30))
+	r.interval = time.Minute
+
+	r.startedAt = time.Now().Add(-time.Minute)
+	require.NoError(t, r.Ping(context.Background()))
+
+	// no successful runs since the start
+	r.startedAt = time.Now().Add(-3 * time.Minute)
+	require.True(t, errors.Is(r.Ping(context.Background()), ErrRewarderLag))
+
+	r.lastSuccessAt = time.Now().Add(-90 * time.Second)
+	require.NoError(t, r.Ping(context.Background()))
+
+	r.lastSuccessAt = time.Now().Add(-time.Hour)
+	require.True(t, errors.Is(r.Ping(context.Background()), ErrRewarderLag))
+	require.EqualValues(t, 3600, r.Details()["lagSeconds"])
+}
@@ -3,12 +3,54 @@ package server
 import (
 	"errors"
 	"fmt"
+	"net/url"
 	"regexp"
+	"strings"
+	"time"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/go-openapi/strfmt"
+
+	"github.com/TessorNetwork/vulcan/internal/events"
+	"github.com/TessorNetwork/vulcan/internal/storage"
+	"github.com/TessorNetwork/vulcan/internal/webhooks"
+)
+
+const (
+	defaultSupplyHistoryPeriod = 30 * 24 * time.Hour
+	maxSupplyHistoryPoints     = 1000
+
+	defaultRegistrationFunnelDays = 30
+	maxRegistrationFunnelDays     = 2 * 366
+
+	minWebhookSecretLength = 16
 )
 
+// nolint:gochecknoglobals
+var supplyHistoryIntervals = map[string]time.Duration{
+	"":   24 * time.Hour,
+	"1h": time.Hour,
+	"6h": 6 * time.Hour,
+	"1d": 24 * time.Hour,
+	"1w": 7 * 24 * time.Hour,
+}
+
+// nolint:gochecknoglobals
+var webhookDeliveryStatuses = map[string]storage.WebhookDeliveryStatus{
+	"":          storage.DeadWebhookDeliveryStatus,
+	"pending":   storage.PendingWebhookDeliveryStatus,
+	"delivered": storage.DeliveredWebhookDeliveryStatus,
+	"dead":      storage.DeadWebhookDeliveryStatus,
+}
+
+// nolint:gochecknoglobals
+var registrationFunnelGranularities = map[string]storage.Granularity{
+	"":      storage.GranularityDay,
+	"day":   storage.GranularityDay,
+	"week":  storage.GranularityWeek,
+	"month": storage.GranularityMonth,
+}
+
 var (
 	emailRegExp       = regexp.MustCompile("(?:[a-z0-9!#$%&'*+\\/=?^_`{|}~-]+(?:\\.[a-z0-9!#$%&'*+\\/=?^_`{|}~-]+)*|\"(?:[\\x01-\\x08\\x0b\\x0c\\x0e-\\x1f\\x21\\x23-\\x5b\\x5d-\\x7f]|\\\\[\\x01-\\x09\\x0b\\x0c\\x0e-\\x7f])*\")@(?:(?:[a-z0-9](?:[a-z0-9-]*[a-z0-9])?\\.)+[a-z0-9](?:[a-z0-9-]*[a-z0-9])?|\\[(?:(?:25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?)\\.){3}(?:25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?|[a-z0-9-]*[a-z0-9]:(?:[\\x01-\\x08\\x0b\\x0c\\x0e-\\x1f\\x21-\\x5a\\x53-\\x7f]|\\\\[\\x01-\\x09\\x0b\\x0c\\x0e-\\x7f])+)\\])") // nolint
 	errInvalidRequest = errors.New("invalid request")
@@ -55,6 +97,77 @@ type DLoan struct {
 	CreatedAt string  `json:"createdAt"`
 }
 
+// RegistrationFunnelItem is the number of registration events within the period. Every event is counted at its own time.
+// swagger:model
+type RegistrationFunnelItem struct {
+	// period start date
+	Date string `json:"date"`
+	// registration requests, a repeated request is counted once at its last time
+	Started int `json:"started"`
+	// verification emails sent, testnet accounts are registered without email
+	EmailsSent int `json:"emailsSent"`
+	// confirmed registrations
+	Confirmed int `json:"confirmed"`
+	// registrations with a referral code
+	ReferralRegistered int `json:"referralRegistered"`
+	// browser installations of the referred accounts
+	Installed int `json:"installed"`
+	// rewarded referrals
+	Rewarded int `json:"rewarded"`
+}
+
+// SupplyDetails ...
+// swagger:model
+type SupplyDetails struct {
+	// amounts denom
+	Denom string `json:"denom"`
+	// number of decimals of the amounts
+	Decimals int `json:"decimals"`
+	// native and erc20 supply
+	Total sdk.Int `json:"total"`
+	// total supply without excluded balances
+	Circulating sdk.Int `json:"circulating"`
+	Native      sdk.Int `json:"native"`
+	// sum of erc20 supply of all chains
+	ERC20 sdk.Int `json:"erc20"`
+	// erc20 supply of every EVM chain
+	Chains []SupplyChain `json:"chains"`
+	// balances excluded from the circulating supply
+	Excluded []ExcludedBalance `json:"excluded"`
+	// RFC3339 time the oldest chain was updated at
+	UpdatedAt string `json:"updatedAt"`
+}
+
+// SupplyChain ...
+// swagger:model
+type SupplyChain struct {
+	Name  string  `json:"name"`
+	Total sdk.Int `json:"total"`
+	// RFC3339 time
+	UpdatedAt string `json:"updatedAt"`
+	// the chain failed to update for longer than the staleness limit, total is its last fetched value
+	Stale bool `json:"stale"`
+}
+
+// ExcludedBalance ...
+// swagger:model
+type ExcludedBalance struct {
+	Name string `json:"name"`
+	// native or EVM chain name
+	Chain   string  `json:"chain"`
+	Address string  `json:"address"`
+	Amount  sdk.Int `json:"amount"`
+}
+
+// SupplyHistoryItem ...
+// swagger:model
+type SupplyHistoryItem struct {
+	// RFC3339 interval start
+	Time        string  `json:"time"`
+	Total       sdk.Int `json:"total"`
+	Circulating sdk.Int `json:"circulating"`
+}
+
 // ReferralCodeResponse ...
 // swagger:model
 type ReferralCodeResponse struct {
@@ -77,6 +190,201 @@ type ReferralTrackingStatsResponse struct {
 	Last30Days ReferralTrackingStatsItem `json:"last30Days"`
 }
 
+// NonceResponse ...
+// swagger:model
+type NonceResponse struct {
+	Nonce string `json:"nonce"`
+	// RFC3339 time
+	ExpiresAt string `json:"expiresAt"`
+}
+
+// LoginRequest ...
+// swagger:model
+type LoginRequest struct {
+	// required: true
+	Address string `json:"address"`
+	// base64 encoded compressed secp256k1 public key
+	// required: true
+	PublicKey []byte `json:"publicKey"`
+	// base64 encoded ADR-036 signature of the nonce
+	// required: true
+	Signature []byte `json:"signature"`
+	// required: true
+	Nonce string `json:"nonce"`
+}
+
+// LoginResponse ...
+// swagger:model
+type LoginResponse struct {
+	// session token to pass in the Authorization header as "Bearer <token>"
+	Token string `json:"token"`
+	// RFC3339 time
+	ExpiresAt string `json:"expiresAt"`
+}
+
+// TrackInstallationRequest ...
+// swagger:model
+type TrackInstallationRequest struct {
+	// required: true
+	Nonce string `json:"nonce"`
+}
+
+// ClaimReferralCodeRequest ...
+// swagger:model
+type ClaimReferralCodeRequest struct {
+	// required: true
+	Code string `json:"code"`
+}
+
+// ReferralTrackingItem ...
+// swagger:model
+type ReferralTrackingItem struct {
+	Receiver       string    `json:"receiver"`
+	Status         string    `json:"status"`
+	RegisteredAt   string    `json:"registeredAt"`
+	InstalledAt    *string   `json:"installedAt"`
+	ConfirmedAt    *string   `json:"confirmedAt"`
+	CancelledAt    *string   `json:"cancelledAt"`
+	SenderReward   *sdk.Coin `json:"senderReward"`
+	ReceiverReward *sdk.Coin `json:"receiverReward"`
+	// days left until the referral is eligible for rewards, set for installed referrals only
+	DaysUntilEligible *int `json:"daysUntilEligible"`
+}
+
+// ReferralLeaderboardItem ...
+// swagger:model
+type ReferralLeaderboardItem struct {
+	Rank      int      `json:"rank"`
+	Address   string   `json:"address"`
+	Confirmed int      `json:"confirmed"`
+	Reward    sdk.Coin `json:"reward"`
+}
+
+// ReferralCampaign ...
+// swagger:model
+type ReferralCampaign struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	// RFC3339 time
+	StartsAt string `json:"startsAt"`
+	// RFC3339 time
+	EndsAt string `json:"endsAt"`
+	// sender reward multiplier, mutually exclusive with fixedReward
+	Multiplier *sdk.Fur `json:"multiplier"`
+	// sender reward override in ufury, mutually exclusive with multiplier
+	FixedReward *sdk.Int `json:"fixedReward"`
+	// referral codes the campaign is limited to
+	Codes []string `json:"codes"`
+	// senders the campaign is limited to
+	Senders []string `json:"senders"`
+}
+
+// CreateReferralCampaignRequest ...
+// swagger:model
+type CreateReferralCampaignRequest struct {
+	// required: true
+	Name string `json:"name"`
+	// required: true
+	StartsAt time.Time `json:"startsAt"`
+	// required: true
+	EndsAt time.Time `json:"endsAt"`
+	// sender reward multiplier, mutually exclusive with fixedReward
+	Multiplier *sdk.Fur `json:"multiplier"`
+	// sender reward override in ufury, mutually exclusive with multiplier
+	FixedReward *sdk.Int `json:"fixedReward"`
+	// referral codes the campaign is limited to
+	Codes []string `json:"codes"`
+	// senders the campaign is limited to
+	Senders []string `json:"senders"`
+}
+
+// CreateReferralCampaignResponse ...
+// swagger:model
+type CreateReferralCampaignResponse struct {
+	ID int `json:"id"`
+}
+
+// ReferralBanRequest ...
+// swagger:model
+type ReferralBanRequest struct {
+	// required: true
+	Reason               string `json:"reason"`
+	CancelPendingRewards bool   `json:"cancelPendingRewards"`
+}
+
+// ReferralBanResponse ...
+// swagger:model
+type ReferralBanResponse struct {
+	CancelledReferrals int `json:"cancelledReferrals"`
+}
+
+// ReferralUnbanRequest ...
+// swagger:model
+type ReferralUnbanRequest struct {
+	// required: true
+	Reason string `json:"reason"`
+}
+
+// ReferralBanEvent ...
+// swagger:model
+type ReferralBanEvent struct {
+	Banned    bool   `json:"banned"`
+	Reason    string `json:"reason"`
+	CreatedAt string `json:"createdAt"`
+}
+
+// CreateWebhookSubscriptionRequest ...
+// swagger:model
+type CreateWebhookSubscriptionRequest struct {
+	// http(s) url events are posted to
+	// required: true
+	URL string `json:"url"`
+	// secret to sign requests with HMAC-SHA256, at least 16 characters
+	// required: true
+	Secret string `json:"secret"`
+	// event types: referral_installed, reward_paid
+	// required: true
+	Events []string `json:"events"`
+	// referral sender the subscription is limited to, events of all senders are sent if empty
+	Sender *string `json:"sender"`
+}
+
+// CreateWebhookSubscriptionResponse ...
+// swagger:model
+type CreateWebhookSubscriptionResponse struct {
+	ID int `json:"id"`
+}
+
+// WebhookSubscription ...
+// swagger:model
+type WebhookSubscription struct {
+	ID     int      `json:"id"`
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Sender *string  `json:"sender"`
+	// RFC3339 time
+	CreatedAt string `json:"createdAt"`
+}
+
+// WebhookDelivery ...
+// swagger:model
+type WebhookDelivery struct {
+	ID             int    `json:"id"`
+	SubscriptionID int    `json:"subscriptionId"`
+	EventID        string `json:"eventId"`
+	EventType      string `json:"eventType"`
+	// pending, delivered or dead
+	Status   string `json:"status"`
+	Attempts int    `json:"attempts"`
+	// RFC3339 time
+	NextAttemptAt string  `json:"nextAttemptAt"`
+	LastError     *string `json:"lastError"`
+	// RFC3339 time
+	CreatedAt string `json:"createdAt"`
+	// RFC3339 time
+	DeliveredAt *string `json:"deliveredAt"`
+}
+
 // RegisterStats ...
 // swagger:model
 type RegisterStats struct {
@@ -106,6 +414,117 @@ func (r RegisterRequest) validate() error {
 	return nil
 }
 
+func (r LoginRequest) validate() error {
+	if !isAddressValid(r.Address) {
+		return fmt.Errorf("%w: invalid address", errInvalidRequest)
+	}
+
+	if r.Nonce == "" {
+		return fmt.Errorf("%w: empty nonce", errInvalidRequest)
+	}
+
+	if len(r.PublicKey) == 0 || len(r.Signature) == 0 {
+		return fmt.Errorf("%w: empty public key or signature", errInvalidRequest)
+	}
+
+	return nil
+}
+
+func (r CreateReferralCampaignRequest) validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return fmt.Errorf("%w: empty name", errInvalidRequest)
+	}
+
+	if !r.EndsAt.After(r.StartsAt) {
+		return fmt.Errorf("%w: endsAt should be after startsAt", errInvalidRequest)
+	}
+
+	if (r.Multiplier == nil) == (r.FixedReward == nil) {
+		return fmt.Errorf("%w: exactly one of multiplier and fixedReward should be set", errInvalidRequest)
+	}
+
+	if r.Multiplier != nil && !r.Multiplier.IsPositive() {
+		return fmt.Errorf("%w: multiplier should be positive", errInvalidRequest)
+	}
+
+	if r.FixedReward != nil && (r.FixedReward.IsNegative() || !r.FixedReward.IsInt64()) {
+		return fmt.Errorf("%w: invalid fixedReward", errInvalidRequest)
+	}
+
+	for _, v := range r.Senders {
+		if !isAddressValid(v) {
+			return fmt.Errorf("%w: invalid sender %s", errInvalidRequest, v)
+		}
+	}
+
+	return nil
+}
+
+func (r CreateWebhookSubscriptionRequest) validate() error {
+	u, err := url.Parse(r.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: invalid url", errInvalidRequest)
+	}
+
+	if len(r.Secret) < minWebhookSecretLength {
+		return fmt.Errorf("%w: secret should be at least %d characters", errInvalidRequest, minWebhookSecretLength)
+	}
+
+	if len(r.Events) == 0 {
+		return fmt.Errorf("%w: empty events", errInvalidRequest)
+	}
+
+	for _, v := range r.Events {
+		if !isWebhookEvent(v) {
+			return fmt.Errorf("%w: invalid event %s", errInvalidRequest, v)
+		}
+	}
+
+	if r.Sender != nil && !isAddressValid(*r.Sender) {
+		return fmt.Errorf("%w: invalid sender", errInvalidRequest)
+	}
+
+	return nil
+}
+
+func isWebhookEvent(s string) bool {
+	for _, v := range webhooks.Events {
+		if events.Type(s) == v {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r ReferralBanRequest) validate() error {
+	if strings.TrimSpace(r.Reason) == "" {
+		return fmt.Errorf("%w: empty reason", errInvalidRequest)
+	}
+
+	return nil
+}
+
+func (r ReferralUnbanRequest) validate() error {
+	if strings.TrimSpace(r.Reason) == "" {
+		return fmt.Errorf("%w: empty reason", errInvalidRequest)
+	}
+
+	return nil
+}
+
+// maskAddress hides the middle part of the address, e.g. furya1qu2z...mk7w.
+func maskAddress(s string) string {
+	const visible = 4
+
+	idx := strings.LastIndex(s, "1")
+	if idx < 0 || len(s)-idx-1 <= 2*visible {
+		return s
+	}
+
+	return s[:idx+1+visible] + "..." + s[len(s)-visible:]
+}
+
 func isAddressValid(s string) bool {
 	_, err := sdk.AccAddressFromBech32(s)
 
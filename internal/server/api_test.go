@@ -72,3 +72,8 @@ func TestRegisterRequest_Validate(t *testing.T) {
 		})
 	}
 }
+
+func Test_maskAddress(t *testing.T) {
+	require.Equal(t, "furya18c2p...7t4m", maskAddress(testAddress))
+	require.Equal(t, "short", maskAddress("short"))
+}
@@ -1,9 +1,11 @@
 package server
 
 import (
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
 	"strconv"
 	"time"
@@ -16,9 +18,12 @@ import (
 	"github.com/TessorNetwork/furya/config"
 	"github.com/TessorNetwork/go-api"
 
+	"github.com/TessorNetwork/vulcan/internal/auth"
 	"github.com/TessorNetwork/vulcan/internal/mail"
+	"github.com/TessorNetwork/vulcan/internal/referral"
 	"github.com/TessorNetwork/vulcan/internal/service"
 	"github.com/TessorNetwork/vulcan/internal/storage"
+	"github.com/TessorNetwork/vulcan/internal/supply"
 )
 
 // register sends email with link to create new wallet.
@@ -48,7 +53,7 @@ func (s *server) register(w http.ResponseWriter, r *http.Request) {
 	//      schema:
 	//        "$ref": "#/definitions/Error"
 	//   '422':
-	//      description: referral code not found.
+	//      description: referral code not found or banned.
 	//      schema:
 	//        "$ref": "#/definitions/Error"
 	//   '429':
@@ -98,6 +103,9 @@ func (s *server) register(w http.ResponseWriter, r *http.Request) {
 		case errors.Is(err, service.ErrReferralCodeNotFound):
 			api.WriteError(w, http.StatusUnprocessableEntity, "referral code not found")
 			logrus.WithField("request", req).Warn("referral code not found")
+		case errors.Is(err, service.ErrReferralCodeBanned):
+			api.WriteError(w, http.StatusUnprocessableEntity, "referral code is banned")
+			logrus.WithField("request", req).Warn("referral code is banned")
 		case errors.Is(err, mail.ErrMailRejected):
 			logrus.WithField("request", req).WithError(err).Error("failed to send email with rejected status")
 			api.WriteError(w, http.StatusBadRequest, err.Error())
@@ -151,6 +159,106 @@ func (s *server) getRegisterStats(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// getRegistrationFunnel returns registration funnel.
+func (s *server) getRegistrationFunnel(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation GET /v1/register/funnel Vulcan GetRegistrationFunnel
+	//
+	// Returns number of registration events per period, oldest first. Periods without events have zero counts.
+	//
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: from
+	//   description: first date (YYYY-MM-DD), 30 days before to by default. It is truncated to the granularity.
+	//   in: query
+	//   required: false
+	//   type: string
+	// - name: to
+	//   description: last date (YYYY-MM-DD), today by default
+	//   in: query
+	//   required: false
+	//   type: string
+	// - name: granularity
+	//   in: query
+	//   required: false
+	//   type: string
+	//   enum: [day, week, month]
+	//   default: day
+	// responses:
+	//   '200':
+	//     schema:
+	//       type: array
+	//       items:
+	//         "$ref": "#/definitions/RegistrationFunnelItem"
+	//   '400':
+	//      description: bad request.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '500':
+	//      description: internal server error.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+
+	granularity, ok := registrationFunnelGranularities[r.FormValue("granularity")]
+	if !ok {
+		api.WriteError(w, http.StatusBadRequest, "invalid granularity")
+		return
+	}
+
+	to := time.Now().UTC().Truncate(24 * time.Hour)
+	if v := r.FormValue("to"); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			api.WriteError(w, http.StatusBadRequest, "invalid to")
+			return
+		}
+		to = t
+	}
+
+	from := to.AddDate(0, 0, -defaultRegistrationFunnelDays+1)
+	if v := r.FormValue("from"); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			api.WriteError(w, http.StatusBadRequest, "invalid from")
+			return
+		}
+		from = t
+	}
+
+	if from.After(to) {
+		api.WriteError(w, http.StatusBadRequest, "from should not be after to")
+		return
+	}
+
+	if to.Sub(from) >= maxRegistrationFunnelDays*24*time.Hour {
+		api.WriteError(w, http.StatusBadRequest, fmt.Sprintf("too long period, max is %d days", maxRegistrationFunnelDays))
+		return
+	}
+
+	// to is inclusive
+	items, err := s.s.GetRegistrationFunnel(r.Context(), from, to.AddDate(0, 0, 1), granularity)
+	if err != nil {
+		api.WriteInternalErrorf(r.Context(), w, err, "failed to get registration funnel")
+		return
+	}
+
+	resp := make([]RegistrationFunnelItem, len(items))
+	for i, v := range items {
+		resp[i] = RegistrationFunnelItem{
+			Date:               v.Date.Format("2006-01-02"),
+			Started:            v.Started,
+			EmailsSent:         v.EmailsSent,
+			Confirmed:          v.Confirmed,
+			ReferralRegistered: v.ReferralRegistered,
+			Installed:          v.Installed,
+			Rewarded:           v.Rewarded,
+		}
+	}
+
+	api.WriteOK(w, http.StatusOK, resp)
+}
+
 // confirm confirms registration and creates wallet.
 func (s *server) confirm(w http.ResponseWriter, r *http.Request) {
 	// swagger:operation POST /v1/confirm Vulcan Confirm
@@ -217,27 +325,32 @@ func (s *server) supply(w http.ResponseWriter, r *http.Request) {
 	// - application/json
 	// responses:
 	//   '200':
+	//     description: circulating supply as a decimal string in tokens, e.g. "1000.5".
 	//     schema:
-	//       type: number
+	//       type: string
 	//   '500':
 	//      description: internal server error.
 	//      schema:
 	//        "$ref": "#/definitions/Error"
+	//   '503':
+	//      description: supply is unavailable or stale.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
 
 	amount, err := s.sup.GetCirculatingSupply()
 	if err != nil {
-		api.WriteInternalErrorf(r.Context(), w, err, "failed to get supply")
+		writeSupplyError(w, r, err)
 		return
 	}
 
 	api.WriteOK(w, http.StatusOK, amount)
 }
 
-// getReferralConfig returns referral config.
-func (s *server) getReferralConfig(w http.ResponseWriter, _ *http.Request) {
-	// swagger:operation GET /v1/referral/config Vulcan RetReferralParams
+// supplyDetails returns supply breakdown.
+func (s *server) supplyDetails(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation GET /v1/supply/details Vulcan SupplyDetails
 	//
-	// Returns referral params
+	// Returns total and circulating supply with their components.
 	//
 	// ---
 	// produces:
@@ -245,220 +358,239 @@ func (s *server) getReferralConfig(w http.ResponseWriter, _ *http.Request) {
 	// responses:
 	//   '200':
 	//     schema:
-	//       "$ref": "#/definitions/Config"
+	//       "$ref": "#/definitions/SupplyDetails"
 	//   '500':
 	//      description: internal server error.
 	//      schema:
 	//        "$ref": "#/definitions/Error"
+	//   '503':
+	//      description: supply is unavailable or stale.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
 
-	api.WriteOK(w, http.StatusOK, s.s.GetReferralConfig())
+	d, err := s.sup.GetDetails()
+	if err != nil {
+		writeSupplyError(w, r, err)
+		return
+	}
+
+	excluded := make([]ExcludedBalance, len(d.Excluded))
+	for i, v := range d.Excluded {
+		excluded[i] = ExcludedBalance{
+			Name:    v.Name,
+			Chain:   v.Chain,
+			Address: v.Address,
+			Amount:  v.Amount,
+		}
+	}
+
+	chains := make([]SupplyChain, len(d.EVM))
+	for i, v := range d.EVM {
+		chains[i] = SupplyChain{
+			Name:      v.Name,
+			Total:     v.Total,
+			UpdatedAt: v.UpdatedAt.Format(time.RFC3339),
+			Stale:     v.Stale,
+		}
+	}
+
+	api.WriteOK(w, http.StatusOK, SupplyDetails{
+		Denom:       d.Denom,
+		Decimals:    d.Decimals,
+		Total:       d.Total(),
+		Circulating: d.Circulating(),
+		Native:      d.Native,
+		ERC20:       d.ERC20(),
+		Chains:      chains,
+		Excluded:    excluded,
+		UpdatedAt:   d.UpdatedAt.Format(time.RFC3339),
+	})
 }
 
-// trackReferralBrowserInstallation tracks the Furya browser installation.
-func (s *server) trackReferralBrowserInstallation(w http.ResponseWriter, r *http.Request) {
-	// swagger:operation POST /v1/referral/track/install/{address} Vulcan TrackBrowserInstallation
+// totalSupply returns total supply as a plain text.
+func (s *server) totalSupply(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation GET /v1/supply/total Vulcan TotalSupply
 	//
-	// Tracks the Furya browser installation.
+	// Returns total supply in whole tokens as a plain text number. The format is compatible with CoinGecko and CoinMarketCap.
 	//
 	// ---
 	// produces:
-	// - application/json
-	// consumes:
-	// - application/json
-	// parameters:
-	// - name: address
-	//   in: path
-	//   required: true
-	//   type: string
+	// - text/plain
 	// responses:
 	//   '200':
-	//     description: referral marked with installed status
-	//   '404':
-	//      description: referral tracking not found
-	//      schema:
-	//        "$ref": "#/definitions/Error"
-	//   '409':
-	//      description: referral is already marked as installed
-	//      schema:
-	//        "$ref": "#/definitions/Error"
+	//     schema:
+	//       type: string
 	//   '500':
 	//      description: internal server error.
 	//      schema:
 	//        "$ref": "#/definitions/Error"
+	//   '503':
+	//      description: supply is unavailable or stale.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
 
-	address := chi.URLParam(r, "address")
-
-	if err := s.s.TrackReferralBrowserInstallation(r.Context(), address); err != nil {
-		switch {
-		case errors.Is(err, service.ErrReferralTrackingNotFound):
-			api.WriteError(w, http.StatusNotFound, "not found")
-		case errors.Is(err, service.ErrReferralTrackingInvalidStatus):
-			api.WriteError(w, http.StatusConflict, "status is installed or confirmed")
-		default:
-			api.WriteInternalErrorf(r.Context(), w, err, "failed to track browser installation")
-		}
+	d, err := s.sup.GetDetails()
+	if err != nil {
+		writeSupplyError(w, r, err)
 		return
 	}
 
-	api.WriteOK(w, http.StatusOK, EmptyResponse{})
+	writeText(w, supply.FormatAmount(d.Total(), d.Decimals))
 }
 
-func (s *server) getReferralTrackingStats(w http.ResponseWriter, r *http.Request) {
-	// swagger:operation GET /v1/referral/track/stats/{address} Vulcan GetReferralTrackingStats
+// circulatingSupply returns circulating supply as a plain text.
+func (s *server) circulatingSupply(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation GET /v1/supply/circulating Vulcan CirculatingSupply
 	//
-	// Returns a referral tracking stats of the given account
+	// Returns circulating supply in whole tokens as a plain text number. The format is compatible with CoinGecko and CoinMarketCap.
 	//
 	// ---
 	// produces:
-	// - application/json
-	// parameters:
-	// - name: address
-	//   in: path
-	//   required: true
-	//   type: string
+	// - text/plain
 	// responses:
 	//   '200':
 	//     schema:
-	//       "$ref": "#/definitions/ReferralTrackingStatsResponse"
-	//   '404':
-	//      description: address not found
-	//      schema:
-	//        "$ref": "#/definitions/Error"
+	//       type: string
 	//   '500':
 	//      description: internal server error.
 	//      schema:
 	//        "$ref": "#/definitions/Error"
+	//   '503':
+	//      description: supply is unavailable or stale.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
 
-	address := chi.URLParam(r, "address")
-
-	stats, err := s.s.GetReferralTrackingStats(r.Context(), address)
+	d, err := s.sup.GetDetails()
 	if err != nil {
-		switch {
-		case errors.Is(err, service.ErrRequestNotFound):
-			api.WriteError(w, http.StatusNotFound, "not found")
-		default:
-			api.WriteInternalErrorf(r.Context(), w,
-				fmt.Errorf("stats %s failed:%w", address, err), "failed to get referral tracking stats")
-		}
+		writeSupplyError(w, r, err)
 		return
 	}
 
-	api.WriteOK(w, http.StatusOK, ReferralTrackingStatsResponse{
-		Total:      toReferralTrackingStatsItem(*stats[0]),
-		Last30Days: toReferralTrackingStatsItem(*stats[1]),
-	})
+	writeText(w, supply.FormatAmount(d.Circulating(), d.Decimals))
 }
 
-// listDLoans returns a list of dloans.
-func (s *server) listDLoans(w http.ResponseWriter, r *http.Request) {
-	// swagger:operation GET /v1/dloan Vulcan ListDLoans
+// getSupplyHistory returns supply time series.
+func (s *server) getSupplyHistory(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation GET /v1/supply/history Vulcan GetSupplyHistory
 	//
-	// List dLoan requests
+	// Returns the last supply snapshot of each interval, oldest first. Intervals without snapshots are skipped.
 	//
 	// ---
 	// produces:
 	// - application/json
 	// parameters:
-	// - name: take
-	//   description: number of loans to take
+	// - name: from
+	//   description: RFC3339 time, 30 days before to by default
 	//   in: query
 	//   required: false
-	//   default: 50
-	//   minimum: 1
-	//   maximum: 50
-	// - name: skip
-	//   description: number of loans to skip
+	//   type: string
+	// - name: to
+	//   description: RFC3339 time, now by default
 	//   in: query
 	//   required: false
-	//   default: 0
+	//   type: string
+	// - name: interval
+	//   in: query
+	//   required: false
+	//   type: string
+	//   enum: [1h, 6h, 1d, 1w]
+	//   default: 1d
 	// responses:
 	//   '200':
 	//     schema:
-	//       "$ref": "#/definitions/DLoan"
+	//       type: array
+	//       items:
+	//         "$ref": "#/definitions/SupplyHistoryItem"
+	//   '400':
+	//      description: bad request.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
 	//   '500':
 	//      description: internal server error.
 	//      schema:
 	//        "$ref": "#/definitions/Error"
 
-	take, _ := strconv.Atoi(r.FormValue("take"))
-	skip, _ := strconv.Atoi(r.FormValue("skip"))
+	interval, ok := supplyHistoryIntervals[r.FormValue("interval")]
+	if !ok {
+		api.WriteError(w, http.StatusBadRequest, "invalid interval")
+		return
+	}
 
-	if take <= 0 || take > 50 {
-		take = 50
+	to := time.Now()
+	if v := r.FormValue("to"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			api.WriteError(w, http.StatusBadRequest, "invalid to")
+			return
+		}
+		to = t
 	}
 
-	if skip < 0 {
-		skip = 0
+	from := to.Add(-defaultSupplyHistoryPeriod)
+	if v := r.FormValue("from"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			api.WriteError(w, http.StatusBadRequest, "invalid from")
+			return
+		}
+		from = t
 	}
 
-	loans, err := s.s.ListDloanRequests(r.Context(), take, skip)
+	if !to.After(from) {
+		api.WriteError(w, http.StatusBadRequest, "to should be after from")
+		return
+	}
+
+	if to.Sub(from)/interval > maxSupplyHistoryPoints {
+		api.WriteError(w, http.StatusBadRequest, fmt.Sprintf("too many points, max is %d", maxSupplyHistoryPoints))
+		return
+	}
+
+	history, err := s.sup.GetHistory(r.Context(), from, to, interval)
 	if err != nil {
-		api.WriteInternalErrorf(r.Context(), w, err, "failed to list dLoans")
+		api.WriteInternalErrorf(r.Context(), w, err, "failed to get supply history")
 		return
 	}
 
-	apiLoans := make([]*DLoan, len(loans))
-	for idx, loan := range loans {
-		apiLoans[idx] = &DLoan{
-			ID:        loan.ID,
-			FirstName: loan.FirstName,
-			LastName:  loan.LastName,
-			Address:   loan.Address,
-			PDV:       loan.PDV,
-			CreatedAt: loan.CreatedAt.Format(time.RFC3339),
+	resp := make([]SupplyHistoryItem, len(history))
+	for i, v := range history {
+		resp[i] = SupplyHistoryItem{
+			Time:        v.CreatedAt.UTC().Format(time.RFC3339),
+			Total:       v.Total,
+			Circulating: v.Circulating,
 		}
 	}
 
-	api.WriteOK(w, http.StatusOK, apiLoans)
+	api.WriteOK(w, http.StatusOK, resp)
 }
 
-// createDLoan creates a new dloan request.
-func (s *server) createDLoan(w http.ResponseWriter, r *http.Request) {
-	// swagger:operation POST /v1/dloan Vulcan CreateDLoan
+// getReferralConfig returns referral config.
+func (s *server) getReferralConfig(w http.ResponseWriter, _ *http.Request) {
+	// swagger:operation GET /v1/referral/config Vulcan RetReferralParams
 	//
-	// Creates dLoan request
+	// Returns referral params
 	//
 	// ---
 	// produces:
 	// - application/json
-	// parameters:
-	// - name: request
-	//   in: body
-	//   required: true
-	//   schema:
-	//     '$ref': '#/definitions/DLoanRequest'
 	// responses:
 	//   '200':
-	//     description: dloan created.
 	//     schema:
-	//       "$ref": "#/definitions/EmptyResponse"
-	//   '400':
-	//      description: bad request.
-	//      schema:
-	//        "$ref": "#/definitions/Error"
+	//       "$ref": "#/definitions/Config"
 	//   '500':
 	//      description: internal server error.
 	//      schema:
 	//        "$ref": "#/definitions/Error"
-	var req DLoanRequest
-	if err := json.NewFuroder(r.Body).Decode(&req); err != nil {
-		api.WriteError(w, http.StatusBadRequest, err.Error())
-		return
-	}
-
-	if err := s.s.CreateDLoanRequest(r.Context(), req.Address, req.FirstName, req.LastName, req.PDV); err != nil {
-		api.WriteInternalErrorf(r.Context(), w, err, "failed to create dLoan request")
-		return
-	}
 
-	api.WriteOK(w, http.StatusOK, EmptyResponse{})
+	api.WriteOK(w, http.StatusOK, s.s.GetReferralConfig())
 }
 
-// getOwnReferralCode return a referral code of the given account.
-func (s *server) getOwnReferralCode(w http.ResponseWriter, r *http.Request) {
-	// swagger:operation GET /v1/referral/code/{address} Vulcan GetOwnReferralCode
+// getInstallationNonce issues a nonce to sign for the Furya browser installation tracking.
+func (s *server) getInstallationNonce(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation GET /v1/referral/track/install/{address}/nonce Vulcan GetInstallationNonce
 	//
-	// Returns a referral code of the given account
+	// Issues a nonce the browser should sign to track the installation. Every nonce is valid once until it expires,
+	// issuing a new nonce doesn't invalidate previous ones.
 	//
 	// ---
 	// produces:
@@ -471,9 +603,13 @@ func (s *server) getOwnReferralCode(w http.ResponseWriter, r *http.Request) {
 	// responses:
 	//   '200':
 	//     schema:
-	//       "$ref": "#/definitions/ReferralCodeResponse"
+	//       "$ref": "#/definitions/NonceResponse"
 	//   '404':
-	//      description: referral code not found
+	//      description: referral tracking not found
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '409':
+	//      description: referral is already marked as installed
 	//      schema:
 	//        "$ref": "#/definitions/Error"
 	//   '500':
@@ -483,38 +619,1143 @@ func (s *server) getOwnReferralCode(w http.ResponseWriter, r *http.Request) {
 
 	address := chi.URLParam(r, "address")
 
-	code, err := s.s.GetOwnReferralCode(r.Context(), address)
+	nonce, expiresAt, err := s.s.GetInstallationNonce(r.Context(), address)
 	if err != nil {
 		switch {
-		case errors.Is(err, service.ErrRequestNotFound):
+		case errors.Is(err, service.ErrReferralTrackingNotFound):
 			api.WriteError(w, http.StatusNotFound, "not found")
+		case errors.Is(err, service.ErrReferralTrackingInvalidStatus):
+			api.WriteError(w, http.StatusConflict, "status is installed or confirmed")
 		default:
-			api.WriteInternalErrorf(r.Context(), w, err, "failed to get own referral code")
+			api.WriteInternalErrorf(r.Context(), w, err, "failed to issue installation nonce")
 		}
 		return
 	}
-	api.WriteOK(w, http.StatusOK, ReferralCodeResponse{Code: code})
+
+	api.WriteOK(w, http.StatusOK, NonceResponse{
+		Nonce:     nonce,
+		ExpiresAt: expiresAt.Format(time.RFC3339),
+	})
 }
 
-func (s *server) getRegistrationReferralCode(w http.ResponseWriter, r *http.Request) {
-	// swagger:operation GET /v1/referral/code/{address}/registration Vulcan GetRegistrationReferralCode
+// trackReferralBrowserInstallation tracks the Furya browser installation.
+func (s *server) trackReferralBrowserInstallation(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation POST /v1/referral/track/install/{address} Vulcan TrackBrowserInstallation
 	//
-	// Returns a referral code the account was registered with
+	// Tracks the Furya browser installation.
+	// The request should contain a nonce issued by GetInstallationNonce and be signed by the account's key.
 	//
 	// ---
 	// produces:
 	// - application/json
+	// consumes:
+	// - application/json
 	// parameters:
-	// - name: address
-	//   in: path
+	// - name: Public-Key
+	//   in: header
+	//   required: true
+	//   type: string
+	// - name: Signature
+	//   in: header
+	//   required: true
+	//   type: string
+	// - name: address
+	//   in: path
+	//   required: true
+	//   type: string
+	// - name: request
+	//   in: body
+	//   required: true
+	//   schema:
+	//     '$ref': '#/definitions/TrackInstallationRequest'
+	// responses:
+	//   '200':
+	//     description: referral marked with installed status
+	//   '400':
+	//      description: bad request.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '401':
+	//      description: signature is not verified or nonce is invalid.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '403':
+	//      description: public key doesn't belong to the address.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '404':
+	//      description: referral tracking not found
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '409':
+	//      description: referral is already marked as installed
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '500':
+	//      description: internal server error.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+
+	address := chi.URLParam(r, "address")
+
+	if !verifyOwner(w, r, address) {
+		return
+	}
+
+	var req TrackInstallationRequest
+	if err := json.NewFuroder(r.Body).Decode(&req); err != nil {
+		api.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := s.s.TrackReferralBrowserInstallation(r.Context(), address, req.Nonce); err != nil {
+		switch {
+		case errors.Is(err, service.ErrReferralTrackingNotFound):
+			api.WriteError(w, http.StatusNotFound, "not found")
+		case errors.Is(err, service.ErrReferralTrackingInvalidStatus):
+			api.WriteError(w, http.StatusConflict, "status is installed or confirmed")
+		case errors.Is(err, service.ErrInvalidNonce):
+			api.WriteError(w, http.StatusUnauthorized, "invalid nonce")
+		default:
+			api.WriteInternalErrorf(r.Context(), w, err, "failed to track browser installation")
+		}
+		return
+	}
+
+	api.WriteOK(w, http.StatusOK, EmptyResponse{})
+}
+
+func (s *server) getReferralTrackingStats(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation GET /v1/referral/track/stats/{address} Vulcan GetReferralTrackingStats
+	//
+	// Returns a referral tracking stats of the given account
+	//
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: address
+	//   in: path
+	//   required: true
+	//   type: string
+	// responses:
+	//   '200':
+	//     schema:
+	//       "$ref": "#/definitions/ReferralTrackingStatsResponse"
+	//   '404':
+	//      description: address not found
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '500':
+	//      description: internal server error.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+
+	address := chi.URLParam(r, "address")
+
+	stats, err := s.s.GetReferralTrackingStats(r.Context(), address)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrRequestNotFound):
+			api.WriteError(w, http.StatusNotFound, "not found")
+		default:
+			api.WriteInternalErrorf(r.Context(), w,
+				fmt.Errorf("stats %s failed:%w", address, err), "failed to get referral tracking stats")
+		}
+		return
+	}
+
+	api.WriteOK(w, http.StatusOK, ReferralTrackingStatsResponse{
+		Total:      toReferralTrackingStatsItem(*stats[0]),
+		Last30Days: toReferralTrackingStatsItem(*stats[1]),
+	})
+}
+
+// listReferralTracking returns referrals of the given sender.
+func (s *server) listReferralTracking(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation GET /v1/referral/track/{address}/referrals Vulcan ListReferralTracking
+	//
+	// Returns referrals of the given sender, newest first
+	//
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: address
+	//   in: path
+	//   required: true
+	//   type: string
+	// - name: take
+	//   description: number of referrals to take
+	//   in: query
+	//   required: false
+	//   default: 50
+	//   minimum: 1
+	//   maximum: 50
+	// - name: skip
+	//   description: number of referrals to skip
+	//   in: query
+	//   required: false
+	//   default: 0
+	// responses:
+	//   '200':
+	//     schema:
+	//       type: array
+	//       items:
+	//         "$ref": "#/definitions/ReferralTrackingItem"
+	//   '404':
+	//      description: address not found
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '500':
+	//      description: internal server error.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+
+	address := chi.URLParam(r, "address")
+
+	take, _ := strconv.Atoi(r.FormValue("take"))
+	skip, _ := strconv.Atoi(r.FormValue("skip"))
+
+	if take <= 0 || take > 50 {
+		take = 50
+	}
+
+	if skip < 0 {
+		skip = 0
+	}
+
+	referrals, err := s.s.ListReferralTracking(r.Context(), address, take, skip)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrRequestNotFound):
+			api.WriteError(w, http.StatusNotFound, "not found")
+		default:
+			api.WriteInternalErrorf(r.Context(), w, err, "failed to list referral tracking")
+		}
+		return
+	}
+
+	thresholdDays := s.s.GetReferralConfig().ThresholdDays
+	now := time.Now()
+
+	resp := make([]ReferralTrackingItem, len(referrals))
+	for i, item := range referrals {
+		resp[i] = toReferralTrackingItem(*item, thresholdDays, now)
+	}
+
+	api.WriteOK(w, http.StatusOK, resp)
+}
+
+// getReferralLeaderboard returns top referral senders.
+func (s *server) getReferralLeaderboard(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation GET /v1/referral/leaderboard Vulcan GetReferralLeaderboard
+	//
+	// Returns top senders by confirmed referrals and total reward. The leaderboard is refreshed periodically.
+	//
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: window
+	//   description: period to calculate the leaderboard for
+	//   in: query
+	//   required: false
+	//   type: string
+	//   enum: [7d, 30d, all]
+	//   default: all
+	// - name: limit
+	//   description: number of senders to return
+	//   in: query
+	//   required: false
+	//   type: integer
+	//   default: 10
+	//   minimum: 1
+	//   maximum: 100
+	// - name: mask
+	//   description: mask senders' addresses
+	//   in: query
+	//   required: false
+	//   type: boolean
+	//   default: true
+	// responses:
+	//   '200':
+	//     schema:
+	//       type: array
+	//       items:
+	//         "$ref": "#/definitions/ReferralLeaderboardItem"
+	//   '400':
+	//      description: bad request.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '500':
+	//      description: internal server error.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+
+	window := storage.LeaderboardWindow(r.FormValue("window"))
+	switch window {
+	case "":
+		window = storage.LeaderboardWindowAll
+	case storage.LeaderboardWindow7Days, storage.LeaderboardWindow30Days, storage.LeaderboardWindowAll:
+	default:
+		api.WriteError(w, http.StatusBadRequest, "invalid window")
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.FormValue("limit"))
+	if limit <= 0 || limit > 100 {
+		limit = 10
+	}
+
+	mask := true
+	if v := r.FormValue("mask"); v != "" {
+		var err error
+		if mask, err = strconv.ParseBool(v); err != nil {
+			api.WriteError(w, http.StatusBadRequest, "invalid mask")
+			return
+		}
+	}
+
+	items, err := s.s.GetReferralLeaderboard(r.Context(), window, limit)
+	if err != nil {
+		api.WriteInternalErrorf(r.Context(), w, err, "failed to get referral leaderboard")
+		return
+	}
+
+	resp := make([]ReferralLeaderboardItem, len(items))
+	for i, item := range items {
+		address := item.Sender
+		if mask {
+			address = maskAddress(address)
+		}
+
+		resp[i] = ReferralLeaderboardItem{
+			Rank:      i + 1,
+			Address:   address,
+			Confirmed: item.Confirmed,
+			Reward:    sdk.NewCoin(config.DefaultBondDenom, item.Reward),
+		}
+	}
+
+	api.WriteOK(w, http.StatusOK, resp)
+}
+
+// listDLoans returns a list of dloans.
+func (s *server) listDLoans(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation GET /v1/dloan Vulcan ListDLoans
+	//
+	// List dLoan requests
+	//
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: take
+	//   description: number of loans to take
+	//   in: query
+	//   required: false
+	//   default: 50
+	//   minimum: 1
+	//   maximum: 50
+	// - name: skip
+	//   description: number of loans to skip
+	//   in: query
+	//   required: false
+	//   default: 0
+	// responses:
+	//   '200':
+	//     schema:
+	//       "$ref": "#/definitions/DLoan"
+	//   '500':
+	//      description: internal server error.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+
+	take, _ := strconv.Atoi(r.FormValue("take"))
+	skip, _ := strconv.Atoi(r.FormValue("skip"))
+
+	if take <= 0 || take > 50 {
+		take = 50
+	}
+
+	if skip < 0 {
+		skip = 0
+	}
+
+	loans, err := s.s.ListDloanRequests(r.Context(), take, skip)
+	if err != nil {
+		api.WriteInternalErrorf(r.Context(), w, err, "failed to list dLoans")
+		return
+	}
+
+	apiLoans := make([]*DLoan, len(loans))
+	for idx, loan := range loans {
+		apiLoans[idx] = &DLoan{
+			ID:        loan.ID,
+			FirstName: loan.FirstName,
+			LastName:  loan.LastName,
+			Address:   loan.Address,
+			PDV:       loan.PDV,
+			CreatedAt: loan.CreatedAt.Format(time.RFC3339),
+		}
+	}
+
+	api.WriteOK(w, http.StatusOK, apiLoans)
+}
+
+// createDLoan creates a new dloan request.
+func (s *server) createDLoan(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation POST /v1/dloan Vulcan CreateDLoan
+	//
+	// Creates dLoan request
+	//
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: request
+	//   in: body
+	//   required: true
+	//   schema:
+	//     '$ref': '#/definitions/DLoanRequest'
+	// responses:
+	//   '200':
+	//     description: dloan created.
+	//     schema:
+	//       "$ref": "#/definitions/EmptyResponse"
+	//   '400':
+	//      description: bad request.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '500':
+	//      description: internal server error.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	var req DLoanRequest
+	if err := json.NewFuroder(r.Body).Decode(&req); err != nil {
+		api.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := s.s.CreateDLoanRequest(r.Context(), req.Address, req.FirstName, req.LastName, req.PDV); err != nil {
+		api.WriteInternalErrorf(r.Context(), w, err, "failed to create dLoan request")
+		return
+	}
+
+	api.WriteOK(w, http.StatusOK, EmptyResponse{})
+}
+
+// getOwnReferralCode return a referral code of the given account.
+func (s *server) getOwnReferralCode(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation GET /v1/referral/code/{address} Vulcan GetOwnReferralCode
+	//
+	// Returns a referral code of the given account
+	//
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: address
+	//   in: path
+	//   required: true
+	//   type: string
+	// responses:
+	//   '200':
+	//     schema:
+	//       "$ref": "#/definitions/ReferralCodeResponse"
+	//   '404':
+	//      description: referral code not found
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '500':
+	//      description: internal server error.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+
+	address := chi.URLParam(r, "address")
+
+	code, err := s.s.GetOwnReferralCode(r.Context(), address)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrRequestNotFound):
+			api.WriteError(w, http.StatusNotFound, "not found")
+		default:
+			api.WriteInternalErrorf(r.Context(), w, err, "failed to get own referral code")
+		}
+		return
+	}
+	api.WriteOK(w, http.StatusOK, ReferralCodeResponse{Code: code})
+}
+
+// claimReferralCode replaces a referral code of the given account with a vanity one.
+func (s *server) claimReferralCode(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation POST /v1/referral/code/{address} Vulcan ClaimReferralCode
+	//
+	// Replaces a referral code of the given confirmed account with a vanity one. Old codes keep working.
+	// The request should be signed by the account's key.
+	//
+	// ---
+	// produces:
+	// - application/json
+	// consumes:
+	// - application/json
+	// parameters:
+	// - name: Public-Key
+	//   in: header
+	//   required: true
+	//   type: string
+	// - name: Signature
+	//   in: header
+	//   required: true
+	//   type: string
+	// - name: address
+	//   in: path
+	//   required: true
+	//   type: string
+	// - name: request
+	//   in: body
+	//   required: true
+	//   schema:
+	//     '$ref': '#/definitions/ClaimReferralCodeRequest'
+	// responses:
+	//   '200':
+	//     schema:
+	//       "$ref": "#/definitions/ReferralCodeResponse"
+	//   '400':
+	//      description: bad request.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '401':
+	//      description: signature is not verified.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '403':
+	//      description: public key doesn't belong to the address or account is not confirmed.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '404':
+	//      description: address not found
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '409':
+	//      description: referral code is taken
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '500':
+	//      description: internal server error.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+
+	address := chi.URLParam(r, "address")
+
+	if !verifyOwner(w, r, address) {
+		return
+	}
+
+	var req ClaimReferralCodeRequest
+	if err := json.NewFuroder(r.Body).Decode(&req); err != nil {
+		api.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	code, err := s.s.ClaimReferralCode(r.Context(), address, req.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, referral.ErrInvalidCode):
+			api.WriteError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, service.ErrRequestNotFound):
+			api.WriteError(w, http.StatusNotFound, "not found")
+		case errors.Is(err, service.ErrNotConfirmed):
+			api.WriteError(w, http.StatusForbidden, "not confirmed")
+		case errors.Is(err, service.ErrReferralCodeIsTaken):
+			api.WriteError(w, http.StatusConflict, "referral code is taken")
+		default:
+			api.WriteInternalErrorf(r.Context(), w, err, "failed to claim referral code")
+		}
+		return
+	}
+
+	api.WriteOK(w, http.StatusOK, ReferralCodeResponse{Code: code})
+}
+
+func (s *server) getRegistrationReferralCode(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation GET /v1/referral/code/{address}/registration Vulcan GetRegistrationReferralCode
+	//
+	// Returns a referral code the account was registered with
+	//
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: address
+	//   in: path
+	//   required: true
+	//   type: string
+	// responses:
+	//   '200':
+	//     schema:
+	//       "$ref": "#/definitions/ReferralCodeResponse"
+	//   '404':
+	//      description: referral code not found
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '500':
+	//      description: internal server error.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+
+	address := chi.URLParam(r, "address")
+
+	code, err := s.s.GetRegistrationReferralCode(r.Context(), address)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrRequestNotFound):
+			api.WriteError(w, http.StatusNotFound, "not found")
+		default:
+			api.WriteInternalErrorf(r.Context(), w, err, "failed to get registered referral code")
+		}
+		return
+	}
+	api.WriteOK(w, http.StatusOK, ReferralCodeResponse{Code: code})
+}
+
+func (s *server) registerTestnetAccount(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation GET /v1/hesoyam/{address} Vulcan GiveStakes
+	//
+	// Like a game cheat gives you test stakes. Works only for testnet.
+	//
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: address
+	//   in: path
+	//   required: true
+	//   type: string
+	// responses:
+	//   '200':
+	//     description: stakes were sent
+	//   '500':
+	//      description: internal server error.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+
+	address := chi.URLParam(r, "address")
+	if !isAddressValid(address) {
+		api.WriteError(w, http.StatusBadRequest, "invalid address")
+		return
+	}
+
+	if err := s.s.RegisterTestnetAccount(r.Context(), address); err != nil {
+		api.WriteInternalErrorf(r.Context(), w, err, "failed to give stakes")
+		return
+	}
+
+	api.WriteOK(w, http.StatusOK, EmptyResponse{})
+}
+
+// banReferrer bans the referrer.
+func (s *server) banReferrer(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation POST /v1/admin/referral/ban/{address} Vulcan BanReferrer
+	//
+	// Bans the referrer. Optionally cancels the referrer's pending rewards.
+	//
+	// ---
+	// produces:
+	// - application/json
+	// consumes:
+	// - application/json
+	// parameters:
+	// - name: Authorization
+	//   in: header
+	//   required: true
+	//   type: string
+	// - name: address
+	//   in: path
+	//   required: true
+	//   type: string
+	// - name: request
+	//   in: body
+	//   required: true
+	//   schema:
+	//     '$ref': '#/definitions/ReferralBanRequest'
+	// responses:
+	//   '200':
+	//     description: referrer was banned
+	//     schema:
+	//       "$ref": "#/definitions/ReferralBanResponse"
+	//   '400':
+	//      description: bad request.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '401':
+	//      description: unauthorized.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '404':
+	//      description: address not found
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '409':
+	//      description: referrer is already banned
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '500':
+	//      description: internal server error.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+
+	address := chi.URLParam(r, "address")
+	if !isAddressValid(address) {
+		api.WriteError(w, http.StatusBadRequest, "invalid address")
+		return
+	}
+
+	var req ReferralBanRequest
+	if err := json.NewFuroder(r.Body).Decode(&req); err != nil {
+		api.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := req.validate(); err != nil {
+		api.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	cancelled, err := s.s.BanReferrer(r.Context(), address, req.Reason, req.CancelPendingRewards)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrRequestNotFound):
+			api.WriteError(w, http.StatusNotFound, "not found")
+		case errors.Is(err, service.ErrReferrerAlreadyBanned):
+			api.WriteError(w, http.StatusConflict, "already banned")
+		default:
+			api.WriteInternalErrorf(r.Context(), w, err, "failed to ban referrer")
+		}
+		return
+	}
+
+	api.WriteOK(w, http.StatusOK, ReferralBanResponse{CancelledReferrals: cancelled})
+}
+
+// unbanReferrer unbans the referrer.
+func (s *server) unbanReferrer(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation POST /v1/admin/referral/unban/{address} Vulcan UnbanReferrer
+	//
+	// Unbans the referrer.
+	//
+	// ---
+	// produces:
+	// - application/json
+	// consumes:
+	// - application/json
+	// parameters:
+	// - name: Authorization
+	//   in: header
+	//   required: true
+	//   type: string
+	// - name: address
+	//   in: path
+	//   required: true
+	//   type: string
+	// - name: request
+	//   in: body
+	//   required: true
+	//   schema:
+	//     '$ref': '#/definitions/ReferralUnbanRequest'
+	// responses:
+	//   '200':
+	//     description: referrer was unbanned
+	//     schema:
+	//       "$ref": "#/definitions/EmptyResponse"
+	//   '400':
+	//      description: bad request.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '401':
+	//      description: unauthorized.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '404':
+	//      description: address not found
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '409':
+	//      description: referrer is not banned
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '500':
+	//      description: internal server error.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+
+	address := chi.URLParam(r, "address")
+	if !isAddressValid(address) {
+		api.WriteError(w, http.StatusBadRequest, "invalid address")
+		return
+	}
+
+	var req ReferralUnbanRequest
+	if err := json.NewFuroder(r.Body).Decode(&req); err != nil {
+		api.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := req.validate(); err != nil {
+		api.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := s.s.UnbanReferrer(r.Context(), address, req.Reason); err != nil {
+		switch {
+		case errors.Is(err, service.ErrRequestNotFound):
+			api.WriteError(w, http.StatusNotFound, "not found")
+		case errors.Is(err, service.ErrReferrerNotBanned):
+			api.WriteError(w, http.StatusConflict, "not banned")
+		default:
+			api.WriteInternalErrorf(r.Context(), w, err, "failed to unban referrer")
+		}
+		return
+	}
+
+	api.WriteOK(w, http.StatusOK, EmptyResponse{})
+}
+
+// getReferralBanEvents returns the ban history of the referrer.
+func (s *server) getReferralBanEvents(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation GET /v1/admin/referral/ban/{address} Vulcan GetReferralBanEvents
+	//
+	// Returns the ban history of the referrer, newest first.
+	//
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: Authorization
+	//   in: header
+	//   required: true
+	//   type: string
+	// - name: address
+	//   in: path
+	//   required: true
+	//   type: string
+	// responses:
+	//   '200':
+	//     schema:
+	//       type: array
+	//       items:
+	//         "$ref": "#/definitions/ReferralBanEvent"
+	//   '400':
+	//      description: bad request.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '401':
+	//      description: unauthorized.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '500':
+	//      description: internal server error.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+
+	address := chi.URLParam(r, "address")
+	if !isAddressValid(address) {
+		api.WriteError(w, http.StatusBadRequest, "invalid address")
+		return
+	}
+
+	events, err := s.s.GetReferralBanEvents(r.Context(), address)
+	if err != nil {
+		api.WriteInternalErrorf(r.Context(), w, err, "failed to get referral ban events")
+		return
+	}
+
+	resp := make([]ReferralBanEvent, 0, len(events))
+	for _, item := range events {
+		resp = append(resp, ReferralBanEvent{
+			Banned:    item.Banned,
+			Reason:    item.Reason,
+			CreatedAt: item.CreatedAt.Format(time.RFC3339),
+		})
+	}
+
+	api.WriteOK(w, http.StatusOK, resp)
+}
+
+// createReferralCampaign creates a referral campaign.
+func (s *server) createReferralCampaign(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation POST /v1/admin/referral/campaign Vulcan CreateReferralCampaign
+	//
+	// Creates a referral campaign changing the sender reward within the period.
+	// When several campaigns are applicable the most profitable one is applied.
+	//
+	// ---
+	// produces:
+	// - application/json
+	// consumes:
+	// - application/json
+	// parameters:
+	// - name: Authorization
+	//   in: header
+	//   required: true
+	//   type: string
+	// - name: request
+	//   in: body
+	//   required: true
+	//   schema:
+	//     '$ref': '#/definitions/CreateReferralCampaignRequest'
+	// responses:
+	//   '200':
+	//     schema:
+	//       "$ref": "#/definitions/CreateReferralCampaignResponse"
+	//   '400':
+	//      description: bad request.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '401':
+	//      description: unauthorized.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '500':
+	//      description: internal server error.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+
+	var req CreateReferralCampaignRequest
+	if err := json.NewFuroder(r.Body).Decode(&req); err != nil {
+		api.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := req.validate(); err != nil {
+		api.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	id, err := s.s.CreateReferralCampaign(r.Context(), storage.ReferralCampaign{
+		Name:        req.Name,
+		StartsAt:    req.StartsAt.UTC(),
+		EndsAt:      req.EndsAt.UTC(),
+		Multiplier:  req.Multiplier,
+		FixedReward: req.FixedReward,
+		Codes:       req.Codes,
+		Senders:     req.Senders,
+	})
+	if err != nil {
+		api.WriteInternalErrorf(r.Context(), w, err, "failed to create referral campaign")
+		return
+	}
+
+	api.WriteOK(w, http.StatusOK, CreateReferralCampaignResponse{ID: id})
+}
+
+// listReferralCampaigns returns all referral campaigns.
+func (s *server) listReferralCampaigns(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation GET /v1/admin/referral/campaign Vulcan ListReferralCampaigns
+	//
+	// Returns all referral campaigns, newest first.
+	//
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: Authorization
+	//   in: header
+	//   required: true
+	//   type: string
+	// responses:
+	//   '200':
+	//     schema:
+	//       type: array
+	//       items:
+	//         "$ref": "#/definitions/ReferralCampaign"
+	//   '401':
+	//      description: unauthorized.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '500':
+	//      description: internal server error.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+
+	campaigns, err := s.s.ListReferralCampaigns(r.Context())
+	if err != nil {
+		api.WriteInternalErrorf(r.Context(), w, err, "failed to list referral campaigns")
+		return
+	}
+
+	resp := make([]ReferralCampaign, len(campaigns))
+	for i, c := range campaigns {
+		resp[i] = ReferralCampaign{
+			ID:          c.ID,
+			Name:        c.Name,
+			StartsAt:    c.StartsAt.Format(time.RFC3339),
+			EndsAt:      c.EndsAt.Format(time.RFC3339),
+			Multiplier:  c.Multiplier,
+			FixedReward: c.FixedReward,
+			Codes:       c.Codes,
+			Senders:     c.Senders,
+		}
+	}
+
+	api.WriteOK(w, http.StatusOK, resp)
+}
+
+// createWebhookSubscription creates a partner's webhook subscription.
+func (s *server) createWebhookSubscription(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation POST /v1/admin/webhook/subscription Vulcan CreateWebhookSubscription
+	//
+	// Creates a webhook subscription to referral events.
+	// Requests are signed with HMAC-SHA256 of the secret, the signature is sent as "X-Vulcan-Signature: sha256=<hex>".
+	// Failed deliveries are retried with exponential backoff and become dead after the last attempt.
+	//
+	// ---
+	// produces:
+	// - application/json
+	// consumes:
+	// - application/json
+	// parameters:
+	// - name: Authorization
+	//   in: header
+	//   required: true
+	//   type: string
+	// - name: request
+	//   in: body
+	//   required: true
+	//   schema:
+	//     '$ref': '#/definitions/CreateWebhookSubscriptionRequest'
+	// responses:
+	//   '200':
+	//     schema:
+	//       "$ref": "#/definitions/CreateWebhookSubscriptionResponse"
+	//   '400':
+	//      description: bad request.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '401':
+	//      description: unauthorized.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '500':
+	//      description: internal server error.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+
+	var req CreateWebhookSubscriptionRequest
+	if err := json.NewFuroder(r.Body).Decode(&req); err != nil {
+		api.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := req.validate(); err != nil {
+		api.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var sender sql.NullString
+	if req.Sender != nil {
+		sender = sql.NullString{Valid: true, String: *req.Sender}
+	}
+
+	id, err := s.s.CreateWebhookSubscription(r.Context(), storage.WebhookSubscription{
+		URL:    req.URL,
+		Secret: req.Secret,
+		Events: req.Events,
+		Sender: sender,
+	})
+	if err != nil {
+		api.WriteInternalErrorf(r.Context(), w, err, "failed to create webhook subscription")
+		return
+	}
+
+	api.WriteOK(w, http.StatusOK, CreateWebhookSubscriptionResponse{ID: id})
+}
+
+// listWebhookSubscriptions returns all webhook subscriptions.
+func (s *server) listWebhookSubscriptions(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation GET /v1/admin/webhook/subscription Vulcan ListWebhookSubscriptions
+	//
+	// Returns all webhook subscriptions without secrets, newest first.
+	//
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: Authorization
+	//   in: header
 	//   required: true
 	//   type: string
 	// responses:
 	//   '200':
 	//     schema:
-	//       "$ref": "#/definitions/ReferralCodeResponse"
+	//       type: array
+	//       items:
+	//         "$ref": "#/definitions/WebhookSubscription"
+	//   '401':
+	//      description: unauthorized.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '500':
+	//      description: internal server error.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+
+	subscriptions, err := s.s.ListWebhookSubscriptions(r.Context())
+	if err != nil {
+		api.WriteInternalErrorf(r.Context(), w, err, "failed to list webhook subscriptions")
+		return
+	}
+
+	resp := make([]WebhookSubscription, len(subscriptions))
+	for i, v := range subscriptions {
+		resp[i] = WebhookSubscription{
+			ID:        v.ID,
+			URL:       v.URL,
+			Events:    v.Events,
+			CreatedAt: v.CreatedAt.Format(time.RFC3339),
+		}
+		if v.Sender.Valid {
+			resp[i].Sender = &v.Sender.String
+		}
+	}
+
+	api.WriteOK(w, http.StatusOK, resp)
+}
+
+// deleteWebhookSubscription deletes the webhook subscription.
+func (s *server) deleteWebhookSubscription(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation DELETE /v1/admin/webhook/subscription/{id} Vulcan DeleteWebhookSubscription
+	//
+	// Deletes the webhook subscription with its deliveries.
+	//
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: Authorization
+	//   in: header
+	//   required: true
+	//   type: string
+	// - name: id
+	//   in: path
+	//   required: true
+	//   type: integer
+	// responses:
+	//   '200':
+	//     description: subscription was deleted
+	//     schema:
+	//       "$ref": "#/definitions/EmptyResponse"
+	//   '400':
+	//      description: bad request.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '401':
+	//      description: unauthorized.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
 	//   '404':
-	//      description: referral code not found
+	//      description: subscription not found
 	//      schema:
 	//        "$ref": "#/definitions/Error"
 	//   '500':
@@ -522,25 +1763,186 @@ func (s *server) getRegistrationReferralCode(w http.ResponseWriter, r *http.Requ
 	//      schema:
 	//        "$ref": "#/definitions/Error"
 
-	address := chi.URLParam(r, "address")
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		api.WriteError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
 
-	code, err := s.s.GetRegistrationReferralCode(r.Context(), address)
+	if err := s.s.DeleteWebhookSubscription(r.Context(), id); err != nil {
+		if errors.Is(err, service.ErrWebhookSubscriptionNotFound) {
+			api.WriteError(w, http.StatusNotFound, "not found")
+			return
+		}
+		api.WriteInternalErrorf(r.Context(), w, err, "failed to delete webhook subscription")
+		return
+	}
+
+	api.WriteOK(w, http.StatusOK, EmptyResponse{})
+}
+
+// listWebhookDeliveries returns webhook deliveries with the given status.
+func (s *server) listWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation GET /v1/admin/webhook/delivery Vulcan ListWebhookDeliveries
+	//
+	// Returns webhook deliveries with the given status, newest first.
+	//
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: Authorization
+	//   in: header
+	//   required: true
+	//   type: string
+	// - name: status
+	//   in: query
+	//   required: false
+	//   type: string
+	//   enum: [pending, delivered, dead]
+	//   default: dead
+	// - name: take
+	//   description: number of deliveries to take
+	//   in: query
+	//   required: false
+	//   default: 50
+	//   minimum: 1
+	//   maximum: 50
+	// - name: skip
+	//   description: number of deliveries to skip
+	//   in: query
+	//   required: false
+	//   default: 0
+	// responses:
+	//   '200':
+	//     schema:
+	//       type: array
+	//       items:
+	//         "$ref": "#/definitions/WebhookDelivery"
+	//   '400':
+	//      description: bad request.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '401':
+	//      description: unauthorized.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '500':
+	//      description: internal server error.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+
+	status, ok := webhookDeliveryStatuses[r.FormValue("status")]
+	if !ok {
+		api.WriteError(w, http.StatusBadRequest, "invalid status")
+		return
+	}
+
+	take, _ := strconv.Atoi(r.FormValue("take"))
+	skip, _ := strconv.Atoi(r.FormValue("skip"))
+
+	if take <= 0 || take > 50 {
+		take = 50
+	}
+
+	if skip < 0 {
+		skip = 0
+	}
+
+	deliveries, err := s.s.ListWebhookDeliveries(r.Context(), status, take, skip)
 	if err != nil {
-		switch {
-		case errors.Is(err, service.ErrRequestNotFound):
+		api.WriteInternalErrorf(r.Context(), w, err, "failed to list webhook deliveries")
+		return
+	}
+
+	resp := make([]WebhookDelivery, len(deliveries))
+	for i, v := range deliveries {
+		resp[i] = WebhookDelivery{
+			ID:             v.ID,
+			SubscriptionID: v.SubscriptionID,
+			EventID:        v.EventID,
+			EventType:      v.EventType,
+			Status:         string(v.Status),
+			Attempts:       v.Attempts,
+			NextAttemptAt:  v.NextAttemptAt.Format(time.RFC3339),
+			CreatedAt:      v.CreatedAt.Format(time.RFC3339),
+		}
+		if v.LastError.Valid {
+			resp[i].LastError = &v.LastError.String
+		}
+		if v.DeliveredAt.Valid {
+			deliveredAt := v.DeliveredAt.Time.Format(time.RFC3339)
+			resp[i].DeliveredAt = &deliveredAt
+		}
+	}
+
+	api.WriteOK(w, http.StatusOK, resp)
+}
+
+// replayWebhookDelivery schedules the dead delivery for redelivery.
+func (s *server) replayWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation POST /v1/admin/webhook/delivery/{id}/replay Vulcan ReplayWebhookDelivery
+	//
+	// Makes the dead delivery pending again, it is sent with the next dispatch.
+	//
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: Authorization
+	//   in: header
+	//   required: true
+	//   type: string
+	// - name: id
+	//   in: path
+	//   required: true
+	//   type: integer
+	// responses:
+	//   '200':
+	//     description: delivery was scheduled
+	//     schema:
+	//       "$ref": "#/definitions/EmptyResponse"
+	//   '400':
+	//      description: bad request.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '401':
+	//      description: unauthorized.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '404':
+	//      description: dead delivery not found
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '500':
+	//      description: internal server error.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		api.WriteError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+
+	if err := s.s.ReplayWebhookDelivery(r.Context(), id); err != nil {
+		if errors.Is(err, service.ErrWebhookDeliveryNotFound) {
 			api.WriteError(w, http.StatusNotFound, "not found")
-		default:
-			api.WriteInternalErrorf(r.Context(), w, err, "failed to get registered referral code")
+			return
 		}
+		api.WriteInternalErrorf(r.Context(), w, err, "failed to replay webhook delivery")
 		return
 	}
-	api.WriteOK(w, http.StatusOK, ReferralCodeResponse{Code: code})
+
+	api.WriteOK(w, http.StatusOK, EmptyResponse{})
 }
 
-func (s *server) registerTestnetAccount(w http.ResponseWriter, r *http.Request) {
-	// swagger:operation GET /v1/hesoyam/{address} Vulcan GiveStakes
+// getLoginNonce issues a nonce to sign for the session.
+func (s *server) getLoginNonce(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation GET /v1/auth/nonce/{address} Vulcan GetLoginNonce
 	//
-	// Like a game cheat gives you test stakes. Works only for testnet.
+	// Issues a nonce the wallet should sign as ADR-036 arbitrary data to log in.
+	// Every nonce is valid once until it expires, issuing a new nonce doesn't invalidate previous ones.
 	//
 	// ---
 	// produces:
@@ -552,7 +1954,12 @@ func (s *server) registerTestnetAccount(w http.ResponseWriter, r *http.Request)
 	//   type: string
 	// responses:
 	//   '200':
-	//     description: stakes were sent
+	//     schema:
+	//       "$ref": "#/definitions/NonceResponse"
+	//   '400':
+	//      description: bad request.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
 	//   '500':
 	//      description: internal server error.
 	//      schema:
@@ -564,12 +1971,121 @@ func (s *server) registerTestnetAccount(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if err := s.s.RegisterTestnetAccount(r.Context(), address); err != nil {
-		api.WriteInternalErrorf(r.Context(), w, err, "failed to give stakes")
+	nonce, expiresAt, err := s.s.GetLoginNonce(r.Context(), address)
+	if err != nil {
+		api.WriteInternalErrorf(r.Context(), w, err, "failed to issue login nonce")
 		return
 	}
 
-	api.WriteOK(w, http.StatusOK, EmptyResponse{})
+	api.WriteOK(w, http.StatusOK, NonceResponse{
+		Nonce:     nonce,
+		ExpiresAt: expiresAt.Format(time.RFC3339),
+	})
+}
+
+// login exchanges a signed nonce for a session token.
+func (s *server) login(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation POST /v1/auth/login Vulcan Login
+	//
+	// Exchanges a nonce issued by GetLoginNonce and signed as ADR-036 arbitrary data for a session token.
+	// The token gives access to the account's endpoints.
+	//
+	// ---
+	// produces:
+	// - application/json
+	// consumes:
+	// - application/json
+	// parameters:
+	// - name: request
+	//   in: body
+	//   required: true
+	//   schema:
+	//     '$ref': '#/definitions/LoginRequest'
+	// responses:
+	//   '200':
+	//     schema:
+	//       "$ref": "#/definitions/LoginResponse"
+	//   '400':
+	//      description: bad request.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '401':
+	//      description: signature is not verified or nonce is invalid.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '500':
+	//      description: internal server error.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+
+	var req LoginRequest
+	if err := json.NewFuroder(r.Body).Decode(&req); err != nil {
+		api.WriteError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if err := req.validate(); err != nil {
+		api.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := auth.VerifyADR036(req.Address, req.PublicKey, req.Signature, []byte(req.Nonce)); err != nil {
+		api.WriteError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	if err := s.s.ConsumeLoginNonce(r.Context(), req.Address, req.Nonce); err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidNonce):
+			api.WriteError(w, http.StatusUnauthorized, "invalid nonce")
+		default:
+			api.WriteInternalErrorf(r.Context(), w, err, "failed to consume login nonce")
+		}
+		return
+	}
+
+	token, expiresAt := s.sessions.Issue(req.Address)
+
+	api.WriteOK(w, http.StatusOK, LoginResponse{
+		Token:     token,
+		ExpiresAt: expiresAt.Format(time.RFC3339),
+	})
+}
+
+func writeSupplyError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, supply.ErrUnavailable) || errors.Is(err, supply.ErrStale) {
+		api.WriteError(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+
+	api.WriteInternalErrorf(r.Context(), w, err, "failed to get supply")
+}
+
+func writeText(w http.ResponseWriter, s string) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(s)) // nolint:errcheck
+}
+
+// verifyOwner checks the request is signed by the address' key. Writes an error and returns false otherwise.
+func verifyOwner(w http.ResponseWriter, r *http.Request, address string) bool {
+	if err := api.Verify(r); err != nil {
+		api.WriteVerifyError(r.Context(), w, err)
+		return false
+	}
+
+	owner, err := api.GetAddressFromPubKey(r.Header.Get(api.PublicKeyHeader))
+	if err != nil {
+		api.WriteError(w, http.StatusBadRequest, api.ErrInvalidPublicKey.Error())
+		return false
+	}
+
+	if owner.String() != address {
+		api.WriteError(w, http.StatusForbidden, "public key doesn't belong to address")
+		return false
+	}
+
+	return true
 }
 
 func toReferralTrackingStatsItem(item storage.ReferralTrackingStats) ReferralTrackingStatsItem {
@@ -580,3 +2096,43 @@ func toReferralTrackingStatsItem(item storage.ReferralTrackingStats) ReferralTra
 		Reward:     sdk.NewCoin(config.DefaultBondDenom, item.Reward),
 	}
 }
+
+func toReferralTrackingItem(item storage.ReferralTracking, thresholdDays int, now time.Time) ReferralTrackingItem {
+	formatTime := func(t sql.NullTime) *string {
+		if !t.Valid {
+			return nil
+		}
+		s := t.Time.Format(time.RFC3339)
+		return &s
+	}
+
+	toCoin := func(amount sql.NullInt64) *sdk.Coin {
+		if !amount.Valid {
+			return nil
+		}
+		c := sdk.NewInt64Coin(config.DefaultBondDenom, amount.Int64)
+		return &c
+	}
+
+	out := ReferralTrackingItem{
+		Receiver:       maskAddress(item.Receiver),
+		Status:         string(item.Status),
+		RegisteredAt:   item.RegisteredAt.Format(time.RFC3339),
+		InstalledAt:    formatTime(item.InstalledAt),
+		ConfirmedAt:    formatTime(item.ConfirmedAt),
+		CancelledAt:    formatTime(item.CancelledAt),
+		SenderReward:   toCoin(item.SenderReward),
+		ReceiverReward: toCoin(item.ReceiverReward),
+	}
+
+	if item.Status == storage.InstalledReferralStatus && !item.CancelledAt.Valid && item.InstalledAt.Valid {
+		left := item.InstalledAt.Time.AddDate(0, 0, thresholdDays).Sub(now)
+		days := int(math.Ceil(left.Hours() / 24))
+		if days < 0 {
+			days = 0
+		}
+		out.DaysUntilEligible = &days
+	}
+
+	return out
+}
@@ -1,6 +1,9 @@
 package server
 
 import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"strings"
@@ -12,12 +15,17 @@ import (
 	"github.com/go-chi/chi"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/crypto/secp256k1"
 
+	"github.com/TessorNetwork/go-api"
 	"github.com/TessorNetwork/go-api/test"
+	"github.com/TessorNetwork/vulcan/internal/auth"
 	"github.com/TessorNetwork/vulcan/internal/referral"
 	"github.com/TessorNetwork/vulcan/internal/service"
 	servicemock "github.com/TessorNetwork/vulcan/internal/service/mock"
 	"github.com/TessorNetwork/vulcan/internal/storage"
+	"github.com/TessorNetwork/vulcan/internal/supply"
 	supplymock "github.com/TessorNetwork/vulcan/internal/supply/mock"
 )
 
@@ -106,6 +114,17 @@ func Test_Register(t *testing.T) {
 			rdata: `{"error": "internal error"}`,
 			rlog:  "failed to register request",
 		},
+		{
+			name: "referral code banned",
+			body: []byte(`{"email":"furya@furya.xyz", "address":"furya18c2phdrfjkggr4afwf3rw4h4xsjvfhh2gl7t4m", "referralCode": "abcdef12", "recaptchaResponse": "213"}`),
+			mockFn: func(srv *servicemock.MockService) {
+				srv.EXPECT().CheckRecaptcha(gomock.Not(gomock.Nil()), "register", "213").Return(nil)
+				srv.EXPECT().Register(gomock.Not(gomock.Nil()), "furya@furya.xyz", "furya18c2phdrfjkggr4afwf3rw4h4xsjvfhh2gl7t4m", gomock.Any()).Return(service.ErrReferralCodeBanned)
+			},
+			rcode: http.StatusUnprocessableEntity,
+			rdata: `{"error": "referral code is banned"}`,
+			rlog:  "",
+		},
 		{
 			name: "referral code",
 			body: []byte(`{"email":"furya@furya.xyz", "address":"furya18c2phdrfjkggr4afwf3rw4h4xsjvfhh2gl7t4m", "referralCode": "abcdef12", "recaptchaResponse": "213"}`),
@@ -180,6 +199,82 @@ func Test_GetRegisterStats(t *testing.T) {
 		w.Body.String())
 }
 
+func Test_GetRegistrationFunnel(t *testing.T) {
+	day := time.Date(2022, 10, 17, 0, 0, 0, 0, time.UTC)
+
+	tt := []struct {
+		name        string
+		url         string
+		from, to    time.Time
+		granularity storage.Granularity
+		rcode       int
+		rdata       string
+	}{
+		{
+			name:        "success",
+			url:         "v1/register/funnel?from=2022-10-17&to=2022-10-18&granularity=week",
+			from:        day,
+			to:          day.AddDate(0, 0, 2),
+			granularity: storage.GranularityWeek,
+			rcode:       http.StatusOK,
+			rdata: `[{"date":"2022-10-17","started":2,"emailsSent":1,"confirmed":1,` +
+				`"referralRegistered":1,"installed":0,"rewarded":0}]`,
+		},
+		{
+			name:  "invalid granularity",
+			url:   "v1/register/funnel?granularity=year",
+			rcode: http.StatusBadRequest,
+			rdata: `{"error":"invalid granularity"}`,
+		},
+		{
+			name:  "invalid from",
+			url:   "v1/register/funnel?from=2022-10-17T00:00:00Z",
+			rcode: http.StatusBadRequest,
+			rdata: `{"error":"invalid from"}`,
+		},
+		{
+			name:  "from after to",
+			url:   "v1/register/funnel?from=2022-10-18&to=2022-10-17",
+			rcode: http.StatusBadRequest,
+			rdata: `{"error":"from should not be after to"}`,
+		},
+		{
+			name:  "too long period",
+			url:   "v1/register/funnel?from=2018-10-18&to=2022-10-17",
+			rcode: http.StatusBadRequest,
+			rdata: `{"error":"too long period, max is 732 days"}`,
+		},
+	}
+
+	for i := range tt {
+		tc := tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			_, w, r := test.NewAPITestParameters(http.MethodGet, tc.url, nil)
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			srv := servicemock.NewMockService(ctrl)
+			if tc.rcode == http.StatusOK {
+				srv.EXPECT().GetRegistrationFunnel(gomock.Any(), tc.from, tc.to, tc.granularity).Return(
+					[]*storage.RegistrationFunnelItem{
+						{Date: day, Started: 2, EmailsSent: 1, Confirmed: 1, ReferralRegistered: 1},
+					}, nil)
+			}
+
+			router := chi.NewRouter()
+
+			s := server{s: srv}
+			router.Get("/v1/register/funnel", s.getRegistrationFunnel)
+
+			router.ServeHTTP(w, r)
+
+			assert.Equal(t, tc.rcode, w.Code)
+			assert.JSONEq(t, tc.rdata, w.Body.String())
+		})
+	}
+}
+
 func Test_ListDLoans(t *testing.T) {
 	_, w, r := test.NewAPITestParameters(http.MethodGet, "v1/dloan?take=25&skip=5", nil)
 
@@ -360,17 +455,17 @@ func Test_GetReferralConfig(t *testing.T) {
 func Test_Circulating(t *testing.T) {
 	tt := []struct {
 		name   string
-		amount int64
+		amount string
 		err    error
 		rcode  int
 		rdata  string
 	}{
 		{
 			name:   "success",
-			amount: 1000,
+			amount: "1000.5",
 			err:    nil,
 			rcode:  http.StatusOK,
-			rdata:  "1000",
+			rdata:  `"1000.5"`,
 		},
 		{
 			name:  "internal error",
@@ -378,6 +473,18 @@ func Test_Circulating(t *testing.T) {
 			rcode: http.StatusInternalServerError,
 			rdata: `{"error": "internal error"}`,
 		},
+		{
+			name:  "unavailable",
+			err:   fmt.Errorf("%w: native hasn't been fetched", supply.ErrUnavailable),
+			rcode: http.StatusServiceUnavailable,
+			rdata: `{"error": "supply is unavailable: native hasn't been fetched"}`,
+		},
+		{
+			name:  "stale",
+			err:   fmt.Errorf("%w: native updated at 2022-10-17T12:00:00Z", supply.ErrStale),
+			rcode: http.StatusServiceUnavailable,
+			rdata: `{"error": "supply is stale: native updated at 2022-10-17T12:00:00Z"}`,
+		},
 	}
 
 	for i := range tt {
@@ -406,3 +513,1177 @@ func Test_Circulating(t *testing.T) {
 		})
 	}
 }
+
+func Test_SupplyDetails(t *testing.T) {
+	details := supply.Details{
+		Denom:    "ufury",
+		Decimals: 6,
+		Native:   sdk.NewInt(1000000000),
+		EVM: []supply.ChainSupply{
+			{Name: "ethereum", Total: sdk.NewInt(400000000), UpdatedAt: time.Date(2022, 10, 17, 12, 0, 0, 0, time.UTC)},
+			{Name: "bsc", Total: sdk.NewInt(100000000), UpdatedAt: time.Date(2022, 10, 17, 12, 5, 0, 0, time.UTC), Stale: true},
+		},
+		Excluded: []supply.ExcludedBalance{
+			{Name: "locked", Chain: "ethereum", Address: "0x91b028C41b0268d346E78209Eb5EF5579487b639", Amount: sdk.NewInt(100000000)},
+			{Name: "community pool", Chain: supply.NativeChain, Address: "furya1pool", Amount: sdk.NewInt(50000)},
+		},
+		UpdatedAt: time.Date(2022, 10, 17, 12, 0, 0, 0, time.UTC),
+	}
+
+	tt := []struct {
+		name  string
+		url   string
+		rdata string
+		ctype string
+	}{
+		{
+			name: "details",
+			url:  "v1/supply/details",
+			rdata: `{"denom":"ufury","decimals":6,"total":"1500000000","circulating":"1399950000",` +
+				`"native":"1000000000","erc20":"500000000","chains":[` +
+				`{"name":"ethereum","total":"400000000","updatedAt":"2022-10-17T12:00:00Z","stale":false},` +
+				`{"name":"bsc","total":"100000000","updatedAt":"2022-10-17T12:05:00Z","stale":true}],"excluded":[` +
+				`{"name":"locked","chain":"ethereum","address":"0x91b028C41b0268d346E78209Eb5EF5579487b639","amount":"100000000"},` +
+				`{"name":"community pool","chain":"native","address":"furya1pool","amount":"50000"}],` +
+				`"updatedAt":"2022-10-17T12:00:00Z"}`,
+			ctype: "application/json",
+		},
+		{
+			name:  "total",
+			url:   "v1/supply/total",
+			rdata: "1500",
+			ctype: "text/plain; charset=utf-8",
+		},
+		{
+			name:  "circulating",
+			url:   "v1/supply/circulating",
+			rdata: "1399.95",
+			ctype: "text/plain; charset=utf-8",
+		},
+	}
+
+	for i := range tt {
+		tc := tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, w, r := test.NewAPITestParameters(http.MethodGet, tc.url, nil)
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			sup := supplymock.NewMockSupply(ctrl)
+			sup.EXPECT().GetDetails().Return(details, nil)
+
+			router := chi.NewRouter()
+			SetupRouter(nil, sup, router, time.Second, false, "", nil)
+
+			router.ServeHTTP(w, r)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tc.ctype, w.Header().Get("Content-Type"))
+			if tc.ctype == "application/json" {
+				assert.JSONEq(t, tc.rdata, w.Body.String())
+			} else {
+				assert.Equal(t, tc.rdata, w.Body.String())
+			}
+		})
+	}
+}
+
+func Test_SupplyDetails_Unavailable(t *testing.T) {
+	tt := []struct {
+		name  string
+		url   string
+		err   error
+		rdata string
+	}{
+		{
+			name:  "details of never fetched chain",
+			url:   "v1/supply/details",
+			err:   fmt.Errorf("%w: bsc hasn't been fetched", supply.ErrUnavailable),
+			rdata: `{"error": "supply is unavailable: bsc hasn't been fetched"}`,
+		},
+		{
+			name:  "circulating of never fetched chain",
+			url:   "v1/supply/circulating",
+			err:   fmt.Errorf("%w: bsc hasn't been fetched", supply.ErrUnavailable),
+			rdata: `{"error": "supply is unavailable: bsc hasn't been fetched"}`,
+		},
+		{
+			name:  "details of stale native supply",
+			url:   "v1/supply/details",
+			err:   fmt.Errorf("%w: native updated at 2022-10-17T12:00:00Z", supply.ErrStale),
+			rdata: `{"error": "supply is stale: native updated at 2022-10-17T12:00:00Z"}`,
+		},
+		{
+			name:  "total of stale native supply",
+			url:   "v1/supply/total",
+			err:   fmt.Errorf("%w: native updated at 2022-10-17T12:00:00Z", supply.ErrStale),
+			rdata: `{"error": "supply is stale: native updated at 2022-10-17T12:00:00Z"}`,
+		},
+	}
+
+	for i := range tt {
+		tc := tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, w, r := test.NewAPITestParameters(http.MethodGet, tc.url, nil)
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			sup := supplymock.NewMockSupply(ctrl)
+			sup.EXPECT().GetDetails().Return(supply.Details{}, tc.err)
+
+			router := chi.NewRouter()
+			SetupRouter(nil, sup, router, time.Second, false, "", nil)
+
+			router.ServeHTTP(w, r)
+
+			assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+			assert.JSONEq(t, tc.rdata, w.Body.String())
+		})
+	}
+}
+
+func Test_GetSupplyHistory(t *testing.T) {
+	from := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2022, 10, 3, 0, 0, 0, 0, time.UTC)
+
+	tt := []struct {
+		name   string
+		query  string
+		mockFn func(sup *supplymock.MockSupply)
+		rcode  int
+		rdata  string
+	}{
+		{
+			name:  "success",
+			query: "?from=2022-10-01T00:00:00Z&to=2022-10-03T00:00:00Z&interval=1d",
+			mockFn: func(sup *supplymock.MockSupply) {
+				sup.EXPECT().GetHistory(gomock.Not(gomock.Nil()), from, to, 24*time.Hour).Return([]*storage.SupplySnapshot{
+					{CreatedAt: from, Total: sdk.NewInt(3), Circulating: sdk.NewInt(2)},
+					{CreatedAt: from.Add(24 * time.Hour), Total: sdk.NewInt(4), Circulating: sdk.NewInt(3)},
+				}, nil)
+			},
+			rcode: http.StatusOK,
+			rdata: `[
+  {"time":"2022-10-01T00:00:00Z","total":"3","circulating":"2"},
+  {"time":"2022-10-02T00:00:00Z","total":"4","circulating":"3"}
+]`,
+		},
+		{
+			name:  "invalid interval",
+			query: "?interval=1m",
+			rcode: http.StatusBadRequest,
+			rdata: `{"error":"invalid interval"}`,
+		},
+		{
+			name:  "invalid from",
+			query: "?from=yesterday",
+			rcode: http.StatusBadRequest,
+			rdata: `{"error":"invalid from"}`,
+		},
+		{
+			name:  "from after to",
+			query: "?from=2022-10-03T00:00:00Z&to=2022-10-01T00:00:00Z",
+			rcode: http.StatusBadRequest,
+			rdata: `{"error":"to should be after from"}`,
+		},
+		{
+			name:  "too many points",
+			query: "?from=2020-10-01T00:00:00Z&to=2022-10-01T00:00:00Z&interval=1h",
+			rcode: http.StatusBadRequest,
+			rdata: `{"error":"too many points, max is 1000"}`,
+		},
+	}
+
+	for i := range tt {
+		tc := tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, w, r := test.NewAPITestParameters(http.MethodGet, "v1/supply/history"+tc.query, nil)
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			sup := supplymock.NewMockSupply(ctrl)
+			if tc.mockFn != nil {
+				tc.mockFn(sup)
+			}
+
+			router := chi.NewRouter()
+			SetupRouter(nil, sup, router, time.Second, false, "", nil)
+
+			router.ServeHTTP(w, r)
+
+			assert.Equal(t, tc.rcode, w.Code)
+			assert.JSONEq(t, tc.rdata, w.Body.String())
+		})
+	}
+}
+
+func Test_ClaimReferralCode(t *testing.T) {
+	pk := secp256k1.GenPrivKey()
+	address := sdk.AccAddress(pk.PubKey().Address()).String()
+
+	tt := []struct {
+		name    string
+		address string
+		body    []byte
+		unsign  bool
+		mockFn  func(srv *servicemock.MockService)
+		rcode   int
+		rdata   string
+	}{
+		{
+			name:    "success",
+			address: address,
+			body:    []byte(`{"code":"Alice"}`),
+			mockFn: func(srv *servicemock.MockService) {
+				srv.EXPECT().ClaimReferralCode(gomock.Not(gomock.Nil()), address, "Alice").Return("alice", nil)
+			},
+			rcode: http.StatusOK,
+			rdata: `{"code": "alice"}`,
+		},
+		{
+			name:    "not signed",
+			address: address,
+			body:    []byte(`{"code":"alice"}`),
+			unsign:  true,
+			rcode:   http.StatusBadRequest,
+			rdata:   `{"error": "invalid request: public key is invalid"}`,
+		},
+		{
+			name:    "foreign address",
+			address: "furya1qu2zzt3mfp2kymmu3xt28v9aett7fu07dcmk7w",
+			body:    []byte(`{"code":"alice"}`),
+			rcode:   http.StatusForbidden,
+			rdata:   `{"error": "public key doesn't belong to address"}`,
+		},
+		{
+			name:    "invalid code",
+			address: address,
+			body:    []byte(`{"code":"admin"}`),
+			mockFn: func(srv *servicemock.MockService) {
+				srv.EXPECT().ClaimReferralCode(gomock.Not(gomock.Nil()), address, "admin").
+					Return("", fmt.Errorf("%w: code is reserved", referral.ErrInvalidCode))
+			},
+			rcode: http.StatusBadRequest,
+			rdata: `{"error": "invalid referral code: code is reserved"}`,
+		},
+		{
+			name:    "not confirmed",
+			address: address,
+			body:    []byte(`{"code":"alice"}`),
+			mockFn: func(srv *servicemock.MockService) {
+				srv.EXPECT().ClaimReferralCode(gomock.Not(gomock.Nil()), address, "alice").Return("", service.ErrNotConfirmed)
+			},
+			rcode: http.StatusForbidden,
+			rdata: `{"error": "not confirmed"}`,
+		},
+		{
+			name:    "taken",
+			address: address,
+			body:    []byte(`{"code":"alice"}`),
+			mockFn: func(srv *servicemock.MockService) {
+				srv.EXPECT().ClaimReferralCode(gomock.Not(gomock.Nil()), address, "alice").Return("", service.ErrReferralCodeIsTaken)
+			},
+			rcode: http.StatusConflict,
+			rdata: `{"error": "referral code is taken"}`,
+		},
+	}
+
+	for i := range tt {
+		tc := tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, w, r := test.NewAPITestParameters(http.MethodPost, "v1/referral/code/"+tc.address, tc.body)
+			if !tc.unsign {
+				require.NoError(t, api.Sign(r, pk))
+			}
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			srv := servicemock.NewMockService(ctrl)
+			if tc.mockFn != nil {
+				tc.mockFn(srv)
+			}
+
+			router := chi.NewRouter()
+
+			s := server{s: srv}
+			router.Post("/v1/referral/code/{address}", s.claimReferralCode)
+
+			router.ServeHTTP(w, r)
+
+			assert.Equal(t, tc.rcode, w.Code)
+			assert.JSONEq(t, tc.rdata, w.Body.String())
+		})
+	}
+}
+
+func Test_TrackReferralBrowserInstallation(t *testing.T) {
+	pk := secp256k1.GenPrivKey()
+	address := sdk.AccAddress(pk.PubKey().Address()).String()
+
+	tt := []struct {
+		name   string
+		body   []byte
+		signer *secp256k1.PrivKey
+		mockFn func(srv *servicemock.MockService)
+		rcode  int
+		rdata  string
+	}{
+		{
+			name:   "success",
+			body:   []byte(`{"nonce":"abc"}`),
+			signer: &pk,
+			mockFn: func(srv *servicemock.MockService) {
+				srv.EXPECT().TrackReferralBrowserInstallation(gomock.Not(gomock.Nil()), address, "abc").Return(nil)
+			},
+			rcode: http.StatusOK,
+			rdata: `{}`,
+		},
+		{
+			name:  "not signed",
+			body:  []byte(`{"nonce":"abc"}`),
+			rcode: http.StatusBadRequest,
+			rdata: `{"error": "invalid request: public key is invalid"}`,
+		},
+		{
+			name: "signed by another key",
+			body: []byte(`{"nonce":"abc"}`),
+			signer: func() *secp256k1.PrivKey {
+				k := secp256k1.GenPrivKey()
+				return &k
+			}(),
+			rcode: http.StatusForbidden,
+			rdata: `{"error": "public key doesn't belong to address"}`,
+		},
+		{
+			name:   "invalid nonce",
+			body:   []byte(`{"nonce":"abc"}`),
+			signer: &pk,
+			mockFn: func(srv *servicemock.MockService) {
+				srv.EXPECT().TrackReferralBrowserInstallation(gomock.Not(gomock.Nil()), address, "abc").Return(service.ErrInvalidNonce)
+			},
+			rcode: http.StatusUnauthorized,
+			rdata: `{"error": "invalid nonce"}`,
+		},
+		{
+			name:   "already installed",
+			body:   []byte(`{"nonce":"abc"}`),
+			signer: &pk,
+			mockFn: func(srv *servicemock.MockService) {
+				srv.EXPECT().TrackReferralBrowserInstallation(gomock.Not(gomock.Nil()), address, "abc").Return(service.ErrReferralTrackingInvalidStatus)
+			},
+			rcode: http.StatusConflict,
+			rdata: `{"error": "status is installed or confirmed"}`,
+		},
+	}
+
+	for i := range tt {
+		tc := tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, w, r := test.NewAPITestParameters(http.MethodPost, "v1/referral/track/install/"+address, tc.body)
+			if tc.signer != nil {
+				require.NoError(t, api.Sign(r, *tc.signer))
+			}
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			srv := servicemock.NewMockService(ctrl)
+			if tc.mockFn != nil {
+				tc.mockFn(srv)
+			}
+
+			router := chi.NewRouter()
+
+			s := server{s: srv}
+			router.Post("/v1/referral/track/install/{address}", s.trackReferralBrowserInstallation)
+
+			router.ServeHTTP(w, r)
+
+			assert.Equal(t, tc.rcode, w.Code)
+			assert.JSONEq(t, tc.rdata, w.Body.String())
+		})
+	}
+}
+
+func Test_GetInstallationNonce(t *testing.T) {
+	const address = "furya1qu2zzt3mfp2kymmu3xt28v9aett7fu07dcmk7w"
+
+	_, w, r := test.NewAPITestParameters(http.MethodGet, "v1/referral/track/install/"+address+"/nonce", nil)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	srv := servicemock.NewMockService(ctrl)
+	srv.EXPECT().GetInstallationNonce(gomock.Not(gomock.Nil()), address).
+		Return("abc", time.Date(2022, 10, 15, 12, 10, 0, 0, time.UTC), nil)
+
+	router := chi.NewRouter()
+
+	s := server{s: srv}
+	router.Get("/v1/referral/track/install/{address}/nonce", s.getInstallationNonce)
+
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"nonce": "abc", "expiresAt": "2022-10-15T12:10:00Z"}`, w.Body.String())
+}
+
+func Test_GetLoginNonce(t *testing.T) {
+	const address = "furya1qu2zzt3mfp2kymmu3xt28v9aett7fu07dcmk7w"
+
+	_, w, r := test.NewAPITestParameters(http.MethodGet, "v1/auth/nonce/"+address, nil)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	srv := servicemock.NewMockService(ctrl)
+	srv.EXPECT().GetLoginNonce(gomock.Not(gomock.Nil()), address).
+		Return("abc", time.Date(2022, 10, 15, 12, 5, 0, 0, time.UTC), nil)
+
+	router := chi.NewRouter()
+
+	s := server{s: srv}
+	router.Get("/v1/auth/nonce/{address}", s.getLoginNonce)
+
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"nonce": "abc", "expiresAt": "2022-10-15T12:05:00Z"}`, w.Body.String())
+}
+
+func Test_Login(t *testing.T) {
+	pk := secp256k1.GenPrivKey()
+	address, err := api.GetAddressFromPubKey(fmt.Sprintf("%x", pk.PubKey().Bytes()))
+	require.NoError(t, err)
+
+	sig, err := pk.Sign(auth.GetADR036SignBytes(address.String(), []byte("abc")))
+	require.NoError(t, err)
+
+	body := func(nonce string, sig []byte) []byte {
+		return []byte(fmt.Sprintf(`{"address":"%s","publicKey":"%s","signature":"%s","nonce":"%s"}`,
+			address, base64.StdEncoding.EncodeToString(pk.PubKey().Bytes()), base64.StdEncoding.EncodeToString(sig), nonce))
+	}
+
+	tt := []struct {
+		name   string
+		body   []byte
+		mockFn func(srv *servicemock.MockService)
+		rcode  int
+		rdata  string
+	}{
+		{
+			name: "success",
+			body: body("abc", sig),
+			mockFn: func(srv *servicemock.MockService) {
+				srv.EXPECT().ConsumeLoginNonce(gomock.Not(gomock.Nil()), address.String(), "abc").Return(nil)
+			},
+			rcode: http.StatusOK,
+		},
+		{
+			name:  "invalid signature",
+			body:  body("abd", sig),
+			rcode: http.StatusUnauthorized,
+			rdata: `{"error":"invalid signature"}`,
+		},
+		{
+			name: "invalid nonce",
+			body: body("abc", sig),
+			mockFn: func(srv *servicemock.MockService) {
+				srv.EXPECT().ConsumeLoginNonce(gomock.Not(gomock.Nil()), address.String(), "abc").Return(service.ErrInvalidNonce)
+			},
+			rcode: http.StatusUnauthorized,
+			rdata: `{"error":"invalid nonce"}`,
+		},
+		{
+			name:  "empty nonce",
+			body:  body("", sig),
+			rcode: http.StatusBadRequest,
+			rdata: `{"error":"invalid request: empty nonce"}`,
+		},
+	}
+
+	for i := range tt {
+		tc := tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, w, r := test.NewAPITestParameters(http.MethodPost, "v1/auth/login", tc.body)
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			srv := servicemock.NewMockService(ctrl)
+			if tc.mockFn != nil {
+				tc.mockFn(srv)
+			}
+
+			sessions := auth.NewSessions([]byte("secret"), time.Hour)
+
+			router := chi.NewRouter()
+			SetupRouter(srv, nil, router, time.Second, false, "", sessions)
+
+			router.ServeHTTP(w, r)
+
+			assert.Equal(t, tc.rcode, w.Code)
+			if tc.rcode != http.StatusOK {
+				assert.JSONEq(t, tc.rdata, w.Body.String())
+				return
+			}
+
+			var resp LoginResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+
+			owner, err := sessions.Verify(resp.Token)
+			require.NoError(t, err)
+			assert.Equal(t, address.String(), owner)
+		})
+	}
+}
+
+func Test_SessionMiddleware(t *testing.T) {
+	const (
+		address = "furya1qu2zzt3mfp2kymmu3xt28v9aett7fu07dcmk7w"
+		another = "furya1p4s4djk5dqstfswg6k8sljhkzku4a6ve9dmng5"
+	)
+
+	sessions := auth.NewSessions([]byte("secret"), time.Hour)
+	token, _ := sessions.Issue(address)
+	anotherToken, _ := sessions.Issue(another)
+
+	tt := []struct {
+		name  string
+		token string
+		rcode int
+	}{
+		{
+			name:  "success",
+			token: token,
+			rcode: http.StatusOK,
+		},
+		{
+			name:  "no token",
+			rcode: http.StatusUnauthorized,
+		},
+		{
+			name:  "invalid token",
+			token: token + "x",
+			rcode: http.StatusUnauthorized,
+		},
+		{
+			name:  "another address",
+			token: anotherToken,
+			rcode: http.StatusForbidden,
+		},
+	}
+
+	for i := range tt {
+		tc := tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, w, r := test.NewAPITestParameters(http.MethodGet, "v1/referral/code/"+address, nil)
+			if tc.token != "" {
+				r.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			srv := servicemock.NewMockService(ctrl)
+			if tc.rcode == http.StatusOK {
+				srv.EXPECT().GetOwnReferralCode(gomock.Not(gomock.Nil()), address).Return("code", nil)
+			}
+
+			router := chi.NewRouter()
+			SetupRouter(srv, nil, router, time.Second, false, "", sessions)
+
+			router.ServeHTTP(w, r)
+
+			assert.Equal(t, tc.rcode, w.Code)
+		})
+	}
+}
+
+func Test_ListReferralTracking(t *testing.T) {
+	const address = "furya1qu2zzt3mfp2kymmu3xt28v9aett7fu07dcmk7w"
+
+	registeredAt := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	tt := []struct {
+		name   string
+		url    string
+		mockFn func(srv *servicemock.MockService)
+		rcode  int
+		rdata  string
+	}{
+		{
+			name: "success",
+			url:  "v1/referral/track/" + address + "/referrals?take=10&skip=5",
+			mockFn: func(srv *servicemock.MockService) {
+				srv.EXPECT().ListReferralTracking(gomock.Not(gomock.Nil()), address, 10, 5).Return([]*storage.ReferralTracking{
+					{
+						Sender:         address,
+						Receiver:       "furya18c2phdrfjkggr4afwf3rw4h4xsjvfhh2gl7t4m",
+						Status:         storage.ConfirmedReferralStatus,
+						RegisteredAt:   registeredAt,
+						InstalledAt:    sql.NullTime{Valid: true, Time: registeredAt},
+						ConfirmedAt:    sql.NullTime{Valid: true, Time: registeredAt.AddDate(0, 0, 31)},
+						SenderReward:   sql.NullInt64{Valid: true, Int64: 10},
+						ReceiverReward: sql.NullInt64{Valid: true, Int64: 5},
+					},
+					{
+						Sender:       address,
+						Receiver:     "furya1qu2zzt3mfp2kymmu3xt28v9aett7fu07dcmk7w",
+						Status:       storage.RegisteredReferralStatus,
+						RegisteredAt: registeredAt,
+					},
+				}, nil)
+				srv.EXPECT().GetReferralConfig().Return(referral.NewConfig(sdk.MustNewFurFromStr("0.000100"), 30))
+			},
+			rcode: http.StatusOK,
+			rdata: `[
+  {
+    "receiver": "furya18c2p...7t4m",
+    "status": "confirmed",
+    "registeredAt": "2022-10-01T00:00:00Z",
+    "installedAt": "2022-10-01T00:00:00Z",
+    "confirmedAt": "2022-11-01T00:00:00Z",
+    "cancelledAt": null,
+    "senderReward": {"denom": "ufury", "amount": "10"},
+    "receiverReward": {"denom": "ufury", "amount": "5"},
+    "daysUntilEligible": null
+  },
+  {
+    "receiver": "furya1qu2z...mk7w",
+    "status": "registered",
+    "registeredAt": "2022-10-01T00:00:00Z",
+    "installedAt": null,
+    "confirmedAt": null,
+    "cancelledAt": null,
+    "senderReward": null,
+    "receiverReward": null,
+    "daysUntilEligible": null
+  }
+]`,
+		},
+		{
+			name: "not found",
+			url:  "v1/referral/track/" + address + "/referrals",
+			mockFn: func(srv *servicemock.MockService) {
+				srv.EXPECT().ListReferralTracking(gomock.Not(gomock.Nil()), address, 50, 0).Return(nil, service.ErrRequestNotFound)
+			},
+			rcode: http.StatusNotFound,
+			rdata: `{"error": "not found"}`,
+		},
+	}
+
+	for i := range tt {
+		tc := tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, w, r := test.NewAPITestParameters(http.MethodGet, tc.url, nil)
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			srv := servicemock.NewMockService(ctrl)
+			tc.mockFn(srv)
+
+			router := chi.NewRouter()
+
+			s := server{s: srv}
+			router.Get("/v1/referral/track/{address}/referrals", s.listReferralTracking)
+
+			router.ServeHTTP(w, r)
+
+			assert.Equal(t, tc.rcode, w.Code)
+			assert.JSONEq(t, tc.rdata, w.Body.String())
+		})
+	}
+}
+
+func Test_toReferralTrackingItem(t *testing.T) {
+	now := time.Date(2022, 10, 20, 12, 0, 0, 0, time.UTC)
+
+	installed := func(at time.Time) storage.ReferralTracking {
+		return storage.ReferralTracking{
+			Status:      storage.InstalledReferralStatus,
+			InstalledAt: sql.NullTime{Valid: true, Time: at},
+		}
+	}
+
+	days := func(item storage.ReferralTracking) *int {
+		return toReferralTrackingItem(item, 30, now).DaysUntilEligible
+	}
+
+	assert.Equal(t, 30, *days(installed(now)))
+	assert.Equal(t, 20, *days(installed(now.AddDate(0, 0, -10))))
+	assert.Equal(t, 1, *days(installed(now.AddDate(0, 0, -29).Add(-time.Hour))))
+	assert.Equal(t, 0, *days(installed(now.AddDate(0, 0, -31))))
+
+	cancelled := installed(now)
+	cancelled.CancelledAt = sql.NullTime{Valid: true, Time: now}
+	assert.Nil(t, days(cancelled))
+}
+
+func Test_GetReferralLeaderboard(t *testing.T) {
+	items := []*storage.ReferralLeaderboardItem{
+		{Sender: "furya1qu2zzt3mfp2kymmu3xt28v9aett7fu07dcmk7w", Confirmed: 5, Reward: sdk.NewInt(50)},
+		{Sender: "furya18c2phdrfjkggr4afwf3rw4h4xsjvfhh2gl7t4m", Confirmed: 2, Reward: sdk.NewInt(20)},
+	}
+
+	tt := []struct {
+		name   string
+		url    string
+		mockFn func(srv *servicemock.MockService)
+		rcode  int
+		rdata  string
+	}{
+		{
+			name: "defaults",
+			url:  "v1/referral/leaderboard",
+			mockFn: func(srv *servicemock.MockService) {
+				srv.EXPECT().GetReferralLeaderboard(gomock.Not(gomock.Nil()), storage.LeaderboardWindowAll, 10).Return(items, nil)
+			},
+			rcode: http.StatusOK,
+			rdata: `[
+  {"rank": 1, "address": "furya1qu2z...mk7w", "confirmed": 5, "reward": {"denom": "ufury", "amount": "50"}},
+  {"rank": 2, "address": "furya18c2p...7t4m", "confirmed": 2, "reward": {"denom": "ufury", "amount": "20"}}
+]`,
+		},
+		{
+			name: "unmasked",
+			url:  "v1/referral/leaderboard?window=7d&limit=1&mask=false",
+			mockFn: func(srv *servicemock.MockService) {
+				srv.EXPECT().GetReferralLeaderboard(gomock.Not(gomock.Nil()), storage.LeaderboardWindow7Days, 1).Return(items[:1], nil)
+			},
+			rcode: http.StatusOK,
+			rdata: `[
+  {"rank": 1, "address": "furya1qu2zzt3mfp2kymmu3xt28v9aett7fu07dcmk7w", "confirmed": 5, "reward": {"denom": "ufury", "amount": "50"}}
+]`,
+		},
+		{
+			name:  "invalid window",
+			url:   "v1/referral/leaderboard?window=1y",
+			rcode: http.StatusBadRequest,
+			rdata: `{"error": "invalid window"}`,
+		},
+		{
+			name: "internal error",
+			url:  "v1/referral/leaderboard?window=30d",
+			mockFn: func(srv *servicemock.MockService) {
+				srv.EXPECT().GetReferralLeaderboard(gomock.Not(gomock.Nil()), storage.LeaderboardWindow30Days, 10).Return(nil, errTest)
+			},
+			rcode: http.StatusInternalServerError,
+			rdata: `{"error": "internal error"}`,
+		},
+	}
+
+	for i := range tt {
+		tc := tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, w, r := test.NewAPITestParameters(http.MethodGet, tc.url, nil)
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			srv := servicemock.NewMockService(ctrl)
+			if tc.mockFn != nil {
+				tc.mockFn(srv)
+			}
+
+			router := chi.NewRouter()
+
+			s := server{s: srv}
+			router.Get("/v1/referral/leaderboard", s.getReferralLeaderboard)
+
+			router.ServeHTTP(w, r)
+
+			assert.Equal(t, tc.rcode, w.Code)
+			assert.JSONEq(t, tc.rdata, w.Body.String())
+		})
+	}
+}
+
+func Test_BanReferrer(t *testing.T) {
+	const address = "furya1qu2zzt3mfp2kymmu3xt28v9aett7fu07dcmk7w"
+
+	tt := []struct {
+		name   string
+		token  string
+		url    string
+		body   []byte
+		mockFn func(srv *servicemock.MockService)
+		rcode  int
+		rdata  string
+	}{
+		{
+			name:  "success",
+			token: "secret",
+			url:   "v1/admin/referral/ban/" + address,
+			body:  []byte(`{"reason":"fraud", "cancelPendingRewards":true}`),
+			mockFn: func(srv *servicemock.MockService) {
+				srv.EXPECT().BanReferrer(gomock.Not(gomock.Nil()), address, "fraud", true).Return(2, nil)
+			},
+			rcode: http.StatusOK,
+			rdata: `{"cancelledReferrals": 2}`,
+		},
+		{
+			name:  "unauthorized",
+			token: "wrong",
+			url:   "v1/admin/referral/ban/" + address,
+			body:  []byte(`{"reason":"fraud"}`),
+			rcode: http.StatusUnauthorized,
+			rdata: `{"error": "unauthorized"}`,
+		},
+		{
+			name:  "invalid address",
+			token: "secret",
+			url:   "v1/admin/referral/ban/furya1vg085ra5hw8mx5rrheqf8fruks0xv4urqkuqg",
+			body:  []byte(`{"reason":"fraud"}`),
+			rcode: http.StatusBadRequest,
+			rdata: `{"error": "invalid address"}`,
+		},
+		{
+			name:  "empty reason",
+			token: "secret",
+			url:   "v1/admin/referral/ban/" + address,
+			body:  []byte(`{"reason":" "}`),
+			rcode: http.StatusBadRequest,
+			rdata: `{"error": "invalid request: empty reason"}`,
+		},
+		{
+			name:  "not found",
+			token: "secret",
+			url:   "v1/admin/referral/ban/" + address,
+			body:  []byte(`{"reason":"fraud"}`),
+			mockFn: func(srv *servicemock.MockService) {
+				srv.EXPECT().BanReferrer(gomock.Not(gomock.Nil()), address, "fraud", false).Return(0, service.ErrRequestNotFound)
+			},
+			rcode: http.StatusNotFound,
+			rdata: `{"error": "not found"}`,
+		},
+		{
+			name:  "already banned",
+			token: "secret",
+			url:   "v1/admin/referral/ban/" + address,
+			body:  []byte(`{"reason":"fraud"}`),
+			mockFn: func(srv *servicemock.MockService) {
+				srv.EXPECT().BanReferrer(gomock.Not(gomock.Nil()), address, "fraud", false).Return(0, service.ErrReferrerAlreadyBanned)
+			},
+			rcode: http.StatusConflict,
+			rdata: `{"error": "already banned"}`,
+		},
+		{
+			name:  "unban",
+			token: "secret",
+			url:   "v1/admin/referral/unban/" + address,
+			body:  []byte(`{"reason":"appeal"}`),
+			mockFn: func(srv *servicemock.MockService) {
+				srv.EXPECT().UnbanReferrer(gomock.Not(gomock.Nil()), address, "appeal").Return(nil)
+			},
+			rcode: http.StatusOK,
+			rdata: `{}`,
+		},
+		{
+			name:  "unban not banned",
+			token: "secret",
+			url:   "v1/admin/referral/unban/" + address,
+			body:  []byte(`{"reason":"appeal"}`),
+			mockFn: func(srv *servicemock.MockService) {
+				srv.EXPECT().UnbanReferrer(gomock.Not(gomock.Nil()), address, "appeal").Return(service.ErrReferrerNotBanned)
+			},
+			rcode: http.StatusConflict,
+			rdata: `{"error": "not banned"}`,
+		},
+	}
+
+	for i := range tt {
+		tc := tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, w, r := test.NewAPITestParameters(http.MethodPost, tc.url, tc.body)
+			r.Header.Set("Authorization", "Bearer "+tc.token)
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			srv := servicemock.NewMockService(ctrl)
+			if tc.mockFn != nil {
+				tc.mockFn(srv)
+			}
+
+			router := chi.NewRouter()
+			SetupRouter(srv, nil, router, time.Second, false, "secret", nil)
+
+			router.ServeHTTP(w, r)
+
+			assert.Equal(t, tc.rcode, w.Code)
+			assert.JSONEq(t, tc.rdata, w.Body.String())
+		})
+	}
+}
+
+func Test_GetReferralBanEvents(t *testing.T) {
+	const address = "furya1qu2zzt3mfp2kymmu3xt28v9aett7fu07dcmk7w"
+
+	_, w, r := test.NewAPITestParameters(http.MethodGet, "v1/admin/referral/ban/"+address, nil)
+	r.Header.Set("Authorization", "Bearer secret")
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	srv := servicemock.NewMockService(ctrl)
+	srv.EXPECT().GetReferralBanEvents(gomock.Not(gomock.Nil()), address).Return([]*storage.ReferralBanEvent{
+		{ID: 2, Address: address, Banned: false, Reason: "appeal", CreatedAt: time.Date(2022, 10, 12, 0, 0, 0, 0, time.UTC)},
+		{ID: 1, Address: address, Banned: true, Reason: "fraud", CreatedAt: time.Date(2022, 10, 11, 0, 0, 0, 0, time.UTC)},
+	}, nil)
+
+	router := chi.NewRouter()
+	SetupRouter(srv, nil, router, time.Second, false, "secret", nil)
+
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[
+  {"banned": false, "reason": "appeal", "createdAt": "2022-10-12T00:00:00Z"},
+  {"banned": true, "reason": "fraud", "createdAt": "2022-10-11T00:00:00Z"}
+]`, w.Body.String())
+}
+
+func Test_AdminRoutesDisabled(t *testing.T) {
+	_, w, r := test.NewAPITestParameters(http.MethodGet,
+		"v1/admin/referral/ban/furya1qu2zzt3mfp2kymmu3xt28v9aett7fu07dcmk7w", nil)
+
+	router := chi.NewRouter()
+	SetupRouter(nil, nil, router, time.Second, false, "", nil)
+
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func Test_CreateReferralCampaign(t *testing.T) {
+	double := sdk.MustNewFurFromStr("2")
+
+	tt := []struct {
+		name   string
+		body   []byte
+		mockFn func(srv *servicemock.MockService)
+		rcode  int
+		rdata  string
+	}{
+		{
+			name: "success",
+			body: []byte(`{"name":"double week","startsAt":"2022-10-17T00:00:00Z","endsAt":"2022-10-24T00:00:00Z","multiplier":"2","codes":["partner"]}`),
+			mockFn: func(srv *servicemock.MockService) {
+				srv.EXPECT().CreateReferralCampaign(gomock.Not(gomock.Nil()), storage.ReferralCampaign{
+					Name:       "double week",
+					StartsAt:   time.Date(2022, 10, 17, 0, 0, 0, 0, time.UTC),
+					EndsAt:     time.Date(2022, 10, 24, 0, 0, 0, 0, time.UTC),
+					Multiplier: &double,
+					Codes:      []string{"partner"},
+				}).Return(7, nil)
+			},
+			rcode: http.StatusOK,
+			rdata: `{"id": 7}`,
+		},
+		{
+			name:  "both rewards",
+			body:  []byte(`{"name":"n","startsAt":"2022-10-17T00:00:00Z","endsAt":"2022-10-24T00:00:00Z","multiplier":"2","fixedReward":"100"}`),
+			rcode: http.StatusBadRequest,
+			rdata: `{"error": "invalid request: exactly one of multiplier and fixedReward should be set"}`,
+		},
+		{
+			name:  "invalid period",
+			body:  []byte(`{"name":"n","startsAt":"2022-10-24T00:00:00Z","endsAt":"2022-10-17T00:00:00Z","fixedReward":"100"}`),
+			rcode: http.StatusBadRequest,
+			rdata: `{"error": "invalid request: endsAt should be after startsAt"}`,
+		},
+		{
+			name:  "invalid sender",
+			body:  []byte(`{"name":"n","startsAt":"2022-10-17T00:00:00Z","endsAt":"2022-10-24T00:00:00Z","fixedReward":"100","senders":["sender"]}`),
+			rcode: http.StatusBadRequest,
+			rdata: `{"error": "invalid request: invalid sender sender"}`,
+		},
+	}
+
+	for i := range tt {
+		tc := tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, w, r := test.NewAPITestParameters(http.MethodPost, "v1/admin/referral/campaign", tc.body)
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			srv := servicemock.NewMockService(ctrl)
+			if tc.mockFn != nil {
+				tc.mockFn(srv)
+			}
+
+			router := chi.NewRouter()
+
+			s := server{s: srv}
+			router.Post("/v1/admin/referral/campaign", s.createReferralCampaign)
+
+			router.ServeHTTP(w, r)
+
+			assert.Equal(t, tc.rcode, w.Code)
+			assert.JSONEq(t, tc.rdata, w.Body.String())
+		})
+	}
+}
+
+func Test_CreateWebhookSubscription(t *testing.T) {
+	const address = "furya1qu2zzt3mfp2kymmu3xt28v9aett7fu07dcmk7w"
+
+	tt := []struct {
+		name   string
+		body   []byte
+		mockFn func(srv *servicemock.MockService)
+		rcode  int
+		rdata  string
+	}{
+		{
+			name: "success",
+			body: []byte(`{"url":"https://partner.com/hook","secret":"0123456789abcdef","events":["referral_installed","reward_paid"],"sender":"` + address + `"}`),
+			mockFn: func(srv *servicemock.MockService) {
+				srv.EXPECT().CreateWebhookSubscription(gomock.Not(gomock.Nil()), storage.WebhookSubscription{
+					URL:    "https://partner.com/hook",
+					Secret: "0123456789abcdef",
+					Events: []string{"referral_installed", "reward_paid"},
+					Sender: sql.NullString{Valid: true, String: address},
+				}).Return(3, nil)
+			},
+			rcode: http.StatusOK,
+			rdata: `{"id": 3}`,
+		},
+		{
+			name:  "invalid url",
+			body:  []byte(`{"url":"ftp://partner.com","secret":"0123456789abcdef","events":["reward_paid"]}`),
+			rcode: http.StatusBadRequest,
+			rdata: `{"error": "invalid request: invalid url"}`,
+		},
+		{
+			name:  "short secret",
+			body:  []byte(`{"url":"https://partner.com","secret":"secret","events":["reward_paid"]}`),
+			rcode: http.StatusBadRequest,
+			rdata: `{"error": "invalid request: secret should be at least 16 characters"}`,
+		},
+		{
+			name:  "invalid event",
+			body:  []byte(`{"url":"https://partner.com","secret":"0123456789abcdef","events":["dloan_created"]}`),
+			rcode: http.StatusBadRequest,
+			rdata: `{"error": "invalid request: invalid event dloan_created"}`,
+		},
+	}
+
+	for i := range tt {
+		tc := tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, w, r := test.NewAPITestParameters(http.MethodPost, "v1/admin/webhook/subscription", tc.body)
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			srv := servicemock.NewMockService(ctrl)
+			if tc.mockFn != nil {
+				tc.mockFn(srv)
+			}
+
+			router := chi.NewRouter()
+
+			s := server{s: srv}
+			router.Post("/v1/admin/webhook/subscription", s.createWebhookSubscription)
+
+			router.ServeHTTP(w, r)
+
+			assert.Equal(t, tc.rcode, w.Code)
+			assert.JSONEq(t, tc.rdata, w.Body.String())
+		})
+	}
+}
+
+func Test_ReplayWebhookDelivery(t *testing.T) {
+	tt := []struct {
+		name   string
+		id     string
+		mockFn func(srv *servicemock.MockService)
+		rcode  int
+		rdata  string
+	}{
+		{
+			name: "success",
+			id:   "5",
+			mockFn: func(srv *servicemock.MockService) {
+				srv.EXPECT().ReplayWebhookDelivery(gomock.Not(gomock.Nil()), 5).Return(nil)
+			},
+			rcode: http.StatusOK,
+			rdata: `{}`,
+		},
+		{
+			name: "not dead",
+			id:   "6",
+			mockFn: func(srv *servicemock.MockService) {
+				srv.EXPECT().ReplayWebhookDelivery(gomock.Not(gomock.Nil()), 6).Return(service.ErrWebhookDeliveryNotFound)
+			},
+			rcode: http.StatusNotFound,
+			rdata: `{"error": "not found"}`,
+		},
+		{
+			name:  "invalid id",
+			id:    "abc",
+			rcode: http.StatusBadRequest,
+			rdata: `{"error": "invalid id"}`,
+		},
+	}
+
+	for i := range tt {
+		tc := tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, w, r := test.NewAPITestParameters(http.MethodPost, "v1/admin/webhook/delivery/"+tc.id+"/replay", nil)
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			srv := servicemock.NewMockService(ctrl)
+			if tc.mockFn != nil {
+				tc.mockFn(srv)
+			}
+
+			router := chi.NewRouter()
+
+			s := server{s: srv}
+			router.Post("/v1/admin/webhook/delivery/{id}/replay", s.replayWebhookDelivery)
+
+			router.ServeHTTP(w, r)
+
+			assert.Equal(t, tc.rcode, w.Code)
+			assert.JSONEq(t, tc.rdata, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package server
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi"
+
+	"github.com/TessorNetwork/go-api"
+
+	"github.com/TessorNetwork/vulcan/internal/auth"
+)
+
+const bearerPrefix = "Bearer "
+
+// adminAuthMiddleware restricts access to requests carrying the admin token in the Authorization header.
+func adminAuthMiddleware(token string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := r.Header.Get("Authorization")
+			if !strings.HasPrefix(header, bearerPrefix) ||
+				subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(header, bearerPrefix)), []byte(token)) != 1 {
+				api.WriteError(w, http.StatusUnauthorized, "unauthorized")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// sessionMiddleware restricts access to requests carrying a session token issued for the {address} path parameter.
+func sessionMiddleware(sessions *auth.Sessions) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := r.Header.Get("Authorization")
+			if !strings.HasPrefix(header, bearerPrefix) {
+				api.WriteError(w, http.StatusUnauthorized, "unauthorized")
+				return
+			}
+
+			address, err := sessions.Verify(strings.TrimPrefix(header, bearerPrefix))
+			if err != nil {
+				api.WriteError(w, http.StatusUnauthorized, "unauthorized")
+				return
+			}
+
+			if address != chi.URLParam(r, "address") {
+				api.WriteError(w, http.StatusForbidden, "token doesn't belong to address")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
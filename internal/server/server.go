@@ -22,8 +22,11 @@ import (
 
 	"github.com/TessorNetwork/go-api"
 
+	"github.com/TessorNetwork/vulcan/internal/auth"
+	"github.com/TessorNetwork/vulcan/internal/metrics"
 	"github.com/TessorNetwork/vulcan/internal/service"
 	"github.com/TessorNetwork/vulcan/internal/supply"
+	"github.com/TessorNetwork/vulcan/internal/tracing"
 )
 
 //go:generate swagger generate spec -t swagger -m -c . -o ../../static/swagger.json
@@ -31,47 +34,94 @@ import (
 const maxBodySize = 1024
 
 type server struct {
-	s   service.Service
-	sup supply.Supply
+	s        service.Service
+	sup      supply.Supply
+	sessions *auth.Sessions
 }
 
 // SetupRouter setups handlers to chi router.
-func SetupRouter(s service.Service, sup supply.Supply, r chi.Router, timeout time.Duration, testMode bool) {
+// Admin endpoints are enabled only when adminToken is not empty.
+// Account endpoints require a session token only when sessions is not nil.
+func SetupRouter(s service.Service, sup supply.Supply, r chi.Router, timeout time.Duration, testMode bool,
+	adminToken string, sessions *auth.Sessions) {
 	r.Use(
 		api.FileServerMiddleware("/docs", "static"),
 		api.LoggerMiddleware,
+		metrics.Middleware,
 		middleware.StripSlashes,
 		cors.AllowAll().Handler,
 		api.RequestIDMiddleware,
+		tracing.Middleware,
 		api.RecovererMiddleware,
 		api.TimeoutMiddleware(timeout),
 		api.BodyLimiterMiddleware(maxBodySize),
 	)
 
 	srv := server{
-		s:   s,
-		sup: sup,
+		s:        s,
+		sup:      sup,
+		sessions: sessions,
+	}
+
+	withSession := func(r chi.Router) chi.Router {
+		if sessions == nil {
+			return r
+		}
+		return r.With(sessionMiddleware(sessions))
 	}
 
 	r.Route("/v1", func(r chi.Router) {
 		r.Post("/register", srv.register)
 		r.Get("/register/stats", srv.getRegisterStats)
+		r.Get("/register/funnel", srv.getRegistrationFunnel)
 		r.Post("/confirm", srv.confirm)
 		r.Get("/supply", srv.supply)
+		r.Get("/supply/details", srv.supplyDetails)
+		r.Get("/supply/total", srv.totalSupply)
+		r.Get("/supply/circulating", srv.circulatingSupply)
+		r.Get("/supply/history", srv.getSupplyHistory)
 
 		if testMode {
 			r.Get("/hesoyam/{address}", srv.registerTestnetAccount)
 		}
 
+		if sessions != nil {
+			r.Route("/auth", func(r chi.Router) {
+				r.Get("/nonce/{address}", srv.getLoginNonce)
+				r.Post("/login", srv.login)
+			})
+		}
+
 		r.Route("/referral", func(r chi.Router) {
 			r.Get("/config", srv.getReferralConfig)
-			r.Get("/code/{address}", srv.getOwnReferralCode)
-			r.Get("/code/{address}/registration", srv.getRegistrationReferralCode)
+			withSession(r).Get("/code/{address}", srv.getOwnReferralCode)
+			r.Post("/code/{address}", srv.claimReferralCode)
+			withSession(r).Get("/code/{address}/registration", srv.getRegistrationReferralCode)
+			r.Get("/track/install/{address}/nonce", srv.getInstallationNonce)
 			r.Post("/track/install/{address}", srv.trackReferralBrowserInstallation)
-			r.Get("/track/stats/{address}", srv.getReferralTrackingStats)
+			withSession(r).Get("/track/stats/{address}", srv.getReferralTrackingStats)
+			withSession(r).Get("/track/{address}/referrals", srv.listReferralTracking)
+			r.Get("/leaderboard", srv.getReferralLeaderboard)
 		})
 
 		r.Post("/dloan", srv.createDLoan)
 		r.Get("/dloan", srv.listDLoans)
+
+		if adminToken != "" {
+			r.Route("/admin", func(r chi.Router) {
+				r.Use(adminAuthMiddleware(adminToken))
+
+				r.Post("/referral/ban/{address}", srv.banReferrer)
+				r.Post("/referral/unban/{address}", srv.unbanReferrer)
+				r.Get("/referral/ban/{address}", srv.getReferralBanEvents)
+				r.Post("/referral/campaign", srv.createReferralCampaign)
+				r.Get("/referral/campaign", srv.listReferralCampaigns)
+				r.Post("/webhook/subscription", srv.createWebhookSubscription)
+				r.Get("/webhook/subscription", srv.listWebhookSubscriptions)
+				r.Delete("/webhook/subscription/{id}", srv.deleteWebhookSubscription)
+				r.Get("/webhook/delivery", srv.listWebhookDeliveries)
+				r.Post("/webhook/delivery/{id}/replay", srv.replayWebhookDelivery)
+			})
+		}
 	})
 }
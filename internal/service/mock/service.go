@@ -10,6 +10,7 @@ import (
 	storage "github.com/TessorNetwork/vulcan/internal/storage"
 	gomock "github.com/golang/mock/gomock"
 	reflect "reflect"
+	time "time"
 )
 
 // MockService is a mock of Service interface
@@ -79,6 +80,21 @@ func (mr *MockServiceMockRecorder) GetRegisterStats(ctx interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRegisterStats", reflect.TypeOf((*MockService)(nil).GetRegisterStats), ctx)
 }
 
+// GetRegistrationFunnel mocks base method
+func (m *MockService) GetRegistrationFunnel(ctx context.Context, from, to time.Time, granularity storage.Granularity) ([]*storage.RegistrationFunnelItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRegistrationFunnel", ctx, from, to, granularity)
+	ret0, _ := ret[0].([]*storage.RegistrationFunnelItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRegistrationFunnel indicates an expected call of GetRegistrationFunnel
+func (mr *MockServiceMockRecorder) GetRegistrationFunnel(ctx, from, to, granularity interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRegistrationFunnel", reflect.TypeOf((*MockService)(nil).GetRegistrationFunnel), ctx, from, to, granularity)
+}
+
 // GetOwnReferralCode mocks base method
 func (m *MockService) GetOwnReferralCode(ctx context.Context, address string) (string, error) {
 	m.ctrl.T.Helper()
@@ -94,6 +110,21 @@ func (mr *MockServiceMockRecorder) GetOwnReferralCode(ctx, address interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOwnReferralCode", reflect.TypeOf((*MockService)(nil).GetOwnReferralCode), ctx, address)
 }
 
+// ClaimReferralCode mocks base method
+func (m *MockService) ClaimReferralCode(ctx context.Context, address, code string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimReferralCode", ctx, address, code)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimReferralCode indicates an expected call of ClaimReferralCode
+func (mr *MockServiceMockRecorder) ClaimReferralCode(ctx, address, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimReferralCode", reflect.TypeOf((*MockService)(nil).ClaimReferralCode), ctx, address, code)
+}
+
 // GetReferralConfig mocks base method
 func (m *MockService) GetReferralConfig() referral.Config {
 	m.ctrl.T.Helper()
@@ -123,18 +154,64 @@ func (mr *MockServiceMockRecorder) GetRegistrationReferralCode(ctx, address inte
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRegistrationReferralCode", reflect.TypeOf((*MockService)(nil).GetRegistrationReferralCode), ctx, address)
 }
 
+// GetLoginNonce mocks base method
+func (m *MockService) GetLoginNonce(ctx context.Context, address string) (string, time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLoginNonce", ctx, address)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(time.Time)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetLoginNonce indicates an expected call of GetLoginNonce
+func (mr *MockServiceMockRecorder) GetLoginNonce(ctx, address interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoginNonce", reflect.TypeOf((*MockService)(nil).GetLoginNonce), ctx, address)
+}
+
+// ConsumeLoginNonce mocks base method
+func (m *MockService) ConsumeLoginNonce(ctx context.Context, address, nonce string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeLoginNonce", ctx, address, nonce)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ConsumeLoginNonce indicates an expected call of ConsumeLoginNonce
+func (mr *MockServiceMockRecorder) ConsumeLoginNonce(ctx, address, nonce interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeLoginNonce", reflect.TypeOf((*MockService)(nil).ConsumeLoginNonce), ctx, address, nonce)
+}
+
+// GetInstallationNonce mocks base method
+func (m *MockService) GetInstallationNonce(ctx context.Context, address string) (string, time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetInstallationNonce", ctx, address)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(time.Time)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetInstallationNonce indicates an expected call of GetInstallationNonce
+func (mr *MockServiceMockRecorder) GetInstallationNonce(ctx, address interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInstallationNonce", reflect.TypeOf((*MockService)(nil).GetInstallationNonce), ctx, address)
+}
+
 // TrackReferralBrowserInstallation mocks base method
-func (m *MockService) TrackReferralBrowserInstallation(ctx context.Context, address string) error {
+func (m *MockService) TrackReferralBrowserInstallation(ctx context.Context, address, nonce string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "TrackReferralBrowserInstallation", ctx, address)
+	ret := m.ctrl.Call(m, "TrackReferralBrowserInstallation", ctx, address, nonce)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // TrackReferralBrowserInstallation indicates an expected call of TrackReferralBrowserInstallation
-func (mr *MockServiceMockRecorder) TrackReferralBrowserInstallation(ctx, address interface{}) *gomock.Call {
+func (mr *MockServiceMockRecorder) TrackReferralBrowserInstallation(ctx, address, nonce interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TrackReferralBrowserInstallation", reflect.TypeOf((*MockService)(nil).TrackReferralBrowserInstallation), ctx, address)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TrackReferralBrowserInstallation", reflect.TypeOf((*MockService)(nil).TrackReferralBrowserInstallation), ctx, address, nonce)
 }
 
 // GetReferralTrackingStats mocks base method
@@ -152,6 +229,36 @@ func (mr *MockServiceMockRecorder) GetReferralTrackingStats(ctx, address interfa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReferralTrackingStats", reflect.TypeOf((*MockService)(nil).GetReferralTrackingStats), ctx, address)
 }
 
+// ListReferralTracking mocks base method
+func (m *MockService) ListReferralTracking(ctx context.Context, address string, take, skip int) ([]*storage.ReferralTracking, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListReferralTracking", ctx, address, take, skip)
+	ret0, _ := ret[0].([]*storage.ReferralTracking)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListReferralTracking indicates an expected call of ListReferralTracking
+func (mr *MockServiceMockRecorder) ListReferralTracking(ctx, address, take, skip interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListReferralTracking", reflect.TypeOf((*MockService)(nil).ListReferralTracking), ctx, address, take, skip)
+}
+
+// GetReferralLeaderboard mocks base method
+func (m *MockService) GetReferralLeaderboard(ctx context.Context, window storage.LeaderboardWindow, limit int) ([]*storage.ReferralLeaderboardItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReferralLeaderboard", ctx, window, limit)
+	ret0, _ := ret[0].([]*storage.ReferralLeaderboardItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReferralLeaderboard indicates an expected call of GetReferralLeaderboard
+func (mr *MockServiceMockRecorder) GetReferralLeaderboard(ctx, window, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReferralLeaderboard", reflect.TypeOf((*MockService)(nil).GetReferralLeaderboard), ctx, window, limit)
+}
+
 // CreateDLoanRequest mocks base method
 func (m *MockService) CreateDLoanRequest(ctx context.Context, address, firstName, lastName string, pdv float64) error {
 	m.ctrl.T.Helper()
@@ -181,6 +288,153 @@ func (mr *MockServiceMockRecorder) ListDloanRequests(ctx, take, skip interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDloanRequests", reflect.TypeOf((*MockService)(nil).ListDloanRequests), ctx, take, skip)
 }
 
+// CreateReferralCampaign mocks base method
+func (m *MockService) CreateReferralCampaign(ctx context.Context, c storage.ReferralCampaign) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateReferralCampaign", ctx, c)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateReferralCampaign indicates an expected call of CreateReferralCampaign
+func (mr *MockServiceMockRecorder) CreateReferralCampaign(ctx, c interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateReferralCampaign", reflect.TypeOf((*MockService)(nil).CreateReferralCampaign), ctx, c)
+}
+
+// ListReferralCampaigns mocks base method
+func (m *MockService) ListReferralCampaigns(ctx context.Context) ([]*storage.ReferralCampaign, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListReferralCampaigns", ctx)
+	ret0, _ := ret[0].([]*storage.ReferralCampaign)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListReferralCampaigns indicates an expected call of ListReferralCampaigns
+func (mr *MockServiceMockRecorder) ListReferralCampaigns(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListReferralCampaigns", reflect.TypeOf((*MockService)(nil).ListReferralCampaigns), ctx)
+}
+
+// BanReferrer mocks base method
+func (m *MockService) BanReferrer(ctx context.Context, address, reason string, cancelPending bool) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BanReferrer", ctx, address, reason, cancelPending)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BanReferrer indicates an expected call of BanReferrer
+func (mr *MockServiceMockRecorder) BanReferrer(ctx, address, reason, cancelPending interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BanReferrer", reflect.TypeOf((*MockService)(nil).BanReferrer), ctx, address, reason, cancelPending)
+}
+
+// UnbanReferrer mocks base method
+func (m *MockService) UnbanReferrer(ctx context.Context, address, reason string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnbanReferrer", ctx, address, reason)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnbanReferrer indicates an expected call of UnbanReferrer
+func (mr *MockServiceMockRecorder) UnbanReferrer(ctx, address, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnbanReferrer", reflect.TypeOf((*MockService)(nil).UnbanReferrer), ctx, address, reason)
+}
+
+// GetReferralBanEvents mocks base method
+func (m *MockService) GetReferralBanEvents(ctx context.Context, address string) ([]*storage.ReferralBanEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReferralBanEvents", ctx, address)
+	ret0, _ := ret[0].([]*storage.ReferralBanEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReferralBanEvents indicates an expected call of GetReferralBanEvents
+func (mr *MockServiceMockRecorder) GetReferralBanEvents(ctx, address interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReferralBanEvents", reflect.TypeOf((*MockService)(nil).GetReferralBanEvents), ctx, address)
+}
+
+// CreateWebhookSubscription mocks base method
+func (m *MockService) CreateWebhookSubscription(ctx context.Context, s storage.WebhookSubscription) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhookSubscription", ctx, s)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWebhookSubscription indicates an expected call of CreateWebhookSubscription
+func (mr *MockServiceMockRecorder) CreateWebhookSubscription(ctx, s interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhookSubscription", reflect.TypeOf((*MockService)(nil).CreateWebhookSubscription), ctx, s)
+}
+
+// ListWebhookSubscriptions mocks base method
+func (m *MockService) ListWebhookSubscriptions(ctx context.Context) ([]*storage.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebhookSubscriptions", ctx)
+	ret0, _ := ret[0].([]*storage.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebhookSubscriptions indicates an expected call of ListWebhookSubscriptions
+func (mr *MockServiceMockRecorder) ListWebhookSubscriptions(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhookSubscriptions", reflect.TypeOf((*MockService)(nil).ListWebhookSubscriptions), ctx)
+}
+
+// DeleteWebhookSubscription mocks base method
+func (m *MockService) DeleteWebhookSubscription(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebhookSubscription", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWebhookSubscription indicates an expected call of DeleteWebhookSubscription
+func (mr *MockServiceMockRecorder) DeleteWebhookSubscription(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebhookSubscription", reflect.TypeOf((*MockService)(nil).DeleteWebhookSubscription), ctx, id)
+}
+
+// ListWebhookDeliveries mocks base method
+func (m *MockService) ListWebhookDeliveries(ctx context.Context, status storage.WebhookDeliveryStatus, take, skip int) ([]*storage.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebhookDeliveries", ctx, status, take, skip)
+	ret0, _ := ret[0].([]*storage.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebhookDeliveries indicates an expected call of ListWebhookDeliveries
+func (mr *MockServiceMockRecorder) ListWebhookDeliveries(ctx, status, take, skip interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhookDeliveries", reflect.TypeOf((*MockService)(nil).ListWebhookDeliveries), ctx, status, take, skip)
+}
+
+// ReplayWebhookDelivery mocks base method
+func (m *MockService) ReplayWebhookDelivery(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplayWebhookDelivery", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReplayWebhookDelivery indicates an expected call of ReplayWebhookDelivery
+func (mr *MockServiceMockRecorder) ReplayWebhookDelivery(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplayWebhookDelivery", reflect.TypeOf((*MockService)(nil).ReplayWebhookDelivery), ctx, id)
+}
+
 // RegisterTestnetAccount mocks base method
 func (m *MockService) RegisterTestnetAccount(ctx context.Context, address string) error {
 	m.ctrl.T.Helper()
@@ -20,13 +20,23 @@ import (
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	log "github.com/sirupsen/logrus"
 
+	"github.com/TessorNetwork/vulcan/internal/auth"
 	"github.com/TessorNetwork/vulcan/internal/blockchain"
+	"github.com/TessorNetwork/vulcan/internal/events"
 	"github.com/TessorNetwork/vulcan/internal/mail"
+	"github.com/TessorNetwork/vulcan/internal/metrics"
 	"github.com/TessorNetwork/vulcan/internal/referral"
 	"github.com/TessorNetwork/vulcan/internal/storage"
 )
 
 const codeBytesSize = 3
+
+const (
+	installationNoncePurpose = "referral_install"
+	installationNonceTTL     = 10 * time.Minute
+	loginNoncePurpose        = "login"
+	loginNonceTTL            = 5 * time.Minute
+)
 const throttlingInterval = time.Minute
 
 // nolint
@@ -60,6 +70,30 @@ var ErrReferralTrackingInvalidStatus = fmt.Errorf("referral tracking has invalid
 // ErrReferralCodeNotFound ...
 var ErrReferralCodeNotFound = fmt.Errorf("referral code not found")
 
+// ErrReferralCodeBanned is returned when the referral code owner is banned.
+var ErrReferralCodeBanned = fmt.Errorf("referral code is banned")
+
+// ErrReferrerAlreadyBanned is returned when trying to ban an already banned referrer.
+var ErrReferrerAlreadyBanned = fmt.Errorf("referrer is already banned")
+
+// ErrReferrerNotBanned is returned when trying to unban a referrer who is not banned.
+var ErrReferrerNotBanned = fmt.Errorf("referrer is not banned")
+
+// ErrNotConfirmed is returned when request is not confirmed yet.
+var ErrNotConfirmed = fmt.Errorf("not confirmed")
+
+// ErrReferralCodeIsTaken is returned when the referral code belongs to another user.
+var ErrReferralCodeIsTaken = fmt.Errorf("referral code is taken")
+
+// ErrInvalidNonce is returned when nonce is forged, expired or already used.
+var ErrInvalidNonce = fmt.Errorf("invalid nonce")
+
+// ErrWebhookSubscriptionNotFound ...
+var ErrWebhookSubscriptionNotFound = fmt.Errorf("webhook subscription not found")
+
+// ErrWebhookDeliveryNotFound is returned when there is no dead delivery to replay.
+var ErrWebhookDeliveryNotFound = fmt.Errorf("webhook delivery not found")
+
 // ErrFraudEmail ...
 var ErrFraudEmail = fmt.Errorf("email from fraud domain")
 
@@ -68,14 +102,35 @@ type Service interface {
 	Register(ctx context.Context, email, address string, referralCode *string) error
 	Confirm(ctx context.Context, owner, code string) error
 	GetRegisterStats(ctx context.Context) ([]*storage.RegisterStats, int, error)
+	GetRegistrationFunnel(ctx context.Context, from, to time.Time,
+		granularity storage.Granularity) ([]*storage.RegistrationFunnelItem, error)
 	GetOwnReferralCode(ctx context.Context, address string) (string, error)
+	ClaimReferralCode(ctx context.Context, address, code string) (string, error)
 	GetReferralConfig() referral.Config
 	GetRegistrationReferralCode(ctx context.Context, address string) (string, error)
-	TrackReferralBrowserInstallation(ctx context.Context, address string) error
+	GetLoginNonce(ctx context.Context, address string) (string, time.Time, error)
+	ConsumeLoginNonce(ctx context.Context, address, nonce string) error
+	GetInstallationNonce(ctx context.Context, address string) (string, time.Time, error)
+	TrackReferralBrowserInstallation(ctx context.Context, address, nonce string) error
 	GetReferralTrackingStats(ctx context.Context, address string) ([]*storage.ReferralTrackingStats, error)
+	ListReferralTracking(ctx context.Context, address string, take, skip int) ([]*storage.ReferralTracking, error)
+	GetReferralLeaderboard(ctx context.Context, window storage.LeaderboardWindow, limit int) ([]*storage.ReferralLeaderboardItem, error)
 	CreateDLoanRequest(ctx context.Context, address, firstName, lastName string, pdv float64) error
 	ListDloanRequests(ctx context.Context, take, skip int) ([]*storage.DLoan, error)
 
+	CreateReferralCampaign(ctx context.Context, c storage.ReferralCampaign) (int, error)
+	ListReferralCampaigns(ctx context.Context) ([]*storage.ReferralCampaign, error)
+	BanReferrer(ctx context.Context, address, reason string, cancelPending bool) (int, error)
+	UnbanReferrer(ctx context.Context, address, reason string) error
+	GetReferralBanEvents(ctx context.Context, address string) ([]*storage.ReferralBanEvent, error)
+
+	CreateWebhookSubscription(ctx context.Context, s storage.WebhookSubscription) (int, error)
+	ListWebhookSubscriptions(ctx context.Context) ([]*storage.WebhookSubscription, error)
+	DeleteWebhookSubscription(ctx context.Context, id int) error
+	ListWebhookDeliveries(ctx context.Context, status storage.WebhookDeliveryStatus,
+		take, skip int) ([]*storage.WebhookDelivery, error)
+	ReplayWebhookDelivery(ctx context.Context, id int) error
+
 	RegisterTestnetAccount(ctx context.Context, address string) error
 
 	CheckRecaptcha(ctx context.Context, action, recaptchaResponse string) error
@@ -86,9 +141,11 @@ type service struct {
 	storage storage.Storage
 	sender  mail.Sender
 	bc      blockchain.Blockchain
+	events  events.Publisher
 
 	rc              referral.Config
 	recaptchaSecret string
+	nonces          *auth.Nonces
 
 	initialStakes sdk.Int
 	initialMemo   string
@@ -99,17 +156,21 @@ func New(
 	storage storage.Storage,
 	sender mail.Sender,
 	bc blockchain.Blockchain,
+	publisher events.Publisher,
 	initialStakes sdk.Int,
 	initialMemo string,
 	rc referral.Config,
 	recaptchaSecret string,
+	nonces *auth.Nonces,
 ) Service {
 	s := &service{
 		storage:         storage,
 		sender:          sender,
 		bc:              bc,
+		events:          publisher,
 		rc:              rc,
 		recaptchaSecret: recaptchaSecret,
+		nonces:          nonces,
 		initialStakes:   initialStakes,
 		initialMemo:     initialMemo,
 	}
@@ -121,7 +182,16 @@ func (s *service) GetReferralConfig() referral.Config {
 	return s.rc
 }
 
-func (s *service) Register(ctx context.Context, email, address string, referralCode *string) error {
+// publish publishes the event if the publisher is set.
+func (s *service) publish(ctx context.Context, e events.Event) {
+	if s.events != nil {
+		s.events.Publish(ctx, e)
+	}
+}
+
+func (s *service) Register(ctx context.Context, email, address string, referralCode *string) (err error) {
+	defer func() { metrics.IncRegistrations(outcome(err)) }()
+
 	var (
 		owner = getEmailHash(truncatePlusPart(email))
 		code  = randomCode()
@@ -159,7 +229,7 @@ func (s *service) Register(ctx context.Context, email, address string, referralC
 		if req.ReferralBanned {
 			log.WithField("address", req.Address).WithField("referral code", req.OwnReferralCode).
 				Warn("referral code banned")
-			return ErrReferralCodeNotFound
+			return ErrReferralCodeBanned
 		}
 	}
 
@@ -172,9 +242,39 @@ func (s *service) Register(ctx context.Context, email, address string, referralC
 
 	s.sender.SendVerificationEmailAsync(ctx, email, code)
 
+	s.publish(ctx, events.RegistrationStartedEvent{
+		Address:      address,
+		ReferralCode: referralCodeAsNullString.String,
+	})
+
 	return nil
 }
 
+// outcome returns the metrics outcome of the registration or confirmation.
+func outcome(err error) string {
+	for _, v := range []struct {
+		err   error
+		label string
+	}{
+		{ErrAlreadyExists, "already_exists"},
+		{ErrAlreadyConfirmed, "already_confirmed"},
+		{ErrRequestNotFound, "not_found"},
+		{ErrTooManyAttempts, "too_many_attempts"},
+		{ErrFraudEmail, "fraud_email"},
+		{ErrReferralCodeNotFound, "referral_code_not_found"},
+		{ErrReferralCodeBanned, "referral_code_banned"},
+	} {
+		if errors.Is(err, v.err) {
+			return v.label
+		}
+	}
+
+	if err != nil {
+		return metrics.OutcomeError
+	}
+	return metrics.OutcomeSuccess
+}
+
 func (s *service) CreateDLoanRequest(ctx context.Context, address, firstName, lastName string, pdv float64) error {
 	if err := s.storage.CreateDLoan(ctx, address, firstName, lastName, pdv); err != nil {
 		if errors.Is(err, storage.ErrAddressIsTaken) {
@@ -184,13 +284,19 @@ func (s *service) CreateDLoanRequest(ctx context.Context, address, firstName, la
 	}
 
 	log.WithFields(log.Fields{
-		"sender":    "slack",
 		"address":   address,
 		"firstName": firstName,
 		"lastName":  lastName,
 		"pdv":       pdv,
 	}).Info("dLoan request")
 
+	s.publish(ctx, events.DLoanCreatedEvent{
+		Address:   address,
+		FirstName: firstName,
+		LastName:  lastName,
+		PDV:       pdv,
+	})
+
 	return nil
 }
 
@@ -228,7 +334,9 @@ func (s *service) checkRegistrationConflicts(ctx context.Context, email, address
 	return nil
 }
 
-func (s *service) Confirm(ctx context.Context, email, code string) error {
+func (s *service) Confirm(ctx context.Context, email, code string) (err error) {
+	defer func() { metrics.IncConfirmations(outcome(err)) }()
+
 	req, err := s.storage.GetRequestByOwner(ctx, getEmailHash(truncatePlusPart(email)))
 	if err != nil {
 		if errors.Is(err, storage.ErrNotFound) {
@@ -245,9 +353,10 @@ func (s *service) Confirm(ctx context.Context, email, code string) error {
 		return ErrRequestNotFound
 	}
 
-	if err := s.bc.SendStakes([]blockchain.Stake{{Address: req.Address, Amount: s.initialStakes}}, s.initialMemo); err != nil {
+	if err := s.bc.SendStakes(ctx, []blockchain.Stake{{Address: req.Address, Amount: s.initialStakes}}, s.initialMemo); err != nil {
 		return fmt.Errorf("failed to send stakes to %s on mainnet: %w", req.Address, err)
 	}
+	metrics.AddPayout(metrics.PayoutConfirmation, s.initialStakes)
 
 	s.sender.SendWelcomeEmailAsync(ctx, req.Email)
 
@@ -285,6 +394,11 @@ func (s *service) Confirm(ctx context.Context, email, code string) error {
 
 	logger.Info("registration complete")
 
+	s.publish(ctx, events.ConfirmedEvent{
+		Address: req.Address,
+		Stakes:  s.initialStakes,
+	})
+
 	return nil
 }
 
@@ -300,7 +414,26 @@ func (s *service) GetOwnReferralCode(ctx context.Context, address string) (strin
 	return req.OwnReferralCode, nil
 }
 
-func (s *service) TrackReferralBrowserInstallation(ctx context.Context, address string) error {
+// GetInstallationNonce issues a nonce the browser should sign to prove the installation.
+// Previous unexpired nonces stay valid.
+func (s *service) GetInstallationNonce(ctx context.Context, address string) (string, time.Time, error) {
+	rt, err := s.storage.GetReferralTrackingByReceiver(ctx, address)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return "", time.Time{}, ErrReferralTrackingNotFound
+		}
+		return "", time.Time{}, err
+	}
+
+	if rt.Status != storage.RegisteredReferralStatus {
+		return "", time.Time{}, ErrReferralTrackingInvalidStatus
+	}
+
+	return s.issueNonce(address, installationNoncePurpose, installationNonceTTL)
+}
+
+// TrackReferralBrowserInstallation marks the referral installed. The nonce should be issued by GetInstallationNonce.
+func (s *service) TrackReferralBrowserInstallation(ctx context.Context, address, nonce string) error {
 	rt, err := s.storage.GetReferralTrackingByReceiver(ctx, address)
 	if err != nil {
 		if errors.Is(err, storage.ErrNotFound) {
@@ -313,6 +446,10 @@ func (s *service) TrackReferralBrowserInstallation(ctx context.Context, address
 		return ErrReferralTrackingInvalidStatus
 	}
 
+	if err := s.consumeNonce(ctx, address, installationNoncePurpose, nonce); err != nil {
+		return err
+	}
+
 	if err := s.storage.TransitionReferralTrackingToInstalled(ctx, address); err != nil {
 		return fmt.Errorf("failed to mark referral tracking installed: %w", err)
 	}
@@ -323,9 +460,102 @@ func (s *service) TrackReferralBrowserInstallation(ctx context.Context, address
 		"registered_at": rt.RegisteredAt,
 	}).Info("referral tracking installed")
 
+	s.publish(ctx, events.ReferralInstalledEvent{
+		Sender:   rt.Sender,
+		Receiver: rt.Receiver,
+	})
+
 	return nil
 }
 
+// GetLoginNonce issues a nonce the wallet should sign to obtain a session.
+// Previous unexpired nonces stay valid.
+func (s *service) GetLoginNonce(_ context.Context, address string) (string, time.Time, error) {
+	return s.issueNonce(address, loginNoncePurpose, loginNonceTTL)
+}
+
+// ConsumeLoginNonce invalidates the nonce issued by GetLoginNonce.
+// Returns ErrInvalidNonce if it is forged, expired or already used.
+func (s *service) ConsumeLoginNonce(ctx context.Context, address, nonce string) error {
+	return s.consumeNonce(ctx, address, loginNoncePurpose, nonce)
+}
+
+func (s *service) issueNonce(address, purpose string, ttl time.Duration) (string, time.Time, error) {
+	nonce, expiresAt, err := s.nonces.Issue(address, purpose, ttl)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to issue nonce: %w", err)
+	}
+
+	return nonce, expiresAt, nil
+}
+
+// consumeNonce verifies the nonce and stores it as used, so it can't be replayed until it expires.
+func (s *service) consumeNonce(ctx context.Context, address, purpose, nonce string) error {
+	expiresAt, err := s.nonces.Verify(address, purpose, nonce)
+	if err != nil {
+		return ErrInvalidNonce
+	}
+
+	if err := s.storage.ConsumeNonce(ctx, address, purpose, nonce, expiresAt); err != nil {
+		if errors.Is(err, storage.ErrNonceUsed) {
+			return ErrInvalidNonce
+		}
+		return fmt.Errorf("failed to consume nonce: %w", err)
+	}
+
+	return nil
+}
+
+// ClaimReferralCode replaces the own referral code with the vanity one. Old codes keep working as aliases.
+// Returns the normalized code.
+func (s *service) ClaimReferralCode(ctx context.Context, address, code string) (string, error) {
+	code = referral.NormalizeCode(code)
+	if err := referral.ValidateCode(code); err != nil {
+		return "", err
+	}
+
+	req, err := s.storage.GetRequestByAddress(ctx, address)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return "", ErrRequestNotFound
+		}
+		return "", fmt.Errorf("failed to get request by address: %w", err)
+	}
+
+	if !req.ConfirmedAt.Valid {
+		return "", ErrNotConfirmed
+	}
+
+	if req.OwnReferralCode == code {
+		return code, nil
+	}
+
+	if err := s.storage.InTx(ctx, func(st storage.Storage) error {
+		if err := st.CreateReferralCodeAlias(ctx, req.Owner, req.OwnReferralCode); err != nil {
+			return fmt.Errorf("failed to keep old referral code: %w", err)
+		}
+
+		if err := st.CreateReferralCodeAlias(ctx, req.Owner, code); err != nil {
+			return err
+		}
+
+		return st.SetOwnReferralCode(ctx, req.Owner, code)
+	}); err != nil {
+		if errors.Is(err, storage.ErrReferralCodeIsTaken) {
+			return "", ErrReferralCodeIsTaken
+		}
+		return "", fmt.Errorf("failed to claim referral code: %w", err)
+	}
+
+	log.WithFields(log.Fields{
+		"address":  address,
+		"old_code": req.OwnReferralCode,
+		"new_code": code,
+	}).Info("referral code claimed")
+
+	return code, nil
+}
+
 func (s *service) GetRegistrationReferralCode(ctx context.Context, address string) (string, error) {
 	req, err := s.storage.GetRequestByAddress(ctx, address)
 	if err != nil {
@@ -377,8 +607,214 @@ func (s *service) GetRegisterStats(ctx context.Context) ([]*storage.RegisterStat
 	return stats, total, nil
 }
 
+func (s *service) GetRegistrationFunnel(ctx context.Context, from, to time.Time,
+	granularity storage.Granularity) ([]*storage.RegistrationFunnelItem, error) {
+	items, err := s.storage.GetRegistrationFunnel(ctx, from, to, granularity)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get registration funnel: %w", err)
+	}
+	return items, nil
+}
+
+func (s *service) ListReferralTracking(ctx context.Context, address string,
+	take, skip int) ([]*storage.ReferralTracking, error) {
+	if _, err := s.storage.GetRequestByAddress(ctx, address); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, ErrRequestNotFound
+		}
+		return nil, fmt.Errorf("failed to get request by address: %w", err)
+	}
+
+	referrals, err := s.storage.GetReferralTrackingBySender(ctx, address, take, skip)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get referral tracking: %w", err)
+	}
+
+	return referrals, nil
+}
+
+func (s *service) GetReferralLeaderboard(ctx context.Context, window storage.LeaderboardWindow,
+	limit int) ([]*storage.ReferralLeaderboardItem, error) {
+	items, err := s.storage.GetReferralLeaderboard(ctx, window, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get referral leaderboard: %w", err)
+	}
+
+	return items, nil
+}
+
+func (s *service) CreateReferralCampaign(ctx context.Context, c storage.ReferralCampaign) (int, error) {
+	id, err := s.storage.CreateReferralCampaign(ctx, c)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create referral campaign: %w", err)
+	}
+
+	log.WithFields(log.Fields{
+		"id":        id,
+		"name":      c.Name,
+		"starts_at": c.StartsAt,
+		"ends_at":   c.EndsAt,
+	}).Info("referral campaign created")
+
+	return id, nil
+}
+
+func (s *service) ListReferralCampaigns(ctx context.Context) ([]*storage.ReferralCampaign, error) {
+	campaigns, err := s.storage.GetReferralCampaigns(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get referral campaigns: %w", err)
+	}
+
+	return campaigns, nil
+}
+
+func (s *service) CreateWebhookSubscription(ctx context.Context, sub storage.WebhookSubscription) (int, error) {
+	id, err := s.storage.CreateWebhookSubscription(ctx, sub)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create webhook subscription: %w", err)
+	}
+
+	log.WithFields(log.Fields{
+		"id":     id,
+		"url":    sub.URL,
+		"events": sub.Events,
+		"sender": sub.Sender.String,
+	}).Info("webhook subscription created")
+
+	return id, nil
+}
+
+func (s *service) ListWebhookSubscriptions(ctx context.Context) ([]*storage.WebhookSubscription, error) {
+	subscriptions, err := s.storage.GetWebhookSubscriptions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook subscriptions: %w", err)
+	}
+
+	return subscriptions, nil
+}
+
+func (s *service) DeleteWebhookSubscription(ctx context.Context, id int) error {
+	if err := s.storage.DeleteWebhookSubscription(ctx, id); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return ErrWebhookSubscriptionNotFound
+		}
+		return fmt.Errorf("failed to delete webhook subscription: %w", err)
+	}
+
+	log.WithField("id", id).Info("webhook subscription deleted")
+
+	return nil
+}
+
+func (s *service) ListWebhookDeliveries(ctx context.Context, status storage.WebhookDeliveryStatus,
+	take, skip int) ([]*storage.WebhookDelivery, error) {
+	deliveries, err := s.storage.GetWebhookDeliveries(ctx, status, take, skip)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// ReplayWebhookDelivery schedules the dead delivery for immediate redelivery.
+func (s *service) ReplayWebhookDelivery(ctx context.Context, id int) error {
+	if err := s.storage.ReplayWebhookDelivery(ctx, id); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return ErrWebhookDeliveryNotFound
+		}
+		return fmt.Errorf("failed to replay webhook delivery: %w", err)
+	}
+
+	log.WithField("id", id).Info("webhook delivery replayed")
+
+	return nil
+}
+
+// BanReferrer bans the referrer, records the audit event and optionally cancels their pending referrals.
+// Returns number of cancelled referrals.
+func (s *service) BanReferrer(ctx context.Context, address, reason string, cancelPending bool) (int, error) {
+	var cancelled int
+	if err := s.storage.InTx(ctx, func(st storage.Storage) error {
+		// the update is conditional, so only one of concurrent calls records the event
+		if err := st.SetReferralBanned(ctx, address, true); err != nil {
+			switch {
+			case errors.Is(err, storage.ErrNotFound):
+				return ErrRequestNotFound
+			case errors.Is(err, storage.ErrReferralBannedUnchanged):
+				return ErrReferrerAlreadyBanned
+			default:
+				return fmt.Errorf("failed to set referral banned: %w", err)
+			}
+		}
+
+		if err := st.CreateReferralBanEvent(ctx, address, true, reason); err != nil {
+			return fmt.Errorf("failed to create ban event: %w", err)
+		}
+
+		if cancelPending {
+			n, err := st.CancelPendingReferralTracking(ctx, address)
+			if err != nil {
+				return fmt.Errorf("failed to cancel pending referrals: %w", err)
+			}
+			cancelled = n
+		}
+
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+
+	log.WithFields(log.Fields{
+		"address":   address,
+		"reason":    reason,
+		"cancelled": cancelled,
+	}).Info("referrer banned")
+
+	return cancelled, nil
+}
+
+// UnbanReferrer unbans the referrer and records the audit event.
+func (s *service) UnbanReferrer(ctx context.Context, address, reason string) error {
+	if err := s.storage.InTx(ctx, func(st storage.Storage) error {
+		if err := st.SetReferralBanned(ctx, address, false); err != nil {
+			switch {
+			case errors.Is(err, storage.ErrNotFound):
+				return ErrRequestNotFound
+			case errors.Is(err, storage.ErrReferralBannedUnchanged):
+				return ErrReferrerNotBanned
+			default:
+				return fmt.Errorf("failed to set referral unbanned: %w", err)
+			}
+		}
+
+		if err := st.CreateReferralBanEvent(ctx, address, false, reason); err != nil {
+			return fmt.Errorf("failed to create unban event: %w", err)
+		}
+
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"address": address,
+		"reason":  reason,
+	}).Info("referrer unbanned")
+
+	return nil
+}
+
+func (s *service) GetReferralBanEvents(ctx context.Context, address string) ([]*storage.ReferralBanEvent, error) {
+	events, err := s.storage.GetReferralBanEvents(ctx, address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ban events: %w", err)
+	}
+
+	return events, nil
+}
+
 func (s *service) RegisterTestnetAccount(ctx context.Context, address string) error {
-	if err := s.bc.SendStakes([]blockchain.Stake{
+	if err := s.bc.SendStakes(ctx, []blockchain.Stake{
 		{
 			Address: address,
 			Amount:  giveStakesAmount,
@@ -386,6 +822,7 @@ func (s *service) RegisterTestnetAccount(ctx context.Context, address string) er
 	}, ""); err != nil {
 		return fmt.Errorf("failed to give stakes: %w", err)
 	}
+	metrics.AddPayout(metrics.PayoutTestnet, giveStakesAmount)
 
 	if err := s.storage.CreateTestnetConfirmedRequest(ctx, address); err != nil {
 		return fmt.Errorf("failed to create confirmed request: %w", err)
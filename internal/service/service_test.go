@@ -14,9 +14,13 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/TessorNetwork/vulcan/internal/auth"
 	"github.com/TessorNetwork/vulcan/internal/blockchain"
 	blockchainmock "github.com/TessorNetwork/vulcan/internal/blockchain/mock"
+	"github.com/TessorNetwork/vulcan/internal/events"
+	eventsmock "github.com/TessorNetwork/vulcan/internal/events/mock"
 	mailmock "github.com/TessorNetwork/vulcan/internal/mail/mock"
+	"github.com/TessorNetwork/vulcan/internal/referral"
 	"github.com/TessorNetwork/vulcan/internal/storage"
 	storagemock "github.com/TessorNetwork/vulcan/internal/storage/mock"
 )
@@ -29,6 +33,7 @@ var (
 	testCode    = "1234"
 
 	initialStakes = sdk.NewInt(100)
+	testNonces    = auth.NewNonces([]byte("secret"))
 )
 
 func TestService_Register(t *testing.T) {
@@ -171,6 +176,341 @@ func TestService_Register(t *testing.T) {
 	}
 }
 
+func TestService_BanReferrer(t *testing.T) {
+	tt := []struct {
+		name          string
+		cancelPending bool
+		mockSetupFunc func(s *storagemock.MockStorage)
+		cancelled     int
+		err           error
+	}{
+		{
+			name: "success",
+			mockSetupFunc: func(s *storagemock.MockStorage) {
+				s.EXPECT().InTx(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, f func(storage.Storage) error) error {
+					return f(s)
+				})
+				s.EXPECT().SetReferralBanned(gomock.Any(), testAddress, true).Return(nil)
+				s.EXPECT().CreateReferralBanEvent(gomock.Any(), testAddress, true, "fraud").Return(nil)
+			},
+		},
+		{
+			name:          "cancel pending",
+			cancelPending: true,
+			mockSetupFunc: func(s *storagemock.MockStorage) {
+				s.EXPECT().InTx(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, f func(storage.Storage) error) error {
+					return f(s)
+				})
+				s.EXPECT().SetReferralBanned(gomock.Any(), testAddress, true).Return(nil)
+				s.EXPECT().CreateReferralBanEvent(gomock.Any(), testAddress, true, "fraud").Return(nil)
+				s.EXPECT().CancelPendingReferralTracking(gomock.Any(), testAddress).Return(3, nil)
+			},
+			cancelled: 3,
+		},
+		{
+			name: "not found",
+			mockSetupFunc: func(s *storagemock.MockStorage) {
+				s.EXPECT().InTx(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, f func(storage.Storage) error) error {
+					return f(s)
+				})
+				s.EXPECT().SetReferralBanned(gomock.Any(), testAddress, true).Return(storage.ErrNotFound)
+			},
+			err: ErrRequestNotFound,
+		},
+		{
+			name: "already banned",
+			mockSetupFunc: func(s *storagemock.MockStorage) {
+				s.EXPECT().InTx(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, f func(storage.Storage) error) error {
+					return f(s)
+				})
+				s.EXPECT().SetReferralBanned(gomock.Any(), testAddress, true).Return(storage.ErrReferralBannedUnchanged)
+			},
+			err: ErrReferrerAlreadyBanned,
+		},
+		{
+			name: "storage error",
+			mockSetupFunc: func(s *storagemock.MockStorage) {
+				s.EXPECT().InTx(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, f func(storage.Storage) error) error {
+					return f(s)
+				})
+				s.EXPECT().SetReferralBanned(gomock.Any(), testAddress, true).Return(errTest)
+			},
+			err: errTest,
+		},
+	}
+
+	for i := range tt {
+		tc := tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			st := storagemock.NewMockStorage(ctrl)
+			tc.mockSetupFunc(st)
+
+			s := &service{storage: st}
+
+			cancelled, err := s.BanReferrer(context.Background(), testAddress, "fraud", tc.cancelPending)
+			assert.True(t, errors.Is(err, tc.err))
+			assert.Equal(t, tc.cancelled, cancelled)
+		})
+	}
+}
+
+func TestService_UnbanReferrer(t *testing.T) {
+	tt := []struct {
+		name          string
+		mockSetupFunc func(s *storagemock.MockStorage)
+		err           error
+	}{
+		{
+			name: "success",
+			mockSetupFunc: func(s *storagemock.MockStorage) {
+				s.EXPECT().InTx(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, f func(storage.Storage) error) error {
+					return f(s)
+				})
+				s.EXPECT().SetReferralBanned(gomock.Any(), testAddress, false).Return(nil)
+				s.EXPECT().CreateReferralBanEvent(gomock.Any(), testAddress, false, "appeal").Return(nil)
+			},
+		},
+		{
+			name: "not banned",
+			mockSetupFunc: func(s *storagemock.MockStorage) {
+				s.EXPECT().InTx(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, f func(storage.Storage) error) error {
+					return f(s)
+				})
+				s.EXPECT().SetReferralBanned(gomock.Any(), testAddress, false).Return(storage.ErrReferralBannedUnchanged)
+			},
+			err: ErrReferrerNotBanned,
+		},
+	}
+
+	for i := range tt {
+		tc := tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			st := storagemock.NewMockStorage(ctrl)
+			tc.mockSetupFunc(st)
+
+			s := &service{storage: st}
+
+			assert.True(t, errors.Is(s.UnbanReferrer(context.Background(), testAddress, "appeal"), tc.err))
+		})
+	}
+}
+
+func TestService_ClaimReferralCode(t *testing.T) {
+	confirmed := &storage.Request{
+		Owner:           testOwner,
+		Address:         testAddress,
+		ConfirmedAt:     sql.NullTime{Valid: true, Time: time.Now()},
+		OwnReferralCode: "abcdef12",
+	}
+
+	tt := []struct {
+		name          string
+		code          string
+		mockSetupFunc func(s *storagemock.MockStorage)
+		result        string
+		err           error
+	}{
+		{
+			name: "success",
+			code: " Alice",
+			mockSetupFunc: func(s *storagemock.MockStorage) {
+				s.EXPECT().GetRequestByAddress(gomock.Any(), testAddress).Return(confirmed, nil)
+				s.EXPECT().InTx(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, f func(storage.Storage) error) error {
+					return f(s)
+				})
+				s.EXPECT().CreateReferralCodeAlias(gomock.Any(), testOwner, "abcdef12").Return(nil)
+				s.EXPECT().CreateReferralCodeAlias(gomock.Any(), testOwner, "alice").Return(nil)
+				s.EXPECT().SetOwnReferralCode(gomock.Any(), testOwner, "alice").Return(nil)
+			},
+			result: "alice",
+		},
+		{
+			name:          "invalid",
+			code:          "admin",
+			mockSetupFunc: func(s *storagemock.MockStorage) {},
+			err:           referral.ErrInvalidCode,
+		},
+		{
+			name: "not confirmed",
+			code: "alice",
+			mockSetupFunc: func(s *storagemock.MockStorage) {
+				s.EXPECT().GetRequestByAddress(gomock.Any(), testAddress).Return(&storage.Request{Address: testAddress}, nil)
+			},
+			err: ErrNotConfirmed,
+		},
+		{
+			name: "taken",
+			code: "alice",
+			mockSetupFunc: func(s *storagemock.MockStorage) {
+				s.EXPECT().GetRequestByAddress(gomock.Any(), testAddress).Return(confirmed, nil)
+				s.EXPECT().InTx(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, f func(storage.Storage) error) error {
+					return f(s)
+				})
+				s.EXPECT().CreateReferralCodeAlias(gomock.Any(), testOwner, "abcdef12").Return(nil)
+				s.EXPECT().CreateReferralCodeAlias(gomock.Any(), testOwner, "alice").Return(storage.ErrReferralCodeIsTaken)
+			},
+			err: ErrReferralCodeIsTaken,
+		},
+	}
+
+	for i := range tt {
+		tc := tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			st := storagemock.NewMockStorage(ctrl)
+			tc.mockSetupFunc(st)
+
+			s := &service{storage: st}
+
+			code, err := s.ClaimReferralCode(context.Background(), testAddress, tc.code)
+			assert.True(t, errors.Is(err, tc.err))
+			assert.Equal(t, tc.result, code)
+		})
+	}
+}
+
+func TestService_TrackReferralBrowserInstallation(t *testing.T) {
+	registered := &storage.ReferralTracking{Sender: "sender", Receiver: testAddress, Status: storage.RegisteredReferralStatus}
+
+	valid, expiresAt, err := testNonces.Issue(testAddress, installationNoncePurpose, time.Minute)
+	require.NoError(t, err)
+	login, _, err := testNonces.Issue(testAddress, loginNoncePurpose, time.Minute)
+	require.NoError(t, err)
+
+	tt := []struct {
+		name          string
+		nonce         string
+		mockSetupFunc func(s *storagemock.MockStorage, p *eventsmock.MockPublisher)
+		err           error
+	}{
+		{
+			name:  "success",
+			nonce: valid,
+			mockSetupFunc: func(s *storagemock.MockStorage, p *eventsmock.MockPublisher) {
+				s.EXPECT().GetReferralTrackingByReceiver(gomock.Any(), testAddress).Return(registered, nil)
+				s.EXPECT().ConsumeNonce(gomock.Any(), testAddress, installationNoncePurpose, valid, expiresAt).Return(nil)
+				s.EXPECT().TransitionReferralTrackingToInstalled(gomock.Any(), testAddress).Return(nil)
+				p.EXPECT().Publish(gomock.Any(), events.ReferralInstalledEvent{Sender: "sender", Receiver: testAddress})
+			},
+		},
+		{
+			name:  "used nonce",
+			nonce: valid,
+			mockSetupFunc: func(s *storagemock.MockStorage, p *eventsmock.MockPublisher) {
+				s.EXPECT().GetReferralTrackingByReceiver(gomock.Any(), testAddress).Return(registered, nil)
+				s.EXPECT().ConsumeNonce(gomock.Any(), testAddress, installationNoncePurpose, valid, expiresAt).
+					Return(storage.ErrNonceUsed)
+			},
+			err: ErrInvalidNonce,
+		},
+		{
+			name:  "nonce of another purpose",
+			nonce: login,
+			mockSetupFunc: func(s *storagemock.MockStorage, p *eventsmock.MockPublisher) {
+				s.EXPECT().GetReferralTrackingByReceiver(gomock.Any(), testAddress).Return(registered, nil)
+			},
+			err: ErrInvalidNonce,
+		},
+		{
+			name:  "already installed",
+			nonce: valid,
+			mockSetupFunc: func(s *storagemock.MockStorage, p *eventsmock.MockPublisher) {
+				s.EXPECT().GetReferralTrackingByReceiver(gomock.Any(), testAddress).
+					Return(&storage.ReferralTracking{Status: storage.InstalledReferralStatus}, nil)
+			},
+			err: ErrReferralTrackingInvalidStatus,
+		},
+	}
+
+	for i := range tt {
+		tc := tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			st := storagemock.NewMockStorage(ctrl)
+			pub := eventsmock.NewMockPublisher(ctrl)
+			tc.mockSetupFunc(st, pub)
+
+			s := &service{storage: st, events: pub, nonces: testNonces}
+
+			assert.True(t, errors.Is(s.TrackReferralBrowserInstallation(context.Background(), testAddress, tc.nonce), tc.err))
+		})
+	}
+}
+
+func TestService_GetInstallationNonce(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	st := storagemock.NewMockStorage(ctrl)
+	st.EXPECT().GetReferralTrackingByReceiver(gomock.Any(), testAddress).
+		Return(&storage.ReferralTracking{Status: storage.RegisteredReferralStatus}, nil)
+
+	s := &service{storage: st, nonces: testNonces}
+
+	nonce, expiresAt, err := s.GetInstallationNonce(context.Background(), testAddress)
+	require.NoError(t, err)
+	assert.True(t, expiresAt.After(time.Now()))
+
+	exp, err := testNonces.Verify(testAddress, installationNoncePurpose, nonce)
+	require.NoError(t, err)
+	assert.Equal(t, expiresAt, exp)
+}
+
+func TestService_GetLoginNonce(t *testing.T) {
+	s := &service{nonces: testNonces}
+
+	// nonces aren't stored, so they can be issued without limits
+	first, _, err := s.GetLoginNonce(context.Background(), testAddress)
+	require.NoError(t, err)
+	second, _, err := s.GetLoginNonce(context.Background(), testAddress)
+	require.NoError(t, err)
+	require.NotEqual(t, first, second)
+
+	_, err = testNonces.Verify(testAddress, loginNoncePurpose, first)
+	require.NoError(t, err)
+	_, err = testNonces.Verify(testAddress, loginNoncePurpose, second)
+	require.NoError(t, err)
+}
+
+func TestService_ConsumeLoginNonce(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	valid, expiresAt, err := testNonces.Issue(testAddress, loginNoncePurpose, time.Minute)
+	require.NoError(t, err)
+	expired, _, err := testNonces.Issue(testAddress, loginNoncePurpose, -time.Minute)
+	require.NoError(t, err)
+
+	st := storagemock.NewMockStorage(ctrl)
+	st.EXPECT().ConsumeNonce(gomock.Any(), testAddress, loginNoncePurpose, valid, expiresAt).Return(nil)
+	st.EXPECT().ConsumeNonce(gomock.Any(), testAddress, loginNoncePurpose, valid, expiresAt).Return(storage.ErrNonceUsed)
+
+	s := &service{storage: st, nonces: testNonces}
+
+	require.NoError(t, s.ConsumeLoginNonce(context.Background(), testAddress, valid))
+	assert.True(t, errors.Is(s.ConsumeLoginNonce(context.Background(), testAddress, valid), ErrInvalidNonce))
+	assert.True(t, errors.Is(s.ConsumeLoginNonce(context.Background(), testAddress, expired), ErrInvalidNonce))
+	assert.True(t, errors.Is(s.ConsumeLoginNonce(context.Background(), "furya1another", valid), ErrInvalidNonce))
+}
+
 func TestService_GetReferralCode(t *testing.T) {
 	tt := []struct {
 		name   string
@@ -229,7 +569,7 @@ func TestService_Confirm(t *testing.T) {
 					Code:    testCode,
 				}, nil)
 
-				bc.EXPECT().SendStakes([]blockchain.Stake{
+				bc.EXPECT().SendStakes(gomock.Any(), []blockchain.Stake{
 					{Address: testAddress, Amount: initialStakes},
 				}, "").Return(nil)
 				m.EXPECT().SendWelcomeEmailAsync(gomock.Any(), testEmail)
@@ -271,7 +611,7 @@ func TestService_Confirm(t *testing.T) {
 					Address: testAddress,
 					Code:    testCode,
 				}, nil)
-				bc.EXPECT().SendStakes([]blockchain.Stake{
+				bc.EXPECT().SendStakes(gomock.Any(), []blockchain.Stake{
 					{Address: testAddress, Amount: initialStakes},
 				}, "").Return(errTest)
 			},
@@ -286,7 +626,7 @@ func TestService_Confirm(t *testing.T) {
 					Address: testAddress,
 					Code:    testCode,
 				}, nil)
-				bc.EXPECT().SendStakes([]blockchain.Stake{
+				bc.EXPECT().SendStakes(gomock.Any(), []blockchain.Stake{
 					{Address: testAddress, Amount: initialStakes},
 				}, "").Return(nil)
 				m.EXPECT().SendWelcomeEmailAsync(gomock.Any(), testEmail)
@@ -332,7 +672,7 @@ func TestService_RegisterTestnetAccount(t *testing.T) {
 		{
 			name: "success",
 			mockSetupFunc: func(bc *blockchainmock.MockBlockchain, storage *storagemock.MockStorage) {
-				bc.EXPECT().SendStakes([]blockchain.Stake{
+				bc.EXPECT().SendStakes(gomock.Any(), []blockchain.Stake{
 					{Address: testAddress, Amount: giveStakesAmount},
 				}, "").Return(nil)
 
@@ -342,7 +682,7 @@ func TestService_RegisterTestnetAccount(t *testing.T) {
 		{
 			name: "error",
 			mockSetupFunc: func(bc *blockchainmock.MockBlockchain, storage *storagemock.MockStorage) {
-				bc.EXPECT().SendStakes([]blockchain.Stake{
+				bc.EXPECT().SendStakes(gomock.Any(), []blockchain.Stake{
 					{Address: testAddress, Amount: giveStakesAmount},
 				}, "").Return(errTest)
 			},
@@ -414,3 +754,10 @@ func Test_truncatePlusPart(t *testing.T) {
 	assert.Equal(t, "email@email.com", truncatePlusPart("email+acc1@email.com"))
 	assert.Equal(t, "email@email.com", truncatePlusPart("email@email.com"))
 }
+
+func Test_outcome(t *testing.T) {
+	assert.Equal(t, "success", outcome(nil))
+	assert.Equal(t, "already_exists", outcome(fmt.Errorf("%w: address is already taken", ErrAlreadyExists)))
+	assert.Equal(t, "referral_code_banned", outcome(ErrReferralCodeBanned))
+	assert.Equal(t, "error", outcome(errors.New("node is down")))
+}
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/TessorNetwork/vulcan/internal/referral"
+	"github.com/TessorNetwork/vulcan/internal/storage"
+	"github.com/TessorNetwork/vulcan/internal/tracing"
+)
+
+type tracedService struct {
+	s Service
+}
+
+// WithTracing returns the Service starting a span "service.<Method>" for every call of s.
+func WithTracing(s Service) Service {
+	return tracedService{s: s}
+}
+
+func (t tracedService) Register(ctx context.Context, email, address string, referralCode *string) (err error) {
+	ctx, span := tracing.Start(ctx, "service.Register")
+	defer func() { tracing.End(span, err) }()
+
+	return t.s.Register(ctx, email, address, referralCode)
+}
+
+func (t tracedService) Confirm(ctx context.Context, owner, code string) (err error) {
+	ctx, span := tracing.Start(ctx, "service.Confirm")
+	defer func() { tracing.End(span, err) }()
+
+	return t.s.Confirm(ctx, owner, code)
+}
+
+func (t tracedService) GetRegisterStats(ctx context.Context) (_ []*storage.RegisterStats, _ int, err error) {
+	ctx, span := tracing.Start(ctx, "service.GetRegisterStats")
+	defer func() { tracing.End(span, err) }()
+
+	return t.s.GetRegisterStats(ctx)
+}
+
+func (t tracedService) GetRegistrationFunnel(ctx context.Context, from, to time.Time,
+	granularity storage.Granularity) (_ []*storage.RegistrationFunnelItem, err error) {
+	ctx, span := tracing.Start(ctx, "service.GetRegistrationFunnel")
+	defer func() { tracing.End(span, err) }()
+
+	return t.s.GetRegistrationFunnel(ctx, from, to, granularity)
+}
+
+func (t tracedService) GetOwnReferralCode(ctx context.Context, address string) (_ string, err error) {
+	ctx, span := tracing.Start(ctx, "service.GetOwnReferralCode")
+	defer func() { tracing.End(span, err) }()
+
+	return t.s.GetOwnReferralCode(ctx, address)
+}
+
+func (t tracedService) ClaimReferralCode(ctx context.Context, address, code string) (_ string, err error) {
+	ctx, span := tracing.Start(ctx, "service.ClaimReferralCode")
+	defer func() { tracing.End(span, err) }()
+
+	return t.s.ClaimReferralCode(ctx, address, code)
+}
+
+func (t tracedService) GetReferralConfig() referral.Config {
+	return t.s.GetReferralConfig()
+}
+
+func (t tracedService) GetRegistrationReferralCode(ctx context.Context, address string) (_ string, err error) {
+	ctx, span := tracing.Start(ctx, "service.GetRegistrationReferralCode")
+	defer func() { tracing.End(span, err) }()
+
+	return t.s.GetRegistrationReferralCode(ctx, address)
+}
+
+func (t tracedService) GetLoginNonce(ctx context.Context, address string) (_ string, _ time.Time, err error) {
+	ctx, span := tracing.Start(ctx, "service.GetLoginNonce")
+	defer func() { tracing.End(span, err) }()
+
+	return t.s.GetLoginNonce(ctx, address)
+}
+
+func (t tracedService) ConsumeLoginNonce(ctx context.Context, address, nonce string) (err error) {
+	ctx, span := tracing.Start(ctx, "service.ConsumeLoginNonce")
+	defer func() { tracing.End(span, err) }()
+
+	return t.s.ConsumeLoginNonce(ctx, address, nonce)
+}
+
+func (t tracedService) GetInstallationNonce(ctx context.Context, address string) (_ string, _ time.Time, err error) {
+	ctx, span := tracing.Start(ctx, "service.GetInstallationNonce")
+	defer func() { tracing.End(span, err) }()
+
+	return t.s.GetInstallationNonce(ctx, address)
+}
+
+func (t tracedService) TrackReferralBrowserInstallation(ctx context.Context, address, nonce string) (err error) {
+	ctx, span := tracing.Start(ctx, "service.TrackReferralBrowserInstallation")
+	defer func() { tracing.End(span, err) }()
+
+	return t.s.TrackReferralBrowserInstallation(ctx, address, nonce)
+}
+
+func (t tracedService) GetReferralTrackingStats(ctx context.Context,
+	address string) (_ []*storage.ReferralTrackingStats, err error) {
+	ctx, span := tracing.Start(ctx, "service.GetReferralTrackingStats")
+	defer func() { tracing.End(span, err) }()
+
+	return t.s.GetReferralTrackingStats(ctx, address)
+}
+
+func (t tracedService) ListReferralTracking(ctx context.Context, address string,
+	take, skip int) (_ []*storage.ReferralTracking, err error) {
+	ctx, span := tracing.Start(ctx, "service.ListReferralTracking")
+	defer func() { tracing.End(span, err) }()
+
+	return t.s.ListReferralTracking(ctx, address, take, skip)
+}
+
+func (t tracedService) GetReferralLeaderboard(ctx context.Context, window storage.LeaderboardWindow,
+	limit int) (_ []*storage.ReferralLeaderboardItem, err error) {
+	ctx, span := tracing.Start(ctx, "service.GetReferralLeaderboard")
+	defer func() { tracing.End(span, err) }()
+
+	return t.s.GetReferralLeaderboard(ctx, window, limit)
+}
+
+func (t tracedService) CreateDLoanRequest(ctx context.Context, address, firstName, lastName string,
+	pdv float64) (err error) {
+	ctx, span := tracing.Start(ctx, "service.CreateDLoanRequest")
+	defer func() { tracing.End(span, err) }()
+
+	return t.s.CreateDLoanRequest(ctx, address, firstName, lastName, pdv)
+}
+
+func (t tracedService) ListDloanRequests(ctx context.Context, take, skip int) (_ []*storage.DLoan, err error) {
+	ctx, span := tracing.Start(ctx, "service.ListDloanRequests")
+	defer func() { tracing.End(span, err) }()
+
+	return t.s.ListDloanRequests(ctx, take, skip)
+}
+
+func (t tracedService) CreateReferralCampaign(ctx context.Context, c storage.ReferralCampaign) (_ int, err error) {
+	ctx, span := tracing.Start(ctx, "service.CreateReferralCampaign")
+	defer func() { tracing.End(span, err) }()
+
+	return t.s.CreateReferralCampaign(ctx, c)
+}
+
+func (t tracedService) ListReferralCampaigns(ctx context.Context) (_ []*storage.ReferralCampaign, err error) {
+	ctx, span := tracing.Start(ctx, "service.ListReferralCampaigns")
+	defer func() { tracing.End(span, err) }()
+
+	return t.s.ListReferralCampaigns(ctx)
+}
+
+func (t tracedService) BanReferrer(ctx context.Context, address, reason string, cancelPending bool) (_ int, err error) {
+	ctx, span := tracing.Start(ctx, "service.BanReferrer")
+	defer func() { tracing.End(span, err) }()
+
+	return t.s.BanReferrer(ctx, address, reason, cancelPending)
+}
+
+func (t tracedService) UnbanReferrer(ctx context.Context, address, reason string) (err error) {
+	ctx, span := tracing.Start(ctx, "service.UnbanReferrer")
+	defer func() { tracing.End(span, err) }()
+
+	return t.s.UnbanReferrer(ctx, address, reason)
+}
+
+func (t tracedService) GetReferralBanEvents(ctx context.Context, address string) (_ []*storage.ReferralBanEvent, err error) {
+	ctx, span := tracing.Start(ctx, "service.GetReferralBanEvents")
+	defer func() { tracing.End(span, err) }()
+
+	return t.s.GetReferralBanEvents(ctx, address)
+}
+
+func (t tracedService) CreateWebhookSubscription(ctx context.Context, s storage.WebhookSubscription) (_ int, err error) {
+	ctx, span := tracing.Start(ctx, "service.CreateWebhookSubscription")
+	defer func() { tracing.End(span, err) }()
+
+	return t.s.CreateWebhookSubscription(ctx, s)
+}
+
+func (t tracedService) ListWebhookSubscriptions(ctx context.Context) (_ []*storage.WebhookSubscription, err error) {
+	ctx, span := tracing.Start(ctx, "service.ListWebhookSubscriptions")
+	defer func() { tracing.End(span, err) }()
+
+	return t.s.ListWebhookSubscriptions(ctx)
+}
+
+func (t tracedService) DeleteWebhookSubscription(ctx context.Context, id int) (err error) {
+	ctx, span := tracing.Start(ctx, "service.DeleteWebhookSubscription")
+	defer func() { tracing.End(span, err) }()
+
+	return t.s.DeleteWebhookSubscription(ctx, id)
+}
+
+func (t tracedService) ListWebhookDeliveries(ctx context.Context, status storage.WebhookDeliveryStatus,
+	take, skip int) (_ []*storage.WebhookDelivery, err error) {
+	ctx, span := tracing.Start(ctx, "service.ListWebhookDeliveries")
+	defer func() { tracing.End(span, err) }()
+
+	return t.s.ListWebhookDeliveries(ctx, status, take, skip)
+}
+
+func (t tracedService) ReplayWebhookDelivery(ctx context.Context, id int) (err error) {
+	ctx, span := tracing.Start(ctx, "service.ReplayWebhookDelivery")
+	defer func() { tracing.End(span, err) }()
+
+	return t.s.ReplayWebhookDelivery(ctx, id)
+}
+
+func (t tracedService) RegisterTestnetAccount(ctx context.Context, address string) (err error) {
+	ctx, span := tracing.Start(ctx, "service.RegisterTestnetAccount")
+	defer func() { tracing.End(span, err) }()
+
+	return t.s.RegisterTestnetAccount(ctx, address)
+}
+
+func (t tracedService) CheckRecaptcha(ctx context.Context, action, recaptchaResponse string) (err error) {
+	ctx, span := tracing.Start(ctx, "service.CheckRecaptcha")
+	defer func() { tracing.End(span, err) }()
+
+	return t.s.CheckRecaptcha(ctx, action, recaptchaResponse)
+}
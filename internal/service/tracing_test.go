@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/TessorNetwork/vulcan/internal/referral"
+	"github.com/TessorNetwork/vulcan/internal/storage"
+	storagemock "github.com/TessorNetwork/vulcan/internal/storage/mock"
+	"github.com/TessorNetwork/vulcan/internal/tracing"
+)
+
+func TestWithTracing(t *testing.T) {
+	exporter, restore := tracing.NewInMemoryExporter()
+	defer restore()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	st := storagemock.NewMockStorage(ctrl)
+	s := WithTracing(New(st, nil, nil, nil, initialStakes, "", referral.Config{}, "", nil))
+
+	ctx, parent := tracing.Start(context.Background(), "parent")
+
+	st.EXPECT().GetRequestByAddress(gomock.Any(), testAddress).DoAndReturn(
+		func(ctx context.Context, _ string) (*storage.Request, error) {
+			// the storage gets the context of the service span
+			require.True(t, trace.SpanContextFromContext(ctx).IsValid())
+			require.NotEqual(t, parent.SpanContext().SpanID(), trace.SpanContextFromContext(ctx).SpanID())
+			return nil, storage.ErrNotFound
+		})
+
+	_, err := s.GetOwnReferralCode(ctx, testAddress)
+	require.True(t, errors.Is(err, ErrRequestNotFound))
+
+	parent.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	require.Equal(t, "service.GetOwnReferralCode", spans[0].Name)
+	require.Equal(t, parent.SpanContext().SpanID(), spans[0].Parent.SpanID())
+	require.Equal(t, codes.Error, spans[0].Status.Code)
+}
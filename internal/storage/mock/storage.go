@@ -11,6 +11,7 @@ import (
 	types "github.com/cosmos/cosmos-sdk/types"
 	gomock "github.com/golang/mock/gomock"
 	reflect "reflect"
+	time "time"
 )
 
 // MockStorage is a mock of Storage interface
@@ -80,6 +81,21 @@ func (mr *MockStorageMockRecorder) GetConfirmedRegistrationsStats(ctx interface{
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetConfirmedRegistrationsStats", reflect.TypeOf((*MockStorage)(nil).GetConfirmedRegistrationsStats), ctx)
 }
 
+// GetRegistrationFunnel mocks base method
+func (m *MockStorage) GetRegistrationFunnel(ctx context.Context, from, to time.Time, granularity storage.Granularity) ([]*storage.RegistrationFunnelItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRegistrationFunnel", ctx, from, to, granularity)
+	ret0, _ := ret[0].([]*storage.RegistrationFunnelItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRegistrationFunnel indicates an expected call of GetRegistrationFunnel
+func (mr *MockStorageMockRecorder) GetRegistrationFunnel(ctx, from, to, granularity interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRegistrationFunnel", reflect.TypeOf((*MockStorage)(nil).GetRegistrationFunnel), ctx, from, to, granularity)
+}
+
 // GetRequestByOwner mocks base method
 func (m *MockStorage) GetRequestByOwner(ctx context.Context, owner string) (*storage.Request, error) {
 	m.ctrl.T.Helper()
@@ -110,6 +126,34 @@ func (mr *MockStorageMockRecorder) GetRequestByOwnReferralCode(ctx, ownReferralC
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRequestByOwnReferralCode", reflect.TypeOf((*MockStorage)(nil).GetRequestByOwnReferralCode), ctx, ownReferralCode)
 }
 
+// CreateReferralCodeAlias mocks base method
+func (m *MockStorage) CreateReferralCodeAlias(ctx context.Context, owner, code string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateReferralCodeAlias", ctx, owner, code)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateReferralCodeAlias indicates an expected call of CreateReferralCodeAlias
+func (mr *MockStorageMockRecorder) CreateReferralCodeAlias(ctx, owner, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateReferralCodeAlias", reflect.TypeOf((*MockStorage)(nil).CreateReferralCodeAlias), ctx, owner, code)
+}
+
+// SetOwnReferralCode mocks base method
+func (m *MockStorage) SetOwnReferralCode(ctx context.Context, owner, code string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetOwnReferralCode", ctx, owner, code)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetOwnReferralCode indicates an expected call of SetOwnReferralCode
+func (mr *MockStorageMockRecorder) SetOwnReferralCode(ctx, owner, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetOwnReferralCode", reflect.TypeOf((*MockStorage)(nil).SetOwnReferralCode), ctx, owner, code)
+}
+
 // GetRequestByAddress mocks base method
 func (m *MockStorage) GetRequestByAddress(ctx context.Context, address string) (*storage.Request, error) {
 	m.ctrl.T.Helper()
@@ -196,17 +240,31 @@ func (mr *MockStorageMockRecorder) TransitionReferralTrackingToInstalled(ctx, re
 }
 
 // TransitionReferralTrackingToConfirmed mocks base method
-func (m *MockStorage) TransitionReferralTrackingToConfirmed(ctx context.Context, receiver string, senderReward, receiverReward types.Int) error {
+func (m *MockStorage) TransitionReferralTrackingToConfirmed(ctx context.Context, receiver string, senderReward, receiverReward types.Int, campaignID sql.NullInt64) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "TransitionReferralTrackingToConfirmed", ctx, receiver, senderReward, receiverReward)
+	ret := m.ctrl.Call(m, "TransitionReferralTrackingToConfirmed", ctx, receiver, senderReward, receiverReward, campaignID)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // TransitionReferralTrackingToConfirmed indicates an expected call of TransitionReferralTrackingToConfirmed
-func (mr *MockStorageMockRecorder) TransitionReferralTrackingToConfirmed(ctx, receiver, senderReward, receiverReward interface{}) *gomock.Call {
+func (mr *MockStorageMockRecorder) TransitionReferralTrackingToConfirmed(ctx, receiver, senderReward, receiverReward, campaignID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransitionReferralTrackingToConfirmed", reflect.TypeOf((*MockStorage)(nil).TransitionReferralTrackingToConfirmed), ctx, receiver, senderReward, receiverReward, campaignID)
+}
+
+// CreateReferralUpperLevelReward mocks base method
+func (m *MockStorage) CreateReferralUpperLevelReward(ctx context.Context, receiver string, level int, sender string, reward types.Int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateReferralUpperLevelReward", ctx, receiver, level, sender, reward)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateReferralUpperLevelReward indicates an expected call of CreateReferralUpperLevelReward
+func (mr *MockStorageMockRecorder) CreateReferralUpperLevelReward(ctx, receiver, level, sender, reward interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransitionReferralTrackingToConfirmed", reflect.TypeOf((*MockStorage)(nil).TransitionReferralTrackingToConfirmed), ctx, receiver, senderReward, receiverReward)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateReferralUpperLevelReward", reflect.TypeOf((*MockStorage)(nil).CreateReferralUpperLevelReward), ctx, receiver, level, sender, reward)
 }
 
 // GetReferralTrackingByReceiver mocks base method
@@ -224,6 +282,35 @@ func (mr *MockStorageMockRecorder) GetReferralTrackingByReceiver(ctx, receiver i
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReferralTrackingByReceiver", reflect.TypeOf((*MockStorage)(nil).GetReferralTrackingByReceiver), ctx, receiver)
 }
 
+// ConsumeNonce mocks base method
+func (m *MockStorage) ConsumeNonce(ctx context.Context, address, purpose, nonce string, expiresAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeNonce", ctx, address, purpose, nonce, expiresAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ConsumeNonce indicates an expected call of ConsumeNonce
+func (mr *MockStorageMockRecorder) ConsumeNonce(ctx, address, purpose, nonce, expiresAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeNonce", reflect.TypeOf((*MockStorage)(nil).ConsumeNonce), ctx, address, purpose, nonce, expiresAt)
+}
+
+// GetReferralTrackingBySender mocks base method
+func (m *MockStorage) GetReferralTrackingBySender(ctx context.Context, sender string, take, skip int) ([]*storage.ReferralTracking, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReferralTrackingBySender", ctx, sender, take, skip)
+	ret0, _ := ret[0].([]*storage.ReferralTracking)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReferralTrackingBySender indicates an expected call of GetReferralTrackingBySender
+func (mr *MockStorageMockRecorder) GetReferralTrackingBySender(ctx, sender, take, skip interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReferralTrackingBySender", reflect.TypeOf((*MockStorage)(nil).GetReferralTrackingBySender), ctx, sender, take, skip)
+}
+
 // GetReferralTrackingStats mocks base method
 func (m *MockStorage) GetReferralTrackingStats(ctx context.Context, sender string) ([]*storage.ReferralTrackingStats, error) {
 	m.ctrl.T.Helper()
@@ -239,6 +326,80 @@ func (mr *MockStorageMockRecorder) GetReferralTrackingStats(ctx, sender interfac
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReferralTrackingStats", reflect.TypeOf((*MockStorage)(nil).GetReferralTrackingStats), ctx, sender)
 }
 
+// CreateReferralCampaign mocks base method
+func (m *MockStorage) CreateReferralCampaign(ctx context.Context, c storage.ReferralCampaign) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateReferralCampaign", ctx, c)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateReferralCampaign indicates an expected call of CreateReferralCampaign
+func (mr *MockStorageMockRecorder) CreateReferralCampaign(ctx, c interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateReferralCampaign", reflect.TypeOf((*MockStorage)(nil).CreateReferralCampaign), ctx, c)
+}
+
+// GetReferralCampaigns mocks base method
+func (m *MockStorage) GetReferralCampaigns(ctx context.Context) ([]*storage.ReferralCampaign, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReferralCampaigns", ctx)
+	ret0, _ := ret[0].([]*storage.ReferralCampaign)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReferralCampaigns indicates an expected call of GetReferralCampaigns
+func (mr *MockStorageMockRecorder) GetReferralCampaigns(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReferralCampaigns", reflect.TypeOf((*MockStorage)(nil).GetReferralCampaigns), ctx)
+}
+
+// GetReferralCampaignsActiveBetween mocks base method
+func (m *MockStorage) GetReferralCampaignsActiveBetween(ctx context.Context, from, to time.Time) ([]*storage.ReferralCampaign, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReferralCampaignsActiveBetween", ctx, from, to)
+	ret0, _ := ret[0].([]*storage.ReferralCampaign)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReferralCampaignsActiveBetween indicates an expected call of GetReferralCampaignsActiveBetween
+func (mr *MockStorageMockRecorder) GetReferralCampaignsActiveBetween(ctx, from, to interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReferralCampaignsActiveBetween", reflect.TypeOf((*MockStorage)(nil).GetReferralCampaignsActiveBetween), ctx, from, to)
+}
+
+// GetReferralLeaderboard mocks base method
+func (m *MockStorage) GetReferralLeaderboard(ctx context.Context, window storage.LeaderboardWindow, limit int) ([]*storage.ReferralLeaderboardItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReferralLeaderboard", ctx, window, limit)
+	ret0, _ := ret[0].([]*storage.ReferralLeaderboardItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReferralLeaderboard indicates an expected call of GetReferralLeaderboard
+func (mr *MockStorageMockRecorder) GetReferralLeaderboard(ctx, window, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReferralLeaderboard", reflect.TypeOf((*MockStorage)(nil).GetReferralLeaderboard), ctx, window, limit)
+}
+
+// RefreshReferralLeaderboard mocks base method
+func (m *MockStorage) RefreshReferralLeaderboard(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RefreshReferralLeaderboard", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RefreshReferralLeaderboard indicates an expected call of RefreshReferralLeaderboard
+func (mr *MockStorageMockRecorder) RefreshReferralLeaderboard(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefreshReferralLeaderboard", reflect.TypeOf((*MockStorage)(nil).RefreshReferralLeaderboard), ctx)
+}
+
 // GetUnconfirmedReferralTracking mocks base method
 func (m *MockStorage) GetUnconfirmedReferralTracking(ctx context.Context, days int) ([]*storage.ReferralTracking, error) {
 	m.ctrl.T.Helper()
@@ -312,3 +473,365 @@ func (mr *MockStorageMockRecorder) GetDLoans(ctx, take, skip interface{}) *gomoc
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDLoans", reflect.TypeOf((*MockStorage)(nil).GetDLoans), ctx, take, skip)
 }
+
+// SetReferralBanned mocks base method
+func (m *MockStorage) SetReferralBanned(ctx context.Context, address string, banned bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetReferralBanned", ctx, address, banned)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetReferralBanned indicates an expected call of SetReferralBanned
+func (mr *MockStorageMockRecorder) SetReferralBanned(ctx, address, banned interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetReferralBanned", reflect.TypeOf((*MockStorage)(nil).SetReferralBanned), ctx, address, banned)
+}
+
+// CreateReferralBanEvent mocks base method
+func (m *MockStorage) CreateReferralBanEvent(ctx context.Context, address string, banned bool, reason string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateReferralBanEvent", ctx, address, banned, reason)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateReferralBanEvent indicates an expected call of CreateReferralBanEvent
+func (mr *MockStorageMockRecorder) CreateReferralBanEvent(ctx, address, banned, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateReferralBanEvent", reflect.TypeOf((*MockStorage)(nil).CreateReferralBanEvent), ctx, address, banned, reason)
+}
+
+// GetReferralBanEvents mocks base method
+func (m *MockStorage) GetReferralBanEvents(ctx context.Context, address string) ([]*storage.ReferralBanEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReferralBanEvents", ctx, address)
+	ret0, _ := ret[0].([]*storage.ReferralBanEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReferralBanEvents indicates an expected call of GetReferralBanEvents
+func (mr *MockStorageMockRecorder) GetReferralBanEvents(ctx, address interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReferralBanEvents", reflect.TypeOf((*MockStorage)(nil).GetReferralBanEvents), ctx, address)
+}
+
+// CreateSupplySnapshot mocks base method
+func (m *MockStorage) CreateSupplySnapshot(ctx context.Context, s storage.SupplySnapshot) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSupplySnapshot", ctx, s)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateSupplySnapshot indicates an expected call of CreateSupplySnapshot
+func (mr *MockStorageMockRecorder) CreateSupplySnapshot(ctx, s interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSupplySnapshot", reflect.TypeOf((*MockStorage)(nil).CreateSupplySnapshot), ctx, s)
+}
+
+// GetSupplyHistory mocks base method
+func (m *MockStorage) GetSupplyHistory(ctx context.Context, from, to time.Time, interval time.Duration) ([]*storage.SupplySnapshot, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSupplyHistory", ctx, from, to, interval)
+	ret0, _ := ret[0].([]*storage.SupplySnapshot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSupplyHistory indicates an expected call of GetSupplyHistory
+func (mr *MockStorageMockRecorder) GetSupplyHistory(ctx, from, to, interval interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSupplyHistory", reflect.TypeOf((*MockStorage)(nil).GetSupplyHistory), ctx, from, to, interval)
+}
+
+// CreateEvent mocks base method
+func (m *MockStorage) CreateEvent(ctx context.Context, e storage.Event) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateEvent", ctx, e)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateEvent indicates an expected call of CreateEvent
+func (mr *MockStorageMockRecorder) CreateEvent(ctx, e interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateEvent", reflect.TypeOf((*MockStorage)(nil).CreateEvent), ctx, e)
+}
+
+// CreateWebhookSubscription mocks base method
+func (m *MockStorage) CreateWebhookSubscription(ctx context.Context, s storage.WebhookSubscription) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhookSubscription", ctx, s)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWebhookSubscription indicates an expected call of CreateWebhookSubscription
+func (mr *MockStorageMockRecorder) CreateWebhookSubscription(ctx, s interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhookSubscription", reflect.TypeOf((*MockStorage)(nil).CreateWebhookSubscription), ctx, s)
+}
+
+// GetWebhookSubscriptions mocks base method
+func (m *MockStorage) GetWebhookSubscriptions(ctx context.Context) ([]*storage.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhookSubscriptions", ctx)
+	ret0, _ := ret[0].([]*storage.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhookSubscriptions indicates an expected call of GetWebhookSubscriptions
+func (mr *MockStorageMockRecorder) GetWebhookSubscriptions(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhookSubscriptions", reflect.TypeOf((*MockStorage)(nil).GetWebhookSubscriptions), ctx)
+}
+
+// DeleteWebhookSubscription mocks base method
+func (m *MockStorage) DeleteWebhookSubscription(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebhookSubscription", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWebhookSubscription indicates an expected call of DeleteWebhookSubscription
+func (mr *MockStorageMockRecorder) DeleteWebhookSubscription(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebhookSubscription", reflect.TypeOf((*MockStorage)(nil).DeleteWebhookSubscription), ctx, id)
+}
+
+// CreateWebhookDeliveries mocks base method
+func (m *MockStorage) CreateWebhookDeliveries(ctx context.Context, eventID, eventType, sender string, payload []byte) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhookDeliveries", ctx, eventID, eventType, sender, payload)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWebhookDeliveries indicates an expected call of CreateWebhookDeliveries
+func (mr *MockStorageMockRecorder) CreateWebhookDeliveries(ctx, eventID, eventType, sender, payload interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhookDeliveries", reflect.TypeOf((*MockStorage)(nil).CreateWebhookDeliveries), ctx, eventID, eventType, sender, payload)
+}
+
+// ClaimWebhookDeliveries mocks base method
+func (m *MockStorage) ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*storage.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimWebhookDeliveries", ctx, limit, lease)
+	ret0, _ := ret[0].([]*storage.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimWebhookDeliveries indicates an expected call of ClaimWebhookDeliveries
+func (mr *MockStorageMockRecorder) ClaimWebhookDeliveries(ctx, limit, lease interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimWebhookDeliveries", reflect.TypeOf((*MockStorage)(nil).ClaimWebhookDeliveries), ctx, limit, lease)
+}
+
+// SetWebhookDeliveryDelivered mocks base method
+func (m *MockStorage) SetWebhookDeliveryDelivered(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetWebhookDeliveryDelivered", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetWebhookDeliveryDelivered indicates an expected call of SetWebhookDeliveryDelivered
+func (mr *MockStorageMockRecorder) SetWebhookDeliveryDelivered(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetWebhookDeliveryDelivered", reflect.TypeOf((*MockStorage)(nil).SetWebhookDeliveryDelivered), ctx, id)
+}
+
+// SetWebhookDeliveryFailed mocks base method
+func (m *MockStorage) SetWebhookDeliveryFailed(ctx context.Context, id int, lastError string, nextAttemptAt sql.NullTime) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetWebhookDeliveryFailed", ctx, id, lastError, nextAttemptAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetWebhookDeliveryFailed indicates an expected call of SetWebhookDeliveryFailed
+func (mr *MockStorageMockRecorder) SetWebhookDeliveryFailed(ctx, id, lastError, nextAttemptAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetWebhookDeliveryFailed", reflect.TypeOf((*MockStorage)(nil).SetWebhookDeliveryFailed), ctx, id, lastError, nextAttemptAt)
+}
+
+// GetWebhookDeliveries mocks base method
+func (m *MockStorage) GetWebhookDeliveries(ctx context.Context, status storage.WebhookDeliveryStatus, take, skip int) ([]*storage.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhookDeliveries", ctx, status, take, skip)
+	ret0, _ := ret[0].([]*storage.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhookDeliveries indicates an expected call of GetWebhookDeliveries
+func (mr *MockStorageMockRecorder) GetWebhookDeliveries(ctx, status, take, skip interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhookDeliveries", reflect.TypeOf((*MockStorage)(nil).GetWebhookDeliveries), ctx, status, take, skip)
+}
+
+// ReplayWebhookDelivery mocks base method
+func (m *MockStorage) ReplayWebhookDelivery(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplayWebhookDelivery", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReplayWebhookDelivery indicates an expected call of ReplayWebhookDelivery
+func (mr *MockStorageMockRecorder) ReplayWebhookDelivery(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplayWebhookDelivery", reflect.TypeOf((*MockStorage)(nil).ReplayWebhookDelivery), ctx, id)
+}
+
+// GetDistributionRun mocks base method
+func (m *MockStorage) GetDistributionRun(ctx context.Context, name string) (*storage.DistributionRun, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDistributionRun", ctx, name)
+	ret0, _ := ret[0].(*storage.DistributionRun)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDistributionRun indicates an expected call of GetDistributionRun
+func (mr *MockStorageMockRecorder) GetDistributionRun(ctx, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDistributionRun", reflect.TypeOf((*MockStorage)(nil).GetDistributionRun), ctx, name)
+}
+
+// CreateDistributionRun mocks base method
+func (m *MockStorage) CreateDistributionRun(ctx context.Context, name string) (*storage.DistributionRun, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateDistributionRun", ctx, name)
+	ret0, _ := ret[0].(*storage.DistributionRun)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateDistributionRun indicates an expected call of CreateDistributionRun
+func (mr *MockStorageMockRecorder) CreateDistributionRun(ctx, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateDistributionRun", reflect.TypeOf((*MockStorage)(nil).CreateDistributionRun), ctx, name)
+}
+
+// SetDistributionRunFinished mocks base method
+func (m *MockStorage) SetDistributionRunFinished(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDistributionRunFinished", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDistributionRunFinished indicates an expected call of SetDistributionRunFinished
+func (mr *MockStorageMockRecorder) SetDistributionRunFinished(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDistributionRunFinished", reflect.TypeOf((*MockStorage)(nil).SetDistributionRunFinished), ctx, id)
+}
+
+// CreateDistributionItems mocks base method
+func (m *MockStorage) CreateDistributionItems(ctx context.Context, runID int, items []storage.DistributionItem) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateDistributionItems", ctx, runID, items)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateDistributionItems indicates an expected call of CreateDistributionItems
+func (mr *MockStorageMockRecorder) CreateDistributionItems(ctx, runID, items interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateDistributionItems", reflect.TypeOf((*MockStorage)(nil).CreateDistributionItems), ctx, runID, items)
+}
+
+// GetDistributionItems mocks base method
+func (m *MockStorage) GetDistributionItems(ctx context.Context, runID int) ([]*storage.DistributionItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDistributionItems", ctx, runID)
+	ret0, _ := ret[0].([]*storage.DistributionItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDistributionItems indicates an expected call of GetDistributionItems
+func (mr *MockStorageMockRecorder) GetDistributionItems(ctx, runID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDistributionItems", reflect.TypeOf((*MockStorage)(nil).GetDistributionItems), ctx, runID)
+}
+
+// SetDistributionItemsSending mocks base method
+func (m *MockStorage) SetDistributionItemsSending(ctx context.Context, ids []int, memo string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDistributionItemsSending", ctx, ids, memo)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDistributionItemsSending indicates an expected call of SetDistributionItemsSending
+func (mr *MockStorageMockRecorder) SetDistributionItemsSending(ctx, ids, memo interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDistributionItemsSending", reflect.TypeOf((*MockStorage)(nil).SetDistributionItemsSending), ctx, ids, memo)
+}
+
+// SetDistributionItemsSent mocks base method
+func (m *MockStorage) SetDistributionItemsSent(ctx context.Context, ids []int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDistributionItemsSent", ctx, ids)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDistributionItemsSent indicates an expected call of SetDistributionItemsSent
+func (mr *MockStorageMockRecorder) SetDistributionItemsSent(ctx, ids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDistributionItemsSent", reflect.TypeOf((*MockStorage)(nil).SetDistributionItemsSent), ctx, ids)
+}
+
+// SetDistributionItemsFailed mocks base method
+func (m *MockStorage) SetDistributionItemsFailed(ctx context.Context, ids []int, lastError string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDistributionItemsFailed", ctx, ids, lastError)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDistributionItemsFailed indicates an expected call of SetDistributionItemsFailed
+func (mr *MockStorageMockRecorder) SetDistributionItemsFailed(ctx, ids, lastError interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDistributionItemsFailed", reflect.TypeOf((*MockStorage)(nil).SetDistributionItemsFailed), ctx, ids, lastError)
+}
+
+// SetDistributionItemsError mocks base method
+func (m *MockStorage) SetDistributionItemsError(ctx context.Context, ids []int, lastError string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDistributionItemsError", ctx, ids, lastError)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDistributionItemsError indicates an expected call of SetDistributionItemsError
+func (mr *MockStorageMockRecorder) SetDistributionItemsError(ctx, ids, lastError interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDistributionItemsError", reflect.TypeOf((*MockStorage)(nil).SetDistributionItemsError), ctx, ids, lastError)
+}
+
+// CancelPendingReferralTracking mocks base method
+func (m *MockStorage) CancelPendingReferralTracking(ctx context.Context, sender string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelPendingReferralTracking", ctx, sender)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelPendingReferralTracking indicates an expected call of CancelPendingReferralTracking
+func (mr *MockStorageMockRecorder) CancelPendingReferralTracking(ctx, sender interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelPendingReferralTracking", reflect.TypeOf((*MockStorage)(nil).CancelPendingReferralTracking), ctx, sender)
+}
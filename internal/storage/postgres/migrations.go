@@ -0,0 +1,137 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+	migratep "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/httpfs"
+	"github.com/lib/pq"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// migrationLockID is the key of the advisory lock held while migrating.
+const migrationLockID = 7305418920734912
+
+// migrationLockRetryInterval is how often the lock is retried while another process holds it.
+const migrationLockRetryInterval = time.Second
+
+// ErrMigrationLocked is returned when the migration lock isn't acquired in time.
+var ErrMigrationLocked = fmt.Errorf("migrations are locked by another process")
+
+// NewMigrator returns the migrator applying the embedded migrations.
+// The migrator takes a connection of db for its whole life and closes db when it is closed,
+// so db shouldn't be shared with the storage.
+func NewMigrator(db *sql.DB) (*migrate.Migrate, error) {
+	src, err := httpfs.New(http.FS(migrations), "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create migrations source: %w", err)
+	}
+
+	driver, err := migratep.WithInstance(db, &migratep.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create database migrate driver: %w", err)
+	}
+
+	migrator, err := migrate.NewWithInstance("httpfs", src, "postgres", driver)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create migrator: %w", err)
+	}
+
+	return migrator, nil
+}
+
+// LatestMigration returns the version of the latest embedded migration.
+func LatestMigration() (uint, error) {
+	entries, err := fs.ReadDir(migrations, "migrations")
+	if err != nil {
+		return 0, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	var latest uint
+	for _, v := range entries {
+		m, err := source.DefaultParse(v.Name())
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse migration %s: %w", v.Name(), err)
+		}
+		if m.Version > latest {
+			latest = m.Version
+		}
+	}
+
+	return latest, nil
+}
+
+// GetMigrationVersion returns the applied migration version and its dirty state.
+// The version is 0 if no migration is applied.
+func GetMigrationVersion(ctx context.Context, db *sql.DB) (uint, bool, error) {
+	var (
+		version int64
+		dirty   bool
+	)
+
+	err := db.QueryRowContext(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty)
+	switch {
+	case err == nil:
+		return uint(version), dirty, nil
+	case errors.Is(err, sql.ErrNoRows):
+		return 0, false, nil
+	default:
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "42P01" { // undefined_table
+			return 0, false, nil
+		}
+		return 0, false, fmt.Errorf("failed to get migration version: %w", err)
+	}
+}
+
+// LockMigrations acquires the session advisory lock which serializes migrations of concurrent processes.
+// It waits for the lock until ctx is done, the returned function releases it.
+// The lock is held by a dedicated connection, so it is released by postgres even if the process dies.
+func LockMigrations(ctx context.Context, db *sql.DB) (func(), error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection: %w", err)
+	}
+
+	ticker := time.NewTicker(migrationLockRetryInterval)
+	defer ticker.Stop()
+
+	for {
+		var locked bool
+		if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, migrationLockID).Scan(&locked); err != nil {
+			conn.Close() // nolint: errcheck
+			if ctx.Err() != nil {
+				return nil, fmt.Errorf("%w: %s", ErrMigrationLocked, ctx.Err())
+			}
+			return nil, fmt.Errorf("failed to lock migrations: %w", err)
+		}
+
+		if locked {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			conn.Close() // nolint: errcheck
+			return nil, fmt.Errorf("%w: %s", ErrMigrationLocked, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+
+	return func() {
+		// the connection returns to the pool, so the lock is released explicitly
+		conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockID) // nolint: errcheck
+		conn.Close()                                                                             // nolint: errcheck
+	}, nil
+}
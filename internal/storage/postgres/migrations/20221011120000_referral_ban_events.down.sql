@@ -0,0 +1,4 @@
+ALTER TABLE referral_tracking
+    DROP COLUMN cancelled_at;
+
+DROP TABLE referral_ban_events;
\ No newline at end of file
@@ -0,0 +1,13 @@
+CREATE TABLE referral_ban_events
+(
+    id         SERIAL PRIMARY KEY,
+    address    TEXT      NOT NULL,
+    banned     BOOLEAN   NOT NULL,
+    reason     TEXT      NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX referral_ban_events_address_idx ON referral_ban_events (address);
+
+ALTER TABLE referral_tracking
+    ADD COLUMN cancelled_at TIMESTAMP;
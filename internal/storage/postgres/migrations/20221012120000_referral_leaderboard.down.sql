@@ -0,0 +1 @@
+DROP MATERIALIZED VIEW referral_leaderboard;
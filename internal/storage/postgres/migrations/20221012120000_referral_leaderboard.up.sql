@@ -0,0 +1,14 @@
+CREATE MATERIALIZED VIEW referral_leaderboard AS
+SELECT sender,
+       COUNT(*) FILTER (WHERE confirmed_at > NOW() - '7 days'::INTERVAL)::INT                          AS confirmed_7d,
+       COALESCE(SUM(sender_reward) FILTER (WHERE confirmed_at > NOW() - '7 days'::INTERVAL), 0)::BIGINT  AS reward_7d,
+       COUNT(*) FILTER (WHERE confirmed_at > NOW() - '30 days'::INTERVAL)::INT                         AS confirmed_30d,
+       COALESCE(SUM(sender_reward) FILTER (WHERE confirmed_at > NOW() - '30 days'::INTERVAL), 0)::BIGINT AS reward_30d,
+       COUNT(*)::INT                                                                                   AS confirmed_all,
+       COALESCE(SUM(sender_reward), 0)::BIGINT                                                         AS reward_all
+FROM referral_tracking
+WHERE status = 'confirmed'
+GROUP BY sender;
+
+-- unique index is required to refresh the view concurrently
+CREATE UNIQUE INDEX referral_leaderboard_sender_idx ON referral_leaderboard (sender);
@@ -0,0 +1,41 @@
+CREATE OR REPLACE FUNCTION unique_referral_code()
+    RETURNS TRIGGER AS
+$$
+DECLARE
+    key   TEXT;
+    qry   TEXT;
+    found TEXT;
+BEGIN
+    qry := 'SELECT own_referral_code FROM ' || quote_ident(TG_TABLE_NAME) || ' WHERE own_referral_code=';
+
+    LOOP
+        key := encode(gen_random_bytes(6), 'base64');
+        key := replace(key, '/', '_');
+        key := replace(key, '+', '-');
+
+        EXECUTE qry || quote_literal(key) INTO found;
+
+        IF found IS NULL THEN
+            EXIT;
+        END IF;
+    END LOOP;
+
+    NEW.own_referral_code = key;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE 'plpgsql';
+
+-- Point registrations made with aliases to the current codes of their owners
+UPDATE request r
+SET registration_referral_code = (SELECT o.own_referral_code
+                                  FROM referral_code_alias a
+                                           JOIN request o ON o.owner = a.owner
+                                  WHERE a.code = r.registration_referral_code)
+WHERE registration_referral_code IN (SELECT code FROM referral_code_alias);
+
+ALTER TABLE request
+    ADD CONSTRAINT request_registration_referral_code_fkey
+        FOREIGN KEY (registration_referral_code) REFERENCES request (own_referral_code);
+
+DROP TABLE referral_code_alias;
@@ -0,0 +1,36 @@
+-- Codes ever claimed by users. A claimed code stays reserved for its owner forever,
+-- so links with old codes keep working after the owner switches to a vanity code.
+CREATE TABLE referral_code_alias
+(
+    code       TEXT      NOT NULL PRIMARY KEY,
+    owner      VARCHAR   NOT NULL REFERENCES request (owner),
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX referral_code_alias_owner_idx ON referral_code_alias (owner);
+
+-- Users can register with an alias which is not present in request.own_referral_code
+ALTER TABLE request
+    DROP CONSTRAINT request_registration_referral_code_fkey;
+
+-- Generated codes must not collide with aliases
+CREATE OR REPLACE FUNCTION unique_referral_code()
+    RETURNS TRIGGER AS
+$$
+DECLARE
+    key TEXT;
+BEGIN
+    LOOP
+        key := encode(gen_random_bytes(6), 'base64');
+        key := replace(key, '/', '_');
+        key := replace(key, '+', '-');
+
+        EXIT WHEN NOT EXISTS(SELECT 1 FROM request WHERE own_referral_code = key) AND
+                  NOT EXISTS(SELECT 1 FROM referral_code_alias WHERE code = key);
+    END LOOP;
+
+    NEW.own_referral_code = key;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE 'plpgsql';
@@ -0,0 +1,4 @@
+ALTER TABLE referral_tracking
+    DROP COLUMN campaign_id;
+
+DROP TABLE referral_campaign;
@@ -0,0 +1,21 @@
+CREATE TABLE referral_campaign
+(
+    id           SERIAL PRIMARY KEY,
+    name         TEXT      NOT NULL,
+    starts_at    TIMESTAMP NOT NULL,
+    ends_at      TIMESTAMP NOT NULL,
+    -- sender reward is either multiplied or overridden
+    multiplier   NUMERIC,
+    fixed_reward BIGINT,
+    -- empty codes and senders mean the campaign applies to everyone
+    codes        TEXT[]    NOT NULL DEFAULT '{}',
+    senders      TEXT[]    NOT NULL DEFAULT '{}',
+    created_at   TIMESTAMP NOT NULL,
+    CHECK (ends_at > starts_at),
+    CHECK ((multiplier IS NULL) <> (fixed_reward IS NULL))
+);
+
+CREATE INDEX referral_campaign_period_idx ON referral_campaign (starts_at, ends_at);
+
+ALTER TABLE referral_tracking
+    ADD COLUMN campaign_id INT REFERENCES referral_campaign (id);
@@ -0,0 +1,9 @@
+-- consumed nonces, they are kept until they expire so they can't be replayed
+CREATE TABLE auth_nonce
+(
+    address    TEXT      NOT NULL,
+    purpose    TEXT      NOT NULL,
+    nonce      TEXT      NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (address, purpose, nonce)
+);
@@ -0,0 +1 @@
+DROP TABLE referral_upper_level_reward;
@@ -0,0 +1,11 @@
+CREATE TABLE referral_upper_level_reward
+(
+    receiver   VARCHAR   NOT NULL REFERENCES referral_tracking (receiver),
+    level      SMALLINT  NOT NULL CHECK (level > 1),
+    sender     VARCHAR   NOT NULL,
+    reward     BIGINT    NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (receiver, level)
+);
+
+CREATE INDEX referral_upper_level_reward_sender_idx ON referral_upper_level_reward (sender);
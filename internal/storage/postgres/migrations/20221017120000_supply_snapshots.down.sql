@@ -0,0 +1 @@
+DROP TABLE supply_snapshots;
@@ -0,0 +1,8 @@
+CREATE TABLE supply_snapshots
+(
+    created_at  TIMESTAMP NOT NULL PRIMARY KEY,
+    native      NUMERIC   NOT NULL,
+    erc20       NUMERIC   NOT NULL,
+    total       NUMERIC   NOT NULL,
+    circulating NUMERIC   NOT NULL
+);
@@ -0,0 +1,9 @@
+CREATE TABLE events
+(
+    id         UUID      NOT NULL PRIMARY KEY,
+    type       VARCHAR   NOT NULL,
+    payload    JSONB     NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX events_type_created_at_idx ON events (type, created_at);
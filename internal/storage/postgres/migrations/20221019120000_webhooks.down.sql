@@ -0,0 +1,3 @@
+DROP TABLE webhook_delivery;
+DROP TYPE WEBHOOK_DELIVERY_STATUS;
+DROP TABLE webhook_subscription;
@@ -0,0 +1,30 @@
+CREATE TABLE webhook_subscription
+(
+    id         SERIAL    NOT NULL PRIMARY KEY,
+    url        VARCHAR   NOT NULL,
+    secret     VARCHAR   NOT NULL,
+    events     VARCHAR[] NOT NULL,
+    sender     VARCHAR,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE TYPE WEBHOOK_DELIVERY_STATUS AS ENUM ('pending', 'delivered', 'dead');
+
+CREATE TABLE webhook_delivery
+(
+    id              SERIAL                  NOT NULL PRIMARY KEY,
+    subscription_id INT                     NOT NULL REFERENCES webhook_subscription (id) ON DELETE CASCADE,
+    event_id        UUID                    NOT NULL,
+    event_type      VARCHAR                 NOT NULL,
+    payload         JSONB                   NOT NULL,
+    status          WEBHOOK_DELIVERY_STATUS NOT NULL DEFAULT 'pending',
+    attempts        INT                     NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP               NOT NULL,
+    last_error      VARCHAR,
+    created_at      TIMESTAMP               NOT NULL,
+    delivered_at    TIMESTAMP,
+    UNIQUE (subscription_id, event_id)
+);
+
+CREATE INDEX webhook_delivery_pending_idx ON webhook_delivery (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX webhook_delivery_status_idx ON webhook_delivery (status, id);
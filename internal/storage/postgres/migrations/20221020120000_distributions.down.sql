@@ -0,0 +1,3 @@
+DROP TABLE distribution_items;
+DROP TYPE DISTRIBUTION_ITEM_STATUS;
+DROP TABLE distribution_runs;
@@ -0,0 +1,24 @@
+CREATE TABLE distribution_runs
+(
+    id          SERIAL    NOT NULL PRIMARY KEY,
+    name        VARCHAR   NOT NULL UNIQUE,
+    created_at  TIMESTAMP NOT NULL,
+    finished_at TIMESTAMP
+);
+
+CREATE TYPE DISTRIBUTION_ITEM_STATUS AS ENUM ('pending', 'sending', 'sent', 'failed');
+
+CREATE TABLE distribution_items
+(
+    id         SERIAL                   NOT NULL PRIMARY KEY,
+    run_id     INT                      NOT NULL REFERENCES distribution_runs (id) ON DELETE CASCADE,
+    address    VARCHAR                  NOT NULL,
+    amount     NUMERIC                  NOT NULL,
+    status     DISTRIBUTION_ITEM_STATUS NOT NULL DEFAULT 'pending',
+    attempts   INT                      NOT NULL DEFAULT 0,
+    last_error VARCHAR,
+    memo       VARCHAR,
+    created_at TIMESTAMP                NOT NULL,
+    sent_at    TIMESTAMP,
+    UNIQUE (run_id, address)
+);
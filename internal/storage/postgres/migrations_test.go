@@ -0,0 +1,45 @@
+package postgres
+
+import (
+	"io/fs"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4/source/httpfs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrations(t *testing.T) {
+	entries, err := fs.ReadDir(migrations, "migrations")
+	require.NoError(t, err)
+	require.NotEmpty(t, entries)
+
+	names := make(map[string]bool, len(entries))
+	for _, v := range entries {
+		names[v.Name()] = true
+	}
+
+	for name := range names {
+		if strings.HasSuffix(name, ".up.sql") {
+			require.True(t, names[strings.TrimSuffix(name, ".up.sql")+".down.sql"], "%s has no down migration", name)
+		}
+	}
+
+	src, err := httpfs.New(http.FS(migrations), "migrations")
+	require.NoError(t, err)
+
+	v, err := src.First()
+	require.NoError(t, err)
+	for {
+		next, err := src.Next(v)
+		if err != nil {
+			break
+		}
+		v = next
+	}
+
+	latest, err := LatestMigration()
+	require.NoError(t, err)
+	require.Equal(t, v, latest)
+}
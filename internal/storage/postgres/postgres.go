@@ -7,6 +7,7 @@ import (
 	"database/sql/driver"
 	"errors"
 	"fmt"
+	"time"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/google/uuid"
@@ -19,7 +20,12 @@ import (
 
 var errBeginCalledWithinTx = errors.New("can not run in tx")
 
+// nonceRetention is how long used nonces are kept after they expire.
+const nonceRetention = time.Minute
+
 type pg struct {
+	// db is nil within tx
+	db  *sqlx.DB
 	ext sqlx.ExtContext
 }
 
@@ -36,6 +42,12 @@ func (i *intDTO) Scan(value interface{}) error {
 	switch t := value.(type) {
 	case int64:
 		*i = intDTO(sdk.NewInt(value.(int64)))
+	case []byte:
+		v, ok := sdk.NewIntFromString(string(t))
+		if !ok {
+			return fmt.Errorf("failed to parse %s as sdk.Int", t)
+		}
+		*i = intDTO(v)
 	default:
 		return fmt.Errorf("failed to scan type %T into sdk.INT", t)
 	}
@@ -45,18 +57,20 @@ func (i *intDTO) Scan(value interface{}) error {
 
 // New creates new instance of pg.
 func New(db *sql.DB) storage.Storage {
+	sdb := sqlx.NewDb(db, "postgres")
+
 	return pg{
-		ext: sqlx.NewDb(db, "postgres"),
+		db:  sdb,
+		ext: tracedExt{sdb},
 	}
 }
 
 func (p pg) InTx(ctx context.Context, f func(s storage.Storage) error) error {
-	db, ok := p.ext.(*sqlx.DB)
-	if !ok {
+	if p.db == nil {
 		return errBeginCalledWithinTx
 	}
 
-	tx, err := db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	tx, err := p.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
 	if err != nil {
 		return fmt.Errorf("failed to create tx: %w", err)
 	}
@@ -67,7 +81,7 @@ func (p pg) InTx(ctx context.Context, f func(s storage.Storage) error) error {
 		}
 
 		return nil
-	}(pg{ext: tx}); err != nil {
+	}(pg{ext: tracedExt{tx}}); err != nil {
 		if err := tx.Rollback(); err != nil {
 			log.WithError(err).Error("failed to rollback tx")
 		}
@@ -95,7 +109,10 @@ func (p pg) GetRequestByOwner(ctx context.Context, owner string) (*storage.Reque
 
 func (p pg) GetRequestByOwnReferralCode(ctx context.Context, ownReferralCode string) (*storage.Request, error) {
 	var r storage.Request
-	if err := sqlx.GetContext(ctx, p.ext, &r, `SELECT * FROM request WHERE own_referral_code=$1`, ownReferralCode); err != nil {
+	if err := sqlx.GetContext(ctx, p.ext, &r, `
+				SELECT * FROM request
+				WHERE own_referral_code=$1 OR
+					owner=(SELECT owner FROM referral_code_alias WHERE code=$1)`, ownReferralCode); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, storage.ErrReferralCodeNotFound
 		}
@@ -105,6 +122,40 @@ func (p pg) GetRequestByOwnReferralCode(ctx context.Context, ownReferralCode str
 	return &r, nil
 }
 
+func (p pg) CreateReferralCodeAlias(ctx context.Context, owner, code string) error {
+	// the update is a no-op which makes the conflicting row visible to RowsAffected for the same owner
+	res, err := p.ext.ExecContext(ctx, `
+				INSERT INTO referral_code_alias (code, owner, created_at)
+				VALUES ($2, $1, CURRENT_TIMESTAMP)
+				ON CONFLICT (code) DO UPDATE SET owner=EXCLUDED.owner
+				WHERE referral_code_alias.owner=EXCLUDED.owner`, owner, code)
+	if err != nil {
+		return fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	if c, _ := res.RowsAffected(); c == 0 {
+		return storage.ErrReferralCodeIsTaken
+	}
+
+	return nil
+}
+
+func (p pg) SetOwnReferralCode(ctx context.Context, owner, code string) error {
+	res, err := p.ext.ExecContext(ctx, `UPDATE request SET own_referral_code=$2 WHERE owner=$1`, owner, code)
+	if err != nil {
+		if isUniqueViolationErr(err, "request_own_referral_code_key") {
+			return storage.ErrReferralCodeIsTaken
+		}
+		return fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	if c, _ := res.RowsAffected(); c == 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
+}
+
 func (p pg) GetRequestByAddress(ctx context.Context, address string) (*storage.Request, error) {
 	var r storage.Request
 	if err := sqlx.GetContext(ctx, p.ext, &r, `SELECT * FROM request WHERE address=$1`, address); err != nil {
@@ -167,7 +218,8 @@ func (p pg) CreateReferralTracking(ctx context.Context, receiver string, referra
 	if _, err := p.ext.ExecContext(ctx, `
 			INSERT INTO referral_tracking (sender, receiver, registered_at) 
 			VALUES (
-				(SELECT address FROM request WHERE own_referral_code = $2), 
+				(SELECT address FROM request WHERE own_referral_code = $2 OR
+					owner = (SELECT owner FROM referral_code_alias WHERE code = $2)),
 				$1, 
 				CURRENT_TIMESTAMP
 			)`,
@@ -210,6 +262,38 @@ func (p pg) GetReferralTrackingByReceiver(ctx context.Context, receiver string)
 	return &r, nil
 }
 
+func (p pg) ConsumeNonce(ctx context.Context, address, purpose, nonce string, expiresAt time.Time) error {
+	// expired nonces are kept for a while, so the one verified right before its expiration can't be consumed twice
+	if _, err := p.ext.ExecContext(ctx, `
+				DELETE FROM auth_nonce WHERE expires_at <= $1`,
+		time.Now().UTC().Add(-nonceRetention)); err != nil {
+		return fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	res, err := p.ext.ExecContext(ctx, `
+				INSERT INTO auth_nonce (address, purpose, nonce, expires_at)
+				VALUES ($1, $2, $3, $4)
+				ON CONFLICT DO NOTHING`,
+		address, purpose, nonce, expiresAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	if c, _ := res.RowsAffected(); c == 0 {
+		return storage.ErrNonceUsed
+	}
+
+	return nil
+}
+
+func (p pg) GetReferralTrackingBySender(ctx context.Context, sender string,
+	take, skip int) (rt []*storage.ReferralTracking, err error) {
+	err = sqlx.SelectContext(ctx, p.ext, &rt, `
+				SELECT * FROM referral_tracking WHERE sender=$1
+				ORDER BY registered_at DESC, receiver LIMIT $2 OFFSET $3`, sender, take, skip)
+	return rt, err
+}
+
 func (p pg) GetReferralTrackingStats(ctx context.Context, sender string) ([]*storage.ReferralTrackingStats, error) {
 	var dto []*struct {
 		Registered int    `db:"registered"`
@@ -235,6 +319,149 @@ func (p pg) GetReferralTrackingStats(ctx context.Context, sender string) ([]*sto
 	return stats, err
 }
 
+type referralCampaignDTO struct {
+	ID          int            `db:"id"`
+	Name        string         `db:"name"`
+	StartsAt    time.Time      `db:"starts_at"`
+	EndsAt      time.Time      `db:"ends_at"`
+	Multiplier  sql.NullString `db:"multiplier"`
+	FixedReward sql.NullInt64  `db:"fixed_reward"`
+	Codes       pq.StringArray `db:"codes"`
+	Senders     pq.StringArray `db:"senders"`
+	CreatedAt   time.Time      `db:"created_at"`
+}
+
+func (d referralCampaignDTO) toStorage() (*storage.ReferralCampaign, error) {
+	c := storage.ReferralCampaign{
+		ID:        d.ID,
+		Name:      d.Name,
+		StartsAt:  d.StartsAt,
+		EndsAt:    d.EndsAt,
+		Codes:     d.Codes,
+		Senders:   d.Senders,
+		CreatedAt: d.CreatedAt,
+	}
+
+	if d.Multiplier.Valid {
+		m, err := sdk.NewFurFromStr(d.Multiplier.String)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse multiplier %s: %w", d.Multiplier.String, err)
+		}
+		c.Multiplier = &m
+	}
+
+	if d.FixedReward.Valid {
+		r := sdk.NewInt(d.FixedReward.Int64)
+		c.FixedReward = &r
+	}
+
+	return &c, nil
+}
+
+func (p pg) CreateReferralCampaign(ctx context.Context, c storage.ReferralCampaign) (int, error) {
+	var (
+		multiplier  sql.NullString
+		fixedReward sql.NullInt64
+	)
+	if c.Multiplier != nil {
+		multiplier = sql.NullString{Valid: true, String: c.Multiplier.String()}
+	}
+	if c.FixedReward != nil {
+		fixedReward = sql.NullInt64{Valid: true, Int64: c.FixedReward.Int64()}
+	}
+
+	var id int
+	if err := sqlx.GetContext(ctx, p.ext, &id, `
+				INSERT INTO referral_campaign (name, starts_at, ends_at, multiplier, fixed_reward, codes, senders, created_at)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, CURRENT_TIMESTAMP)
+				RETURNING id`,
+		c.Name, c.StartsAt, c.EndsAt, multiplier, fixedReward,
+		pq.StringArray(c.Codes), pq.StringArray(c.Senders),
+	); err != nil {
+		return 0, fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	return id, nil
+}
+
+func (p pg) GetReferralCampaigns(ctx context.Context) ([]*storage.ReferralCampaign, error) {
+	return p.selectReferralCampaigns(ctx, `SELECT * FROM referral_campaign ORDER BY id DESC`)
+}
+
+func (p pg) GetReferralCampaignsActiveBetween(ctx context.Context, from, to time.Time) ([]*storage.ReferralCampaign, error) {
+	return p.selectReferralCampaigns(ctx, `
+				SELECT * FROM referral_campaign
+				WHERE starts_at <= $2 AND ends_at > $1
+				ORDER BY id`, from, to)
+}
+
+func (p pg) selectReferralCampaigns(ctx context.Context, query string, args ...interface{}) ([]*storage.ReferralCampaign, error) {
+	var dto []*referralCampaignDTO
+	if err := sqlx.SelectContext(ctx, p.ext, &dto, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	campaigns := make([]*storage.ReferralCampaign, len(dto))
+	for i, v := range dto {
+		c, err := v.toStorage()
+		if err != nil {
+			return nil, err
+		}
+		campaigns[i] = c
+	}
+
+	return campaigns, nil
+}
+
+func (p pg) GetReferralLeaderboard(ctx context.Context, window storage.LeaderboardWindow,
+	limit int) ([]*storage.ReferralLeaderboardItem, error) {
+	var suffix string
+	switch window {
+	case storage.LeaderboardWindow7Days:
+		suffix = "7d"
+	case storage.LeaderboardWindow30Days:
+		suffix = "30d"
+	case storage.LeaderboardWindowAll:
+		suffix = "all"
+	default:
+		return nil, fmt.Errorf("unknown leaderboard window %s", window)
+	}
+
+	var dto []*struct {
+		Sender    string `db:"sender"`
+		Confirmed int    `db:"confirmed"`
+		Reward    intDTO `db:"reward"`
+	}
+	err := sqlx.SelectContext(ctx, p.ext, &dto, fmt.Sprintf(`
+				SELECT sender, confirmed_%[1]s AS confirmed, reward_%[1]s AS reward
+				FROM referral_leaderboard
+				WHERE confirmed_%[1]s > 0 AND
+					sender NOT IN (SELECT address FROM request WHERE referral_banned)
+				ORDER BY confirmed_%[1]s DESC, reward_%[1]s DESC, sender
+				LIMIT $1`, suffix), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*storage.ReferralLeaderboardItem, len(dto))
+	for i, v := range dto {
+		items[i] = &storage.ReferralLeaderboardItem{
+			Sender:    v.Sender,
+			Confirmed: v.Confirmed,
+			Reward:    sdk.Int(v.Reward),
+		}
+	}
+
+	return items, nil
+}
+
+func (p pg) RefreshReferralLeaderboard(ctx context.Context) error {
+	if _, err := p.ext.ExecContext(ctx, `REFRESH MATERIALIZED VIEW CONCURRENTLY referral_leaderboard`); err != nil {
+		return fmt.Errorf("failed to exec query: %w", err)
+	}
+	return nil
+}
+
 func (p pg) GetConfirmedReferralTrackingCount(ctx context.Context, sender string) (int, error) {
 	var count int
 	err := sqlx.GetContext(ctx, p.ext, &count, `
@@ -256,20 +483,33 @@ func (p pg) TransitionReferralTrackingToInstalled(ctx context.Context, receiver
 }
 
 func (p pg) TransitionReferralTrackingToConfirmed(ctx context.Context, receiver string,
-	senderReward, receiverReward sdk.Int) error {
+	senderReward, receiverReward sdk.Int, campaignID sql.NullInt64) error {
 	_, err := p.ext.ExecContext(ctx, `
 				UPDATE referral_tracking
 				SET status = 'confirmed',
 					sender_reward = $2,
 					receiver_reward = $3,
+					campaign_id = $4,
 					confirmed_at = CURRENT_TIMESTAMP
-				WHERE receiver = $1`, receiver, intDTO(senderReward), intDTO(receiverReward))
+				WHERE receiver = $1`, receiver, intDTO(senderReward), intDTO(receiverReward), campaignID)
 	if err != nil {
 		return fmt.Errorf("failed to exec query: %w", err)
 	}
 	return nil
 }
 
+func (p pg) CreateReferralUpperLevelReward(ctx context.Context, receiver string, level int, sender string,
+	reward sdk.Int) error {
+	if _, err := p.ext.ExecContext(ctx, `
+			INSERT INTO referral_upper_level_reward (receiver, level, sender, reward, created_at)
+			VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP)
+	`, receiver, level, sender, intDTO(reward)); err != nil {
+		return fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	return nil
+}
+
 func (p pg) GetConfirmedRegistrationsStats(ctx context.Context) ([]*storage.RegisterStats, error) {
 	var stats []*storage.RegisterStats
 	err := sqlx.SelectContext(ctx, p.ext, &stats, `
@@ -282,12 +522,72 @@ func (p pg) GetConfirmedRegistrationsStats(ctx context.Context) ([]*storage.Regi
 	return stats, err
 }
 
+func (p pg) GetRegistrationFunnel(ctx context.Context, from, to time.Time,
+	granularity storage.Granularity) ([]*storage.RegistrationFunnelItem, error) {
+	var items []*storage.RegistrationFunnelItem
+	if err := sqlx.SelectContext(ctx, p.ext, &items, `
+				WITH bounds AS (
+					SELECT DATE_TRUNC($3, $1::TIMESTAMP) AS start_at, $2::TIMESTAMP AS end_at
+				), series AS (
+					SELECT GENERATE_SERIES(start_at, end_at - INTERVAL '1 microsecond', ('1 ' || $3)::INTERVAL) AS date
+					FROM bounds
+				), requests AS (
+					SELECT DATE_TRUNC($3, created_at) AS date,
+						COUNT(*) AS started,
+						-- testnet accounts are confirmed without email
+						COUNT(*) FILTER (WHERE email NOT LIKE '[testnet]%') AS emails_sent
+					FROM request, bounds
+					WHERE created_at >= start_at AND created_at < end_at
+					GROUP BY 1
+				), confirmations AS (
+					SELECT DATE_TRUNC($3, confirmed_at) AS date, COUNT(*) AS confirmed
+					FROM request, bounds
+					WHERE confirmed_at >= start_at AND confirmed_at < end_at
+					GROUP BY 1
+				), referrals AS (
+					SELECT DATE_TRUNC($3, registered_at) AS date, COUNT(*) AS referral_registered
+					FROM referral_tracking, bounds
+					WHERE registered_at >= start_at AND registered_at < end_at
+					GROUP BY 1
+				), installations AS (
+					SELECT DATE_TRUNC($3, installed_at) AS date, COUNT(*) AS installed
+					FROM referral_tracking, bounds
+					WHERE installed_at >= start_at AND installed_at < end_at
+					GROUP BY 1
+				), rewards AS (
+					SELECT DATE_TRUNC($3, confirmed_at) AS date, COUNT(*) AS rewarded
+					FROM referral_tracking, bounds
+					WHERE confirmed_at >= start_at AND confirmed_at < end_at
+					GROUP BY 1
+				)
+				SELECT date,
+					COALESCE(started, 0) AS started,
+					COALESCE(emails_sent, 0) AS emails_sent,
+					COALESCE(confirmed, 0) AS confirmed,
+					COALESCE(referral_registered, 0) AS referral_registered,
+					COALESCE(installed, 0) AS installed,
+					COALESCE(rewarded, 0) AS rewarded
+				FROM series
+				LEFT JOIN requests USING (date)
+				LEFT JOIN confirmations USING (date)
+				LEFT JOIN referrals USING (date)
+				LEFT JOIN installations USING (date)
+				LEFT JOIN rewards USING (date)
+				ORDER BY date
+	`, from.UTC(), to.UTC(), string(granularity)); err != nil {
+		return nil, fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	return items, nil
+}
+
 func (p pg) GetUnconfirmedReferralTracking(ctx context.Context, days int) ([]*storage.ReferralTracking, error) {
 	var rt []*storage.ReferralTracking
 	err := sqlx.SelectContext(ctx, p.ext, &rt, fmt.Sprintf(`
 				SELECT *
 				FROM referral_tracking
 				WHERE status = 'installed' AND installed_at < NOW() -'%d day'::INTERVAL AND
+					cancelled_at IS NULL AND
 					sender NOT IN (SELECT address FROM request WHERE referral_banned)
 	`, days))
 	return rt, err
@@ -310,6 +610,415 @@ func (p pg) DoesEmailHaveFraudDomain(ctx context.Context, email string) (bool, e
 	return check, err
 }
 
+func (p pg) SetReferralBanned(ctx context.Context, address string, banned bool) error {
+	res, err := p.ext.ExecContext(ctx, `
+		UPDATE request SET referral_banned=$2 WHERE address=$1 AND referral_banned IS DISTINCT FROM $2
+	`, address, banned)
+	if err != nil {
+		return fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	if c, _ := res.RowsAffected(); c > 0 {
+		return nil
+	}
+
+	var exists bool
+	if err := sqlx.GetContext(ctx, p.ext, &exists,
+		`SELECT EXISTS(SELECT * FROM request WHERE address=$1)`, address); err != nil {
+		return fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	if !exists {
+		return storage.ErrNotFound
+	}
+
+	return storage.ErrReferralBannedUnchanged
+}
+
+func (p pg) CreateReferralBanEvent(ctx context.Context, address string, banned bool, reason string) error {
+	if _, err := p.ext.ExecContext(ctx, `
+			INSERT INTO referral_ban_events (address, banned, reason, created_at)
+			VALUES ($1, $2, $3, CURRENT_TIMESTAMP)
+	`, address, banned, reason); err != nil {
+		return fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	return nil
+}
+
+func (p pg) GetReferralBanEvents(ctx context.Context, address string) ([]*storage.ReferralBanEvent, error) {
+	var events []*storage.ReferralBanEvent
+	err := sqlx.SelectContext(ctx, p.ext, &events, `
+				SELECT * FROM referral_ban_events WHERE address = $1 ORDER BY id DESC`, address)
+	return events, err
+}
+
+func (p pg) CancelPendingReferralTracking(ctx context.Context, sender string) (int, error) {
+	res, err := p.ext.ExecContext(ctx, `
+				UPDATE referral_tracking
+				SET cancelled_at = CURRENT_TIMESTAMP
+				WHERE sender = $1 AND status <> 'confirmed' AND cancelled_at IS NULL`, sender)
+	if err != nil {
+		return 0, fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	c, _ := res.RowsAffected()
+
+	return int(c), nil
+}
+
+func (p pg) CreateSupplySnapshot(ctx context.Context, s storage.SupplySnapshot) error {
+	if _, err := p.ext.ExecContext(ctx, `
+			INSERT INTO supply_snapshots (created_at, native, erc20, total, circulating)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (created_at) DO NOTHING
+	`, s.CreatedAt.UTC(), intDTO(s.Native), intDTO(s.ERC20), intDTO(s.Total), intDTO(s.Circulating)); err != nil {
+		return fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	return nil
+}
+
+func (p pg) CreateEvent(ctx context.Context, e storage.Event) error {
+	if _, err := p.ext.ExecContext(ctx, `
+			INSERT INTO events (id, type, payload, created_at)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (id) DO NOTHING
+	`, e.ID, e.Type, string(e.Payload), e.CreatedAt.UTC()); err != nil {
+		return fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	return nil
+}
+
+type webhookSubscriptionDTO struct {
+	ID        int            `db:"id"`
+	URL       string         `db:"url"`
+	Secret    string         `db:"secret"`
+	Events    pq.StringArray `db:"events"`
+	Sender    sql.NullString `db:"sender"`
+	CreatedAt time.Time      `db:"created_at"`
+}
+
+func (p pg) CreateWebhookSubscription(ctx context.Context, s storage.WebhookSubscription) (int, error) {
+	var id int
+	if err := sqlx.GetContext(ctx, p.ext, &id, `
+				INSERT INTO webhook_subscription (url, secret, events, sender, created_at)
+				VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP)
+				RETURNING id`,
+		s.URL, s.Secret, pq.StringArray(s.Events), s.Sender,
+	); err != nil {
+		return 0, fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	return id, nil
+}
+
+func (p pg) GetWebhookSubscriptions(ctx context.Context) ([]*storage.WebhookSubscription, error) {
+	var dto []*webhookSubscriptionDTO
+	if err := sqlx.SelectContext(ctx, p.ext, &dto, `SELECT * FROM webhook_subscription ORDER BY id DESC`); err != nil {
+		return nil, fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	subscriptions := make([]*storage.WebhookSubscription, len(dto))
+	for i, v := range dto {
+		subscriptions[i] = &storage.WebhookSubscription{
+			ID:        v.ID,
+			URL:       v.URL,
+			Secret:    v.Secret,
+			Events:    v.Events,
+			Sender:    v.Sender,
+			CreatedAt: v.CreatedAt,
+		}
+	}
+
+	return subscriptions, nil
+}
+
+func (p pg) DeleteWebhookSubscription(ctx context.Context, id int) error {
+	res, err := p.ext.ExecContext(ctx, `DELETE FROM webhook_subscription WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	if c, _ := res.RowsAffected(); c == 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
+}
+
+func (p pg) CreateWebhookDeliveries(ctx context.Context, eventID, eventType, sender string, payload []byte) (int, error) {
+	res, err := p.ext.ExecContext(ctx, `
+			INSERT INTO webhook_delivery (subscription_id, event_id, event_type, payload, next_attempt_at, created_at)
+			SELECT id, $1::UUID, $2::VARCHAR, $4::JSONB, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP
+			FROM webhook_subscription
+			WHERE $2::VARCHAR = ANY(events) AND (sender IS NULL OR sender = $3)
+			ON CONFLICT (subscription_id, event_id) DO NOTHING
+	`, eventID, eventType, sender, string(payload))
+	if err != nil {
+		return 0, fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	c, _ := res.RowsAffected()
+	return int(c), nil
+}
+
+func (p pg) ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*storage.WebhookDelivery, error) {
+	var deliveries []*storage.WebhookDelivery
+	if err := sqlx.SelectContext(ctx, p.ext, &deliveries, `
+				UPDATE webhook_delivery
+				SET next_attempt_at = CURRENT_TIMESTAMP + $2::INT * INTERVAL '1 second'
+				WHERE id IN (
+					SELECT id FROM webhook_delivery
+					WHERE status = 'pending' AND next_attempt_at <= CURRENT_TIMESTAMP
+					ORDER BY next_attempt_at
+					LIMIT $1
+					FOR UPDATE SKIP LOCKED
+				)
+				RETURNING *`, limit, int64(lease.Seconds())); err != nil {
+		return nil, fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+func (p pg) SetWebhookDeliveryDelivered(ctx context.Context, id int) error {
+	if _, err := p.ext.ExecContext(ctx, `
+			UPDATE webhook_delivery
+			SET status = 'delivered', attempts = attempts + 1, last_error = NULL, delivered_at = CURRENT_TIMESTAMP
+			WHERE id = $1
+	`, id); err != nil {
+		return fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	return nil
+}
+
+func (p pg) SetWebhookDeliveryFailed(ctx context.Context, id int, lastError string, nextAttemptAt sql.NullTime) error {
+	if _, err := p.ext.ExecContext(ctx, `
+			UPDATE webhook_delivery
+			SET status = CASE WHEN $3::TIMESTAMP IS NULL THEN 'dead' ELSE 'pending' END::WEBHOOK_DELIVERY_STATUS,
+				attempts = attempts + 1,
+				last_error = $2,
+				next_attempt_at = COALESCE($3, next_attempt_at)
+			WHERE id = $1
+	`, id, lastError, nextAttemptAt); err != nil {
+		return fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	return nil
+}
+
+func (p pg) GetWebhookDeliveries(ctx context.Context, status storage.WebhookDeliveryStatus,
+	take, skip int) ([]*storage.WebhookDelivery, error) {
+	var deliveries []*storage.WebhookDelivery
+	if err := sqlx.SelectContext(ctx, p.ext, &deliveries, `
+				SELECT * FROM webhook_delivery
+				WHERE status = $1
+				ORDER BY id DESC
+				LIMIT $2 OFFSET $3`, status, take, skip); err != nil {
+		return nil, fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+func (p pg) ReplayWebhookDelivery(ctx context.Context, id int) error {
+	res, err := p.ext.ExecContext(ctx, `
+			UPDATE webhook_delivery
+			SET status = 'pending', attempts = 0, next_attempt_at = CURRENT_TIMESTAMP
+			WHERE id = $1 AND status = 'dead'
+	`, id)
+	if err != nil {
+		return fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	if c, _ := res.RowsAffected(); c == 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
+}
+
+func (p pg) GetDistributionRun(ctx context.Context, name string) (*storage.DistributionRun, error) {
+	var run storage.DistributionRun
+	if err := sqlx.GetContext(ctx, p.ext, &run, `SELECT * FROM distribution_runs WHERE name = $1`, name); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	return &run, nil
+}
+
+func (p pg) CreateDistributionRun(ctx context.Context, name string) (*storage.DistributionRun, error) {
+	var run storage.DistributionRun
+	// the no-op update makes RETURNING return the existing row
+	if err := sqlx.GetContext(ctx, p.ext, &run, `
+				INSERT INTO distribution_runs (name, created_at)
+				VALUES ($1, CURRENT_TIMESTAMP)
+				ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+				RETURNING *`, name); err != nil {
+		return nil, fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	return &run, nil
+}
+
+func (p pg) SetDistributionRunFinished(ctx context.Context, id int) error {
+	if _, err := p.ext.ExecContext(ctx, `
+			UPDATE distribution_runs SET finished_at = CURRENT_TIMESTAMP WHERE id = $1
+	`, id); err != nil {
+		return fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	return nil
+}
+
+func (p pg) CreateDistributionItems(ctx context.Context, runID int, items []storage.DistributionItem) (int, error) {
+	addresses := make(pq.StringArray, len(items))
+	amounts := make(pq.StringArray, len(items))
+	for i, v := range items {
+		addresses[i] = v.Address
+		amounts[i] = v.Amount.String()
+	}
+
+	res, err := p.ext.ExecContext(ctx, `
+			INSERT INTO distribution_items (run_id, address, amount, created_at)
+			SELECT $1, address, amount, CURRENT_TIMESTAMP
+			FROM UNNEST($2::VARCHAR[], $3::NUMERIC[]) WITH ORDINALITY AS t (address, amount, n)
+			ORDER BY n
+			ON CONFLICT (run_id, address) DO NOTHING
+	`, runID, addresses, amounts)
+	if err != nil {
+		return 0, fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	c, _ := res.RowsAffected()
+	return int(c), nil
+}
+
+func (p pg) GetDistributionItems(ctx context.Context, runID int) ([]*storage.DistributionItem, error) {
+	var dto []struct {
+		ID        int                            `db:"id"`
+		RunID     int                            `db:"run_id"`
+		Address   string                         `db:"address"`
+		Amount    intDTO                         `db:"amount"`
+		Status    storage.DistributionItemStatus `db:"status"`
+		Attempts  int                            `db:"attempts"`
+		LastError sql.NullString                 `db:"last_error"`
+		Memo      sql.NullString                 `db:"memo"`
+		CreatedAt time.Time                      `db:"created_at"`
+		SentAt    sql.NullTime                   `db:"sent_at"`
+	}
+
+	if err := sqlx.SelectContext(ctx, p.ext, &dto, `
+				SELECT * FROM distribution_items WHERE run_id = $1 ORDER BY id`, runID); err != nil {
+		return nil, fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	items := make([]*storage.DistributionItem, len(dto))
+	for i, v := range dto {
+		items[i] = &storage.DistributionItem{
+			ID:        v.ID,
+			RunID:     v.RunID,
+			Address:   v.Address,
+			Amount:    sdk.Int(v.Amount),
+			Status:    v.Status,
+			Attempts:  v.Attempts,
+			LastError: v.LastError,
+			Memo:      v.Memo,
+			CreatedAt: v.CreatedAt,
+			SentAt:    v.SentAt,
+		}
+	}
+
+	return items, nil
+}
+
+func (p pg) SetDistributionItemsSending(ctx context.Context, ids []int, memo string) error {
+	if _, err := p.ext.ExecContext(ctx, `
+			UPDATE distribution_items
+			SET status = 'sending', attempts = attempts + 1, last_error = NULL, memo = $2
+			WHERE id = ANY($1)
+	`, pq.Array(ids), memo); err != nil {
+		return fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	return nil
+}
+
+func (p pg) SetDistributionItemsSent(ctx context.Context, ids []int) error {
+	if _, err := p.ext.ExecContext(ctx, `
+			UPDATE distribution_items
+			SET status = 'sent', last_error = NULL, sent_at = CURRENT_TIMESTAMP
+			WHERE id = ANY($1)
+	`, pq.Array(ids)); err != nil {
+		return fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	return nil
+}
+
+func (p pg) SetDistributionItemsFailed(ctx context.Context, ids []int, lastError string) error {
+	if _, err := p.ext.ExecContext(ctx, `
+			UPDATE distribution_items
+			SET status = 'failed', last_error = $2
+			WHERE id = ANY($1)
+	`, pq.Array(ids), lastError); err != nil {
+		return fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	return nil
+}
+
+func (p pg) SetDistributionItemsError(ctx context.Context, ids []int, lastError string) error {
+	if _, err := p.ext.ExecContext(ctx, `
+			UPDATE distribution_items SET last_error = $2 WHERE id = ANY($1)
+	`, pq.Array(ids), lastError); err != nil {
+		return fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	return nil
+}
+
+func (p pg) GetSupplyHistory(ctx context.Context, from, to time.Time,
+	interval time.Duration) ([]*storage.SupplySnapshot, error) {
+	var dto []struct {
+		CreatedAt   time.Time `db:"bucket"`
+		Native      intDTO    `db:"native"`
+		ERC20       intDTO    `db:"erc20"`
+		Total       intDTO    `db:"total"`
+		Circulating intDTO    `db:"circulating"`
+	}
+
+	if err := sqlx.SelectContext(ctx, p.ext, &dto, `
+				SELECT DISTINCT ON (bucket)
+					TO_TIMESTAMP(FLOOR(EXTRACT(EPOCH FROM created_at) / $3) * $3) AT TIME ZONE 'UTC' AS bucket,
+					native, erc20, total, circulating
+				FROM supply_snapshots
+				WHERE created_at >= $1 AND created_at < $2
+				ORDER BY bucket, created_at DESC
+	`, from.UTC(), to.UTC(), int64(interval.Seconds())); err != nil {
+		return nil, fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	out := make([]*storage.SupplySnapshot, len(dto))
+	for i, v := range dto {
+		out[i] = &storage.SupplySnapshot{
+			CreatedAt:   v.CreatedAt,
+			Native:      sdk.Int(v.Native),
+			ERC20:       sdk.Int(v.ERC20),
+			Total:       sdk.Int(v.Total),
+			Circulating: sdk.Int(v.Circulating),
+		}
+	}
+
+	return out, nil
+}
+
 func isUniqueViolationErr(err error, constraint string) bool {
 	if err1, ok := err.(*pq.Error); ok &&
 		err1.Code == "23505" && err1.Constraint == constraint {
@@ -9,16 +9,11 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"path/filepath"
-	"runtime"
 	"strconv"
 	"testing"
 	"time"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
-	m "github.com/golang-migrate/migrate/v4"
-	_ "github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -89,24 +84,19 @@ func setup() func() {
 		}
 	}
 
-	migrate("postgres", "root", host, "postgres", port.Int())
+	migrateUp(dsn)
 
 	return shutdownFn
 }
 
-func migrate(username, password, hostname, dbname string, port int) {
-	_, currFile, _, ok := runtime.Caller(0)
-	if !ok {
-		logrus.Fatal("failed to get current file location")
+func migrateUp(dsn string) {
+	// the migrator closes its db
+	mdb, err := sql.Open("postgres", dsn)
+	if err != nil {
+		logrus.WithError(err).Fatal("failed to open connection")
 	}
 
-	migrations := filepath.Join(currFile, "../../../../scripts/migrations/postgres/")
-
-	migrator, err := m.New(
-		fmt.Sprintf("file://%s", migrations),
-		fmt.Sprintf("postgres://%s:%s@%s:%d/%s?sslmode=disable",
-			username, password, hostname, port, dbname),
-	)
+	migrator, err := NewMigrator(mdb)
 	if err != nil {
 		logrus.WithError(err).Fatal("failed to create migrator")
 	}
@@ -118,12 +108,30 @@ func migrate(username, password, hostname, dbname string, port int) {
 }
 
 func cleanup(t *testing.T) {
-	_, err := db.ExecContext(ctx, "DELETE FROM referral_tracking")
+	_, err := db.ExecContext(ctx, "DELETE FROM referral_upper_level_reward")
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, "DELETE FROM referral_tracking")
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, "DELETE FROM referral_campaign")
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, "DELETE FROM referral_code_alias")
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, "REFRESH MATERIALIZED VIEW referral_leaderboard")
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, "DELETE FROM referral_ban_events")
 	require.NoError(t, err)
 	_, err = db.ExecContext(ctx, "DELETE FROM request")
 	require.NoError(t, err)
 	_, err = db.ExecContext(ctx, "DELETE FROM dloan")
 	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, "DELETE FROM supply_snapshots")
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, "DELETE FROM events")
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, "DELETE FROM webhook_subscription")
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, "DELETE FROM distribution_runs")
+	require.NoError(t, err)
 }
 
 func TestPg_InsertRequest(t *testing.T) {
@@ -196,6 +204,53 @@ func TestPg_GetConfirmedAccountStats(t *testing.T) {
 	require.Equal(t, 10, stats[0].Value)
 }
 
+func TestPg_GetRegistrationFunnel(t *testing.T) {
+	defer cleanup(t)
+
+	day := time.Date(2022, 10, 17, 0, 0, 0, 0, time.UTC)
+
+	require.NoError(t, s.UpsertRequest(ctx, "owner", "e@mail.com", "sender", "code", sql.NullString{}))
+	require.NoError(t, s.SetConfirmed(ctx, "owner"))
+	require.NoError(t, s.CreateTestnetConfirmedRequest(ctx, "testnet"))
+	r, err := s.GetRequestByOwner(ctx, "owner")
+	require.NoError(t, err)
+	require.NoError(t, s.CreateReferralTracking(ctx, "receiver", r.OwnReferralCode))
+
+	_, err = db.ExecContext(ctx, `UPDATE request SET created_at = $1, confirmed_at = $1`, day.Add(time.Hour))
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, `UPDATE request SET confirmed_at = $1 WHERE owner = 'owner'`, day.Add(25*time.Hour))
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, `UPDATE referral_tracking SET registered_at = $1, installed_at = $2, confirmed_at = $2`,
+		day.Add(2*time.Hour), day.Add(26*time.Hour))
+	require.NoError(t, err)
+
+	items, err := s.GetRegistrationFunnel(ctx, day, day.Add(72*time.Hour), storage.GranularityDay)
+	require.NoError(t, err)
+	require.Len(t, items, 3)
+
+	for i := range items {
+		require.Equal(t, day.Add(time.Duration(i)*24*time.Hour), items[i].Date.UTC())
+		items[i].Date = time.Time{}
+	}
+
+	require.Equal(t, storage.RegistrationFunnelItem{
+		Started: 2, EmailsSent: 1, Confirmed: 1, ReferralRegistered: 1,
+	}, *items[0])
+	require.Equal(t, storage.RegistrationFunnelItem{
+		Confirmed: 1, Installed: 1, Rewarded: 1,
+	}, *items[1])
+	require.Equal(t, storage.RegistrationFunnelItem{}, *items[2])
+
+	// from is truncated to the week start
+	items, err = s.GetRegistrationFunnel(ctx, day.Add(30*time.Hour), day.Add(72*time.Hour), storage.GranularityWeek)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	require.Equal(t, day, items[0].Date.UTC())
+	require.Equal(t, 2, items[0].Started)
+	require.Equal(t, 2, items[0].Confirmed)
+	require.Equal(t, 1, items[0].Rewarded)
+}
+
 func dateEqual(date1, date2 time.Time) bool {
 	y1, m1, d1 := date1.Date()
 	y2, m2, d2 := date2.Date()
@@ -385,7 +440,7 @@ func TestPg_GetConfirmedReferralTrackingCount(t *testing.T) {
 	require.NoError(t, err)
 
 	require.NoError(t, s.CreateReferralTracking(ctx, receiverAddr, r.OwnReferralCode))
-	require.NoError(t, s.TransitionReferralTrackingToConfirmed(ctx, receiverAddr, sdk.NewInt(10), sdk.NewInt(10)))
+	require.NoError(t, s.TransitionReferralTrackingToConfirmed(ctx, receiverAddr, sdk.NewInt(10), sdk.NewInt(10), sql.NullInt64{}))
 
 	count, err = s.GetConfirmedReferralTrackingCount(ctx, "sender")
 	require.NoError(t, err)
@@ -438,6 +493,319 @@ func TestPg_GetUnconfirmedReferralTracking(t *testing.T) {
 	requireNoUnconfirmed()
 }
 
+func TestPg_CreateReferralUpperLevelReward(t *testing.T) {
+	defer cleanup(t)
+
+	require.NoError(t, s.UpsertRequest(ctx, "owner", "e@mail.com", "sender", "code", sql.NullString{}))
+	r, err := s.GetRequestByOwner(ctx, "owner")
+	require.NoError(t, err)
+
+	require.NoError(t, s.CreateReferralTracking(ctx, "receiver", r.OwnReferralCode))
+
+	require.NoError(t, s.CreateReferralUpperLevelReward(ctx, "receiver", 2, "upper", sdk.NewInt(1000000)))
+	require.Error(t, s.CreateReferralUpperLevelReward(ctx, "receiver", 2, "upper", sdk.NewInt(1000000)))
+	require.Error(t, s.CreateReferralUpperLevelReward(ctx, "receiver", 1, "sender", sdk.NewInt(1000000)))
+
+	var reward int64
+	require.NoError(t, db.QueryRowContext(ctx, `
+		SELECT reward FROM referral_upper_level_reward WHERE receiver = 'receiver' AND level = 2 AND sender = 'upper'`,
+	).Scan(&reward))
+	require.Equal(t, int64(1000000), reward)
+}
+
+func TestPg_SupplyHistory(t *testing.T) {
+	defer cleanup(t)
+
+	day := time.Date(2022, 10, 17, 0, 0, 0, 0, time.UTC)
+	snapshot := func(at time.Time, circulating int64) storage.SupplySnapshot {
+		return storage.SupplySnapshot{
+			CreatedAt:   at,
+			Native:      sdk.NewInt(1),
+			ERC20:       sdk.NewInt(2),
+			Total:       sdk.NewInt(3),
+			Circulating: sdk.NewInt(circulating),
+		}
+	}
+
+	require.NoError(t, s.CreateSupplySnapshot(ctx, snapshot(day.Add(time.Hour), 1)))
+	require.NoError(t, s.CreateSupplySnapshot(ctx, snapshot(day.Add(2*time.Hour), 2)))
+	require.NoError(t, s.CreateSupplySnapshot(ctx, snapshot(day.Add(25*time.Hour), 3)))
+	// too late
+	require.NoError(t, s.CreateSupplySnapshot(ctx, snapshot(day.Add(72*time.Hour), 4)))
+
+	history, err := s.GetSupplyHistory(ctx, day, day.Add(48*time.Hour), 24*time.Hour)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+
+	require.Equal(t, day, history[0].CreatedAt.UTC())
+	require.Equal(t, "2", history[0].Circulating.String())
+	require.Equal(t, "3", history[0].Total.String())
+	require.Equal(t, day.Add(24*time.Hour), history[1].CreatedAt.UTC())
+	require.Equal(t, "3", history[1].Circulating.String())
+}
+
+func TestPg_ReferralBan(t *testing.T) {
+	defer cleanup(t)
+
+	const (
+		receiverAddr = "receiver"
+		senderAddr   = "sender"
+	)
+
+	require.True(t, errors.Is(s.SetReferralBanned(ctx, senderAddr, true), storage.ErrNotFound))
+
+	require.NoError(t, s.UpsertRequest(ctx, "owner", "e@mail.com", senderAddr, "code", sql.NullString{}))
+	r, err := s.GetRequestByOwner(ctx, "owner")
+	require.NoError(t, err)
+
+	require.NoError(t, s.CreateReferralTracking(ctx, receiverAddr, r.OwnReferralCode))
+	require.NoError(t, s.TransitionReferralTrackingToInstalled(ctx, receiverAddr))
+
+	require.True(t, errors.Is(s.SetReferralBanned(ctx, senderAddr, false), storage.ErrReferralBannedUnchanged))
+	require.NoError(t, s.SetReferralBanned(ctx, senderAddr, true))
+	require.NoError(t, s.CreateReferralBanEvent(ctx, senderAddr, true, "fraud"))
+	// already banned
+	require.True(t, errors.Is(s.SetReferralBanned(ctx, senderAddr, true), storage.ErrReferralBannedUnchanged))
+
+	r, err = s.GetRequestByAddress(ctx, senderAddr)
+	require.NoError(t, err)
+	require.True(t, r.ReferralBanned)
+
+	cancelled, err := s.CancelPendingReferralTracking(ctx, senderAddr)
+	require.NoError(t, err)
+	require.Equal(t, 1, cancelled)
+
+	// already cancelled
+	cancelled, err = s.CancelPendingReferralTracking(ctx, senderAddr)
+	require.NoError(t, err)
+	require.Equal(t, 0, cancelled)
+
+	require.NoError(t, s.SetReferralBanned(ctx, senderAddr, false))
+	require.NoError(t, s.CreateReferralBanEvent(ctx, senderAddr, false, "appeal"))
+
+	_, err = db.ExecContext(ctx, `UPDATE referral_tracking SET installed_at = NOW() - '31 day'::interval`)
+	require.NoError(t, err)
+
+	// cancelled referrals are not rewarded even after unban
+	referrals, err := s.GetUnconfirmedReferralTracking(ctx, 30)
+	require.NoError(t, err)
+	require.Len(t, referrals, 0)
+
+	events, err := s.GetReferralBanEvents(ctx, senderAddr)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	require.False(t, events[0].Banned)
+	require.Equal(t, "appeal", events[0].Reason)
+	require.True(t, events[1].Banned)
+	require.Equal(t, "fraud", events[1].Reason)
+}
+
+func TestPg_ReferralCodeAlias(t *testing.T) {
+	defer cleanup(t)
+
+	require.NoError(t, s.UpsertRequest(ctx, "owner", "e@mail.com", "sender", "code", sql.NullString{}))
+	require.NoError(t, s.UpsertRequest(ctx, "owner2", "e2@mail.com", "sender2", "code", sql.NullString{}))
+	r, err := s.GetRequestByOwner(ctx, "owner")
+	require.NoError(t, err)
+	r2, err := s.GetRequestByOwner(ctx, "owner2")
+	require.NoError(t, err)
+
+	oldCode := r.OwnReferralCode
+
+	require.NoError(t, s.CreateReferralCodeAlias(ctx, "owner", oldCode))
+	require.NoError(t, s.CreateReferralCodeAlias(ctx, "owner", "alice"))
+	// idempotent for the same owner
+	require.NoError(t, s.CreateReferralCodeAlias(ctx, "owner", "alice"))
+	require.True(t, errors.Is(s.CreateReferralCodeAlias(ctx, "owner2", "alice"), storage.ErrReferralCodeIsTaken))
+
+	require.NoError(t, s.SetOwnReferralCode(ctx, "owner", "alice"))
+	require.True(t, errors.Is(s.SetOwnReferralCode(ctx, "owner", r2.OwnReferralCode), storage.ErrReferralCodeIsTaken))
+	require.True(t, errors.Is(s.SetOwnReferralCode(ctx, "unknown", "bob"), storage.ErrNotFound))
+
+	for _, code := range []string{"alice", oldCode} {
+		r, err = s.GetRequestByOwnReferralCode(ctx, code)
+		require.NoError(t, err)
+		require.Equal(t, "owner", r.Owner)
+		require.Equal(t, "alice", r.OwnReferralCode)
+	}
+
+	// registration with the old code is tracked for the owner
+	require.NoError(t, s.CreateReferralTracking(ctx, "receiver", oldCode))
+	rt, err := s.GetReferralTrackingByReceiver(ctx, "receiver")
+	require.NoError(t, err)
+	require.Equal(t, "sender", rt.Sender)
+}
+
+func TestPg_ReferralCampaign(t *testing.T) {
+	defer cleanup(t)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	multiplier := sdk.MustNewFurFromStr("1.5")
+	fixed := sdk.NewInt(100)
+
+	id1, err := s.CreateReferralCampaign(ctx, storage.ReferralCampaign{
+		Name:       "active",
+		StartsAt:   now.Add(-time.Hour),
+		EndsAt:     now.Add(time.Hour),
+		Multiplier: &multiplier,
+		Codes:      []string{"code"},
+	})
+	require.NoError(t, err)
+
+	id2, err := s.CreateReferralCampaign(ctx, storage.ReferralCampaign{
+		Name:        "finished",
+		StartsAt:    now.Add(-2 * time.Hour),
+		EndsAt:      now.Add(-time.Hour),
+		FixedReward: &fixed,
+		Senders:     []string{"sender"},
+	})
+	require.NoError(t, err)
+
+	_, err = s.CreateReferralCampaign(ctx, storage.ReferralCampaign{
+		Name:     "no reward",
+		StartsAt: now,
+		EndsAt:   now.Add(time.Hour),
+	})
+	require.Error(t, err)
+
+	campaigns, err := s.GetReferralCampaigns(ctx)
+	require.NoError(t, err)
+	require.Len(t, campaigns, 2)
+	require.Equal(t, id2, campaigns[0].ID)
+	require.Equal(t, fixed, *campaigns[0].FixedReward)
+	require.Nil(t, campaigns[0].Multiplier)
+	require.Equal(t, []string{"sender"}, campaigns[0].Senders)
+	require.Empty(t, campaigns[0].Codes)
+
+	campaigns, err = s.GetReferralCampaignsActiveBetween(ctx, now, now)
+	require.NoError(t, err)
+	require.Len(t, campaigns, 1)
+	require.Equal(t, id1, campaigns[0].ID)
+	require.True(t, multiplier.Equal(*campaigns[0].Multiplier))
+	require.Nil(t, campaigns[0].FixedReward)
+	require.Equal(t, []string{"code"}, campaigns[0].Codes)
+
+	// the finished campaign was active within the window
+	campaigns, err = s.GetReferralCampaignsActiveBetween(ctx, now.Add(-90*time.Minute), now)
+	require.NoError(t, err)
+	require.Len(t, campaigns, 2)
+	require.Equal(t, id1, campaigns[0].ID)
+	require.Equal(t, id2, campaigns[1].ID)
+
+	campaigns, err = s.GetReferralCampaignsActiveBetween(ctx, now.Add(-3*time.Hour), now.Add(-150*time.Minute))
+	require.NoError(t, err)
+	require.Empty(t, campaigns)
+
+	// campaign is recorded on payout
+	require.NoError(t, s.UpsertRequest(ctx, "owner", "e@mail.com", "sender", "code", sql.NullString{}))
+	r, err := s.GetRequestByOwner(ctx, "owner")
+	require.NoError(t, err)
+	require.NoError(t, s.CreateReferralTracking(ctx, "receiver", r.OwnReferralCode))
+	require.NoError(t, s.TransitionReferralTrackingToConfirmed(ctx, "receiver", sdk.NewInt(15), sdk.NewInt(5),
+		sql.NullInt64{Valid: true, Int64: int64(id1)}))
+
+	rt, err := s.GetReferralTrackingByReceiver(ctx, "receiver")
+	require.NoError(t, err)
+	require.Equal(t, sql.NullInt64{Valid: true, Int64: int64(id1)}, rt.CampaignID)
+}
+
+func TestPg_Nonce(t *testing.T) {
+	defer func() {
+		_, err := db.ExecContext(ctx, "DELETE FROM auth_nonce")
+		require.NoError(t, err)
+	}()
+
+	expiresAt := time.Now().UTC().Add(time.Minute)
+
+	require.NoError(t, s.ConsumeNonce(ctx, "address", "purpose", "nonce", expiresAt))
+	// single use
+	require.True(t, errors.Is(s.ConsumeNonce(ctx, "address", "purpose", "nonce", expiresAt), storage.ErrNonceUsed))
+	// another purpose and address
+	require.NoError(t, s.ConsumeNonce(ctx, "address", "other", "nonce", expiresAt))
+	require.NoError(t, s.ConsumeNonce(ctx, "another", "purpose", "nonce", expiresAt))
+
+	// expired ones are deleted after the retention
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO auth_nonce (address, purpose, nonce, expires_at) VALUES
+		('address', 'purpose', 'expired', $1), ('address', 'purpose', 'just expired', $2)`,
+		time.Now().UTC().Add(-2*nonceRetention), time.Now().UTC().Add(-time.Second))
+	require.NoError(t, err)
+
+	require.NoError(t, s.ConsumeNonce(ctx, "address", "purpose", "next", expiresAt))
+
+	var count int
+	require.NoError(t, db.QueryRowContext(ctx, `SELECT COUNT(*) FROM auth_nonce WHERE purpose = 'purpose'`).Scan(&count))
+	require.Equal(t, 4, count)
+}
+
+func TestPg_GetReferralTrackingBySender(t *testing.T) {
+	defer cleanup(t)
+
+	require.NoError(t, s.UpsertRequest(ctx, "owner", "e@mail.com", "sender", "code", sql.NullString{}))
+	r, err := s.GetRequestByOwner(ctx, "owner")
+	require.NoError(t, err)
+
+	require.NoError(t, s.CreateReferralTracking(ctx, "receiver1", r.OwnReferralCode))
+	require.NoError(t, s.CreateReferralTracking(ctx, "receiver2", r.OwnReferralCode))
+	require.NoError(t, s.TransitionReferralTrackingToInstalled(ctx, "receiver2"))
+
+	referrals, err := s.GetReferralTrackingBySender(ctx, "sender", 10, 0)
+	require.NoError(t, err)
+	require.Len(t, referrals, 2)
+
+	referrals, err = s.GetReferralTrackingBySender(ctx, "sender", 1, 1)
+	require.NoError(t, err)
+	require.Len(t, referrals, 1)
+
+	referrals, err = s.GetReferralTrackingBySender(ctx, "unknown", 10, 0)
+	require.NoError(t, err)
+	require.Len(t, referrals, 0)
+}
+
+func TestPg_GetReferralLeaderboard(t *testing.T) {
+	defer cleanup(t)
+
+	const (
+		receiverAddr = "receiver"
+		senderAddr   = "sender"
+	)
+
+	require.NoError(t, s.UpsertRequest(ctx, "owner", "e@mail.com", senderAddr, "code", sql.NullString{}))
+	r, err := s.GetRequestByOwner(ctx, "owner")
+	require.NoError(t, err)
+
+	require.NoError(t, s.CreateReferralTracking(ctx, receiverAddr, r.OwnReferralCode))
+	require.NoError(t, s.TransitionReferralTrackingToInstalled(ctx, receiverAddr))
+	require.NoError(t, s.TransitionReferralTrackingToConfirmed(ctx, receiverAddr, sdk.NewInt(10), sdk.NewInt(5), sql.NullInt64{}))
+
+	// not refreshed yet
+	items, err := s.GetReferralLeaderboard(ctx, storage.LeaderboardWindowAll, 10)
+	require.NoError(t, err)
+	require.Len(t, items, 0)
+
+	require.NoError(t, s.RefreshReferralLeaderboard(ctx))
+
+	for _, window := range []storage.LeaderboardWindow{
+		storage.LeaderboardWindow7Days, storage.LeaderboardWindow30Days, storage.LeaderboardWindowAll,
+	} {
+		items, err = s.GetReferralLeaderboard(ctx, window, 10)
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		require.Equal(t, senderAddr, items[0].Sender)
+		require.Equal(t, 1, items[0].Confirmed)
+		require.Equal(t, sdk.NewInt(10), items[0].Reward)
+	}
+
+	// banned senders are hidden
+	require.NoError(t, s.SetReferralBanned(ctx, senderAddr, true))
+	items, err = s.GetReferralLeaderboard(ctx, storage.LeaderboardWindowAll, 10)
+	require.NoError(t, err)
+	require.Len(t, items, 0)
+
+	_, err = s.GetReferralLeaderboard(ctx, "1y", 10)
+	require.Error(t, err)
+}
+
 func TestPg_DoesEmailHaveFraudDomain(t *testing.T) {
 	check, err := s.DoesEmailHaveFraudDomain(context.Background(), "valid@gmail.com")
 	require.NoError(t, err)
@@ -509,7 +877,7 @@ func TestPg_GetReferralTrackingStats(t *testing.T) {
 	}, *stats[1])
 
 	// confirmed
-	require.NoError(t, s.TransitionReferralTrackingToConfirmed(ctx, receiverAddr, sdk.NewInt(10), sdk.NewInt(5)))
+	require.NoError(t, s.TransitionReferralTrackingToConfirmed(ctx, receiverAddr, sdk.NewInt(10), sdk.NewInt(5), sql.NullInt64{}))
 	stats, err = s.GetReferralTrackingStats(ctx, senderArr)
 	require.NoError(t, err)
 	require.Len(t, stats, 2)
@@ -526,3 +894,198 @@ func TestPg_GetReferralTrackingStats(t *testing.T) {
 		Reward:     sdk.NewInt(10),
 	}, *stats[1])
 }
+
+func TestPg_CreateEvent(t *testing.T) {
+	defer cleanup(t)
+
+	e := storage.Event{
+		ID:        "6b7c4f4e-3b8e-4a44-9f43-0d2b1f1a8c11",
+		Type:      "confirmed",
+		Payload:   []byte(`{"address":"addr"}`),
+		CreatedAt: time.Now(),
+	}
+	require.NoError(t, s.CreateEvent(ctx, e))
+	// idempotent
+	require.NoError(t, s.CreateEvent(ctx, e))
+
+	var count int
+	require.NoError(t, db.QueryRowContext(ctx, "SELECT COUNT(*) FROM events WHERE payload->>'address' = 'addr'").Scan(&count))
+	require.Equal(t, 1, count)
+}
+
+func TestPg_WebhookDeliveries(t *testing.T) {
+	defer cleanup(t)
+
+	all, err := s.CreateWebhookSubscription(ctx, storage.WebhookSubscription{
+		URL:    "https://partner.com/all",
+		Secret: "secret",
+		Events: []string{"referral_installed", "reward_paid"},
+	})
+	require.NoError(t, err)
+	_, err = s.CreateWebhookSubscription(ctx, storage.WebhookSubscription{
+		URL:    "https://partner.com/other",
+		Secret: "secret",
+		Events: []string{"reward_paid"},
+		Sender: sql.NullString{Valid: true, String: "other"},
+	})
+	require.NoError(t, err)
+
+	subscriptions, err := s.GetWebhookSubscriptions(ctx)
+	require.NoError(t, err)
+	require.Len(t, subscriptions, 2)
+	require.Equal(t, []string{"reward_paid"}, subscriptions[0].Events)
+	require.Equal(t, "other", subscriptions[0].Sender.String)
+
+	const eventID = "6b7c4f4e-3b8e-4a44-9f43-0d2b1f1a8c11"
+	n, err := s.CreateWebhookDeliveries(ctx, eventID, "reward_paid", "sender", []byte(`{"id":"1"}`))
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	// idempotent
+	n, err = s.CreateWebhookDeliveries(ctx, eventID, "reward_paid", "sender", []byte(`{"id":"1"}`))
+	require.NoError(t, err)
+	require.Equal(t, 0, n)
+
+	deliveries, err := s.ClaimWebhookDeliveries(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	require.Equal(t, all, deliveries[0].SubscriptionID)
+	require.JSONEq(t, `{"id":"1"}`, string(deliveries[0].Payload))
+
+	// leased
+	deliveries, err = s.ClaimWebhookDeliveries(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 0)
+
+	pending, err := s.GetWebhookDeliveries(ctx, storage.PendingWebhookDeliveryStatus, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	id := pending[0].ID
+
+	require.NoError(t, s.SetWebhookDeliveryFailed(ctx, id, "unexpected status: 502", sql.NullTime{}))
+
+	dead, err := s.GetWebhookDeliveries(ctx, storage.DeadWebhookDeliveryStatus, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, dead, 1)
+	require.Equal(t, 1, dead[0].Attempts)
+	require.Equal(t, "unexpected status: 502", dead[0].LastError.String)
+
+	require.NoError(t, s.ReplayWebhookDelivery(ctx, id))
+	require.True(t, errors.Is(s.ReplayWebhookDelivery(ctx, id), storage.ErrNotFound))
+
+	deliveries, err = s.ClaimWebhookDeliveries(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	require.Equal(t, 0, deliveries[0].Attempts)
+
+	require.NoError(t, s.SetWebhookDeliveryDelivered(ctx, id))
+	delivered, err := s.GetWebhookDeliveries(ctx, storage.DeliveredWebhookDeliveryStatus, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, delivered, 1)
+	require.True(t, delivered[0].DeliveredAt.Valid)
+
+	require.NoError(t, s.DeleteWebhookSubscription(ctx, all))
+	require.True(t, errors.Is(s.DeleteWebhookSubscription(ctx, all), storage.ErrNotFound))
+}
+
+func TestPg_Distribution(t *testing.T) {
+	defer cleanup(t)
+
+	_, err := s.GetDistributionRun(ctx, "initial")
+	require.True(t, errors.Is(err, storage.ErrNotFound))
+
+	run, err := s.CreateDistributionRun(ctx, "initial")
+	require.NoError(t, err)
+	require.Equal(t, "initial", run.Name)
+	require.False(t, run.FinishedAt.Valid)
+
+	// existing
+	same, err := s.CreateDistributionRun(ctx, "initial")
+	require.NoError(t, err)
+	require.Equal(t, run.ID, same.ID)
+
+	n, err := s.CreateDistributionItems(ctx, run.ID, []storage.DistributionItem{
+		{Address: "b", Amount: sdk.NewInt(10)},
+		{Address: "a", Amount: sdk.NewInt(20)},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+
+	// new addresses only, the existing amount isn't changed
+	n, err = s.CreateDistributionItems(ctx, run.ID, []storage.DistributionItem{
+		{Address: "a", Amount: sdk.NewInt(30)},
+		{Address: "c", Amount: sdk.NewInt(1000000000000000000)},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+
+	items, err := s.GetDistributionItems(ctx, run.ID)
+	require.NoError(t, err)
+	require.Len(t, items, 3)
+	require.Equal(t, "b", items[0].Address)
+	require.Equal(t, "a", items[1].Address)
+	require.Equal(t, sdk.NewInt(20), items[1].Amount)
+	require.Equal(t, sdk.NewInt(1000000000000000000), items[2].Amount)
+	for _, v := range items {
+		require.Equal(t, storage.PendingDistributionItemStatus, v.Status)
+	}
+
+	ids := []int{items[0].ID, items[1].ID}
+	require.NoError(t, s.SetDistributionItemsSending(ctx, ids, "distribution:1"))
+	require.NoError(t, s.SetDistributionItemsError(ctx, ids, "timeout"))
+
+	items, err = s.GetDistributionItems(ctx, run.ID)
+	require.NoError(t, err)
+	require.Equal(t, storage.SendingDistributionItemStatus, items[0].Status)
+	require.Equal(t, "distribution:1", items[0].Memo.String)
+	require.Equal(t, "timeout", items[0].LastError.String)
+	require.Equal(t, 1, items[0].Attempts)
+	require.False(t, items[2].Memo.Valid)
+
+	require.NoError(t, s.SetDistributionItemsFailed(ctx, ids, "insufficient funds"))
+	require.NoError(t, s.SetDistributionItemsSending(ctx, []int{items[1].ID}, "distribution:2"))
+	require.NoError(t, s.SetDistributionItemsSent(ctx, []int{items[1].ID}))
+
+	items, err = s.GetDistributionItems(ctx, run.ID)
+	require.NoError(t, err)
+	require.Equal(t, storage.FailedDistributionItemStatus, items[0].Status)
+	require.Equal(t, "insufficient funds", items[0].LastError.String)
+	require.Equal(t, 1, items[0].Attempts)
+	require.Equal(t, storage.SentDistributionItemStatus, items[1].Status)
+	require.Equal(t, "distribution:2", items[1].Memo.String)
+	require.False(t, items[1].LastError.Valid)
+	require.True(t, items[1].SentAt.Valid)
+	require.Equal(t, 2, items[1].Attempts)
+	require.Equal(t, storage.PendingDistributionItemStatus, items[2].Status)
+
+	require.NoError(t, s.SetDistributionRunFinished(ctx, run.ID))
+	run, err = s.GetDistributionRun(ctx, "initial")
+	require.NoError(t, err)
+	require.True(t, run.FinishedAt.Valid)
+}
+
+func TestLockMigrations(t *testing.T) {
+	unlock, err := LockMigrations(ctx, db)
+	require.NoError(t, err)
+
+	tctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+
+	_, err = LockMigrations(tctx, db)
+	require.True(t, errors.Is(err, ErrMigrationLocked))
+
+	unlock()
+
+	unlock, err = LockMigrations(ctx, db)
+	require.NoError(t, err)
+	unlock()
+}
+
+func TestGetMigrationVersion(t *testing.T) {
+	latest, err := LatestMigration()
+	require.NoError(t, err)
+
+	version, dirty, err := GetMigrationVersion(ctx, db)
+	require.NoError(t, err)
+	require.False(t, dirty)
+	require.Equal(t, latest, version)
+}
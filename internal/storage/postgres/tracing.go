@@ -0,0 +1,69 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/TessorNetwork/vulcan/internal/tracing"
+)
+
+// tracedExt starts a span for every query, the span of a select query ends before its rows are read.
+type tracedExt struct {
+	sqlx.ExtContext
+}
+
+func (e tracedExt) ExecContext(ctx context.Context, query string, args ...interface{}) (_ sql.Result, err error) {
+	ctx, span := startQuery(ctx, query)
+	defer func() { tracing.End(span, err) }()
+
+	return e.ExtContext.ExecContext(ctx, query, args...)
+}
+
+func (e tracedExt) QueryContext(ctx context.Context, query string, args ...interface{}) (_ *sql.Rows, err error) {
+	ctx, span := startQuery(ctx, query)
+	defer func() { tracing.End(span, err) }()
+
+	return e.ExtContext.QueryContext(ctx, query, args...) // nolint: sqlclosecheck
+}
+
+func (e tracedExt) QueryxContext(ctx context.Context, query string, args ...interface{}) (_ *sqlx.Rows, err error) {
+	ctx, span := startQuery(ctx, query)
+	defer func() { tracing.End(span, err) }()
+
+	return e.ExtContext.QueryxContext(ctx, query, args...) // nolint: sqlclosecheck
+}
+
+func (e tracedExt) QueryRowxContext(ctx context.Context, query string, args ...interface{}) *sqlx.Row {
+	ctx, span := startQuery(ctx, query)
+
+	row := e.ExtContext.QueryRowxContext(ctx, query, args...)
+	// sql.ErrNoRows is returned only by Scan
+	tracing.End(span, row.Err())
+
+	return row
+}
+
+// startQuery starts the span named by the query operation, e.g. "postgres SELECT".
+func startQuery(ctx context.Context, query string) (context.Context, trace.Span) {
+	query = strings.Join(strings.Fields(query), " ")
+
+	operation := query
+	if i := strings.IndexByte(query, ' '); i > 0 {
+		operation = query[:i]
+	}
+	operation = strings.ToUpper(operation)
+
+	return tracing.Start(ctx, "postgres "+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBOperationKey.String(operation),
+			semconv.DBStatementKey.String(query),
+		),
+	)
+}
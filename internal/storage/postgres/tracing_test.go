@@ -0,0 +1,54 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/TessorNetwork/vulcan/internal/tracing"
+)
+
+type execer struct {
+	sqlx.ExtContext
+	err error
+}
+
+func (e execer) ExecContext(ctx context.Context, _ string, _ ...interface{}) (sql.Result, error) {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		panic("query is called without the span")
+	}
+	return nil, e.err
+}
+
+func TestTracedExt(t *testing.T) {
+	exporter, restore := tracing.NewInMemoryExporter()
+	defer restore()
+
+	ctx := tracing.WithRequestID(context.Background(), "id")
+
+	_, err := tracedExt{execer{}}.ExecContext(ctx, `
+		update request
+		SET confirmed_at = NOW() WHERE owner=$1`, "owner")
+	require.NoError(t, err)
+
+	_, err = tracedExt{execer{err: sql.ErrConnDone}}.ExecContext(ctx, `DELETE FROM request`)
+	require.Error(t, err)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+
+	require.Equal(t, "postgres UPDATE", spans[0].Name)
+	require.Contains(t, spans[0].Attributes, attribute.String("db.statement",
+		"update request SET confirmed_at = NOW() WHERE owner=$1"))
+	require.Contains(t, spans[0].Attributes, tracing.RequestIDKey.String("id"))
+	require.Equal(t, codes.Unset, spans[0].Status.Code)
+
+	require.Equal(t, "postgres DELETE", spans[1].Name)
+	require.Equal(t, codes.Error, spans[1].Status.Code)
+}
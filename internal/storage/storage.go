@@ -24,6 +24,15 @@ var ErrReferralTrackingExists = fmt.Errorf("referral tracking exists")
 // ErrReferralCodeNotFound ...
 var ErrReferralCodeNotFound = fmt.Errorf("referral code not found")
 
+// ErrReferralCodeIsTaken ...
+var ErrReferralCodeIsTaken = fmt.Errorf("referral code is taken")
+
+// ErrReferralBannedUnchanged is returned when the referrer already has the requested ban state.
+var ErrReferralBannedUnchanged = fmt.Errorf("referral banned is unchanged")
+
+// ErrNonceUsed is returned when the nonce is already consumed.
+var ErrNonceUsed = fmt.Errorf("nonce is already used")
+
 // Request ...
 type Request struct {
 	Owner                    string         `db:"owner"`
@@ -67,8 +76,34 @@ type ReferralTracking struct {
 	RegisteredAt   time.Time      `db:"registered_at"`
 	InstalledAt    sql.NullTime   `db:"installed_at"`
 	ConfirmedAt    sql.NullTime   `db:"confirmed_at"`
-	SenderReward   sql.NullInt32  `db:"sender_reward"`
-	ReceiverReward sql.NullInt32  `db:"receiver_reward"`
+	SenderReward   sql.NullInt64  `db:"sender_reward"`
+	ReceiverReward sql.NullInt64  `db:"receiver_reward"`
+	CancelledAt    sql.NullTime   `db:"cancelled_at"`
+	CampaignID     sql.NullInt64  `db:"campaign_id"`
+}
+
+// ReferralCampaign changes the sender reward of referrals registered within the period.
+// Exactly one of Multiplier and FixedReward is set.
+// Empty Codes and Senders mean the campaign applies to all referrals.
+type ReferralCampaign struct {
+	ID          int
+	Name        string
+	StartsAt    time.Time
+	EndsAt      time.Time
+	Multiplier  *sdk.Fur
+	FixedReward *sdk.Int
+	Codes       []string
+	Senders     []string
+	CreatedAt   time.Time
+}
+
+// ReferralBanEvent is an audit record of a referrer ban or unban.
+type ReferralBanEvent struct {
+	ID        int       `db:"id"`
+	Address   string    `db:"address"`
+	Banned    bool      `db:"banned"`
+	Reason    string    `db:"reason"`
+	CreatedAt time.Time `db:"created_at"`
 }
 
 // ReferralTrackingStats ...
@@ -79,12 +114,146 @@ type ReferralTrackingStats struct {
 	Reward     sdk.Int `db:"reward"`
 }
 
+// LeaderboardWindow is a period the referral leaderboard is calculated for.
+type LeaderboardWindow string
+
+// Leaderboard windows.
+const (
+	LeaderboardWindow7Days  LeaderboardWindow = "7d"
+	LeaderboardWindow30Days LeaderboardWindow = "30d"
+	LeaderboardWindowAll    LeaderboardWindow = "all"
+)
+
+// ReferralLeaderboardItem ...
+type ReferralLeaderboardItem struct {
+	Sender    string  `db:"sender"`
+	Confirmed int     `db:"confirmed"`
+	Reward    sdk.Int `db:"reward"`
+}
+
+// SupplySnapshot is the supply at the given time. Amounts are in ufury.
+type SupplySnapshot struct {
+	CreatedAt   time.Time
+	Native      sdk.Int
+	ERC20       sdk.Int
+	Total       sdk.Int
+	Circulating sdk.Int
+}
+
+// Event is a stored domain event. Payload is the JSON encoded event.
+type Event struct {
+	ID        string    `db:"id"`
+	Type      string    `db:"type"`
+	Payload   []byte    `db:"payload"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// WebhookSubscription is a partner's subscription to referral events.
+// Empty Sender means events of all senders.
+type WebhookSubscription struct {
+	ID        int
+	URL       string
+	Secret    string
+	Events    []string
+	Sender    sql.NullString
+	CreatedAt time.Time
+}
+
+// WebhookDeliveryStatus represents a webhook delivery status: pending -> delivered or pending -> dead.
+type WebhookDeliveryStatus string
+
+const (
+	// PendingWebhookDeliveryStatus means the delivery is waiting for the next attempt.
+	PendingWebhookDeliveryStatus WebhookDeliveryStatus = "pending"
+	// DeliveredWebhookDeliveryStatus means the partner accepted the event.
+	DeliveredWebhookDeliveryStatus WebhookDeliveryStatus = "delivered"
+	// DeadWebhookDeliveryStatus means all attempts failed, the delivery can be replayed manually.
+	DeadWebhookDeliveryStatus WebhookDeliveryStatus = "dead"
+)
+
+// WebhookDelivery is a delivery of the event to the webhook subscription. Payload is the JSON encoded event.
+type WebhookDelivery struct {
+	ID             int                   `db:"id"`
+	SubscriptionID int                   `db:"subscription_id"`
+	EventID        string                `db:"event_id"`
+	EventType      string                `db:"event_type"`
+	Payload        []byte                `db:"payload"`
+	Status         WebhookDeliveryStatus `db:"status"`
+	Attempts       int                   `db:"attempts"`
+	NextAttemptAt  time.Time             `db:"next_attempt_at"`
+	LastError      sql.NullString        `db:"last_error"`
+	CreatedAt      time.Time             `db:"created_at"`
+	DeliveredAt    sql.NullTime          `db:"delivered_at"`
+}
+
+// DistributionRun is a named distribution of stakes, e.g. the initial one. It is finished when all its items are sent.
+type DistributionRun struct {
+	ID         int          `db:"id"`
+	Name       string       `db:"name"`
+	CreatedAt  time.Time    `db:"created_at"`
+	FinishedAt sql.NullTime `db:"finished_at"`
+}
+
+// DistributionItemStatus represents a distribution item status: pending -> sending -> sent or failed,
+// a failed item goes through sending again.
+type DistributionItemStatus string
+
+const (
+	// PendingDistributionItemStatus means the stakes are waiting to be sent.
+	PendingDistributionItemStatus DistributionItemStatus = "pending"
+	// SendingDistributionItemStatus means the stakes are being broadcast with the item memo
+	// or the broadcast result is unknown, the item isn't sent again until it's resolved.
+	SendingDistributionItemStatus DistributionItemStatus = "sending"
+	// SentDistributionItemStatus means the stakes are sent.
+	SentDistributionItemStatus DistributionItemStatus = "sent"
+	// FailedDistributionItemStatus means the stakes aren't sent, the item is retried by the next run.
+	FailedDistributionItemStatus DistributionItemStatus = "failed"
+)
+
+// DistributionItem is a payout of the distribution run to the address.
+type DistributionItem struct {
+	ID        int
+	RunID     int
+	Address   string
+	Amount    sdk.Int
+	Status    DistributionItemStatus
+	Attempts  int
+	LastError sql.NullString
+	// Memo is the memo of the last broadcast transaction, it's used to find the transaction on chain.
+	Memo      sql.NullString
+	CreatedAt time.Time
+	SentAt    sql.NullTime
+}
+
 // RegisterStats ...
 type RegisterStats struct {
 	Date  time.Time `json:"date"`
 	Value int       `json:"value"`
 }
 
+// Granularity is a period of the time series item.
+type Granularity string
+
+// Granularity values.
+const (
+	GranularityDay   Granularity = "day"
+	GranularityWeek  Granularity = "week"
+	GranularityMonth Granularity = "month"
+)
+
+// RegistrationFunnelItem is the number of registration events within the period starting at Date.
+// Every event is counted at its own time, e.g. a registration started and confirmed on different days
+// is counted as Started on the first day and as Confirmed on the second one.
+type RegistrationFunnelItem struct {
+	Date               time.Time `db:"date"`
+	Started            int       `db:"started"`
+	EmailsSent         int       `db:"emails_sent"`
+	Confirmed          int       `db:"confirmed"`
+	ReferralRegistered int       `db:"referral_registered"`
+	Installed          int       `db:"installed"`
+	Rewarded           int       `db:"rewarded"`
+}
+
 // Storage provides methods for interacting with database.
 type Storage interface {
 	// InTx runs code in transaction
@@ -93,10 +262,17 @@ type Storage interface {
 	GetConfirmedRegistrationsTotal(ctx context.Context) (int, error)
 	// GetConfirmedRegistrationsStats return confirmed accounts stats for the last 30 days
 	GetConfirmedRegistrationsStats(ctx context.Context) ([]*RegisterStats, error)
+	// GetRegistrationFunnel returns registration events of every period within [from, to) including empty ones.
+	// from is truncated to the granularity.
+	GetRegistrationFunnel(ctx context.Context, from, to time.Time, granularity Granularity) ([]*RegistrationFunnelItem, error)
 	// GetRequestByOwner returns request by owner.
 	GetRequestByOwner(ctx context.Context, owner string) (*Request, error)
-	// GetRequestByOwnReferralCode returns request by referral code.
+	// GetRequestByOwnReferralCode returns request by referral code or its alias.
 	GetRequestByOwnReferralCode(ctx context.Context, ownReferralCode string) (*Request, error)
+	// CreateReferralCodeAlias reserves the referral code for the owner. Does nothing if the owner already has it.
+	CreateReferralCodeAlias(ctx context.Context, owner, code string) error
+	// SetOwnReferralCode changes the owner's referral code.
+	SetOwnReferralCode(ctx context.Context, owner, code string) error
 	// GetRequestByAddress returns request by address.
 	GetRequestByAddress(ctx context.Context, address string) (*Request, error)
 	// SetConfirmed sets request confirmed.
@@ -110,11 +286,29 @@ type Storage interface {
 	// TransitionReferralTrackingToInstalled transitions referral tracking of the given referral code receiver as installed
 	TransitionReferralTrackingToInstalled(ctx context.Context, receiver string) error
 	// TransitionReferralTrackingToConfirmed transitions referral tracking as confirmed
-	TransitionReferralTrackingToConfirmed(ctx context.Context, receiver string, senderReward, receiverReward sdk.Int) error
+	TransitionReferralTrackingToConfirmed(ctx context.Context, receiver string, senderReward, receiverReward sdk.Int,
+		campaignID sql.NullInt64) error
+	// CreateReferralUpperLevelReward stores a reward of the sender's referrer at the given level (2 for the sender's referrer).
+	CreateReferralUpperLevelReward(ctx context.Context, receiver string, level int, sender string, reward sdk.Int) error
 	// GetReferralTrackingByReceiver returns referral tracking by the given receiver address
 	GetReferralTrackingByReceiver(ctx context.Context, receiver string) (*ReferralTracking, error)
+	// ConsumeNonce stores the nonce as used until it expires and deletes expired ones.
+	// Returns ErrNonceUsed if the nonce is already used.
+	ConsumeNonce(ctx context.Context, address, purpose, nonce string, expiresAt time.Time) error
+	// GetReferralTrackingBySender returns referral tracking of the given sender, newest first
+	GetReferralTrackingBySender(ctx context.Context, sender string, take, skip int) ([]*ReferralTracking, error)
 	// GetReferralTrackingStats returns referral tracking stats: total + 30 last days
 	GetReferralTrackingStats(ctx context.Context, sender string) ([]*ReferralTrackingStats, error)
+	// CreateReferralCampaign creates a referral campaign and returns its id.
+	CreateReferralCampaign(ctx context.Context, c ReferralCampaign) (int, error)
+	// GetReferralCampaigns returns all referral campaigns, newest first.
+	GetReferralCampaigns(ctx context.Context) ([]*ReferralCampaign, error)
+	// GetReferralCampaignsActiveBetween returns referral campaigns active at any time between from and to inclusive.
+	GetReferralCampaignsActiveBetween(ctx context.Context, from, to time.Time) ([]*ReferralCampaign, error)
+	// GetReferralLeaderboard returns top senders by confirmed referrals within the given window.
+	GetReferralLeaderboard(ctx context.Context, window LeaderboardWindow, limit int) ([]*ReferralLeaderboardItem, error)
+	// RefreshReferralLeaderboard recalculates the referral leaderboard.
+	RefreshReferralLeaderboard(ctx context.Context) error
 	// GetUnconfirmedReferralTracking returns referral tracking installed more than given days  ago
 	GetUnconfirmedReferralTracking(ctx context.Context, days int) ([]*ReferralTracking, error)
 	// GetConfirmedReferralTrackingCount returns count of confirmed referrals
@@ -125,4 +319,61 @@ type Storage interface {
 	CreateDLoan(ctx context.Context, address, firstName, lastName string, pdv float64) error
 	// GetDLoans returns a list of DLoans.
 	GetDLoans(ctx context.Context, take, skip int) ([]*DLoan, error)
+	// SetReferralBanned sets referral_banned flag of the request with the given address.
+	// Returns ErrReferralBannedUnchanged if the flag already equals banned, so concurrent calls can't both succeed.
+	SetReferralBanned(ctx context.Context, address string, banned bool) error
+	// CreateReferralBanEvent stores a ban/unban audit record.
+	CreateReferralBanEvent(ctx context.Context, address string, banned bool, reason string) error
+	// GetReferralBanEvents returns ban/unban history of the given address, newest first.
+	GetReferralBanEvents(ctx context.Context, address string) ([]*ReferralBanEvent, error)
+	// CreateSupplySnapshot stores the supply snapshot.
+	CreateSupplySnapshot(ctx context.Context, s SupplySnapshot) error
+	// GetSupplyHistory returns the last supply snapshot of each interval within [from, to), oldest first.
+	// CreatedAt of the returned snapshots is the interval start.
+	GetSupplyHistory(ctx context.Context, from, to time.Time, interval time.Duration) ([]*SupplySnapshot, error)
+	// CreateEvent stores the domain event. Does nothing if the event with the same id exists.
+	CreateEvent(ctx context.Context, e Event) error
+	// CreateWebhookSubscription creates a webhook subscription and returns its id.
+	CreateWebhookSubscription(ctx context.Context, s WebhookSubscription) (int, error)
+	// GetWebhookSubscriptions returns all webhook subscriptions, newest first.
+	GetWebhookSubscriptions(ctx context.Context) ([]*WebhookSubscription, error)
+	// DeleteWebhookSubscription deletes the webhook subscription with its deliveries.
+	DeleteWebhookSubscription(ctx context.Context, id int) error
+	// CreateWebhookDeliveries creates pending deliveries of the event for subscriptions matching its type and sender.
+	// Returns number of created deliveries.
+	CreateWebhookDeliveries(ctx context.Context, eventID, eventType, sender string, payload []byte) (int, error)
+	// ClaimWebhookDeliveries returns up to limit pending deliveries which are due and postpones them by lease,
+	// so the concurrent callers don't get the same deliveries.
+	ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*WebhookDelivery, error)
+	// SetWebhookDeliveryDelivered marks the delivery delivered.
+	SetWebhookDeliveryDelivered(ctx context.Context, id int) error
+	// SetWebhookDeliveryFailed records the failed attempt. The delivery becomes dead if nextAttemptAt isn't valid.
+	SetWebhookDeliveryFailed(ctx context.Context, id int, lastError string, nextAttemptAt sql.NullTime) error
+	// GetWebhookDeliveries returns deliveries with the given status, newest first.
+	GetWebhookDeliveries(ctx context.Context, status WebhookDeliveryStatus, take, skip int) ([]*WebhookDelivery, error)
+	// ReplayWebhookDelivery makes the dead delivery pending again with reset attempts.
+	// Returns ErrNotFound if there is no such dead delivery.
+	ReplayWebhookDelivery(ctx context.Context, id int) error
+	// GetDistributionRun returns the distribution run by name. Returns ErrNotFound if there is no such run.
+	GetDistributionRun(ctx context.Context, name string) (*DistributionRun, error)
+	// CreateDistributionRun creates the distribution run or returns the existing one with the same name.
+	CreateDistributionRun(ctx context.Context, name string) (*DistributionRun, error)
+	// SetDistributionRunFinished marks the distribution run finished.
+	SetDistributionRunFinished(ctx context.Context, id int) error
+	// CreateDistributionItems creates pending items of the run for addresses it doesn't have yet.
+	// Amounts of existing items aren't changed. Returns number of created items.
+	CreateDistributionItems(ctx context.Context, runID int, items []DistributionItem) (int, error)
+	// GetDistributionItems returns all items of the run in creation order.
+	GetDistributionItems(ctx context.Context, runID int) ([]*DistributionItem, error)
+	// SetDistributionItemsSending records the attempt of the items before the transaction with the memo is broadcast.
+	SetDistributionItemsSending(ctx context.Context, ids []int, memo string) error
+	// SetDistributionItemsSent marks the items sent.
+	SetDistributionItemsSent(ctx context.Context, ids []int) error
+	// SetDistributionItemsFailed marks the items failed.
+	SetDistributionItemsFailed(ctx context.Context, ids []int, lastError string) error
+	// SetDistributionItemsError records the error of the items keeping their status.
+	SetDistributionItemsError(ctx context.Context, ids []int, lastError string) error
+	// CancelPendingReferralTracking cancels sender's referral tracking which is not confirmed yet.
+	// Returns number of cancelled referrals.
+	CancelPendingReferralTracking(ctx context.Context, sender string) (int, error)
 }
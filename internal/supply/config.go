@@ -0,0 +1,157 @@
+package supply
+
+import (
+	"fmt"
+	"os"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	"github.com/ethereum/go-ethereum/common"
+	"gopkg.in/yaml.v2"
+)
+
+// ErrInvalidConfig is returned when the supply config is invalid.
+var ErrInvalidConfig = fmt.Errorf("invalid supply config")
+
+// Config describes supply sources and accounts excluded from the circulating supply.
+type Config struct {
+	// EVM are chains with the ERC20 token, e.g. Ethereum and bridged tokens on BSC or Polygon.
+	EVM    []EVMConfig  `yaml:"evm"`
+	Native NativeConfig `yaml:"native"`
+}
+
+// EVMConfig is an EVM chain with the ERC20 token which counts toward the supply.
+type EVMConfig struct {
+	// Name identifies the chain in the supply details, e.g. ethereum.
+	Name   string `yaml:"name"`
+	RPCURL string `yaml:"rpc_url"`
+	// Token is the ERC20 token contract address.
+	Token string `yaml:"token"`
+	// Decimals is the ERC20 token decimals.
+	Decimals int               `yaml:"decimals"`
+	Excluded []ExcludedAccount `yaml:"excluded"`
+}
+
+// NativeConfig ...
+type NativeConfig struct {
+	Excluded []ExcludedAccount `yaml:"excluded"`
+}
+
+// ExcludedAccount is an account whose balance is not circulating.
+// Native accounts are set either by address or by module name, e.g. distribution for the community pool.
+type ExcludedAccount struct {
+	Name    string `yaml:"name"`
+	Address string `yaml:"address"`
+	Module  string `yaml:"module"`
+}
+
+// DefaultConfig returns config with the Ethereum token at rpcURL excluding the reserved and locked balances.
+func DefaultConfig(rpcURL string) Config {
+	return Config{
+		EVM: []EVMConfig{
+			{
+				Name:     "ethereum",
+				RPCURL:   rpcURL,
+				Token:    "0x30f271c9e86d2b7d00a6376cd96a1cfbd5f0b9b3",
+				Decimals: 18,
+				Excluded: []ExcludedAccount{
+					{Name: "reserved", Address: "0x30f271c9e86d2b7d00a6376cd96a1cfbd5f0b9b3"},
+					{Name: "locked", Address: "0x91b028C41b0268d346E78209Eb5EF5579487b639"},
+				},
+			},
+		},
+	}
+}
+
+// LoadConfig reads the YAML config file.
+func LoadConfig(path string) (Config, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	var c Config
+	if err := yaml.UnmarshalStrict(b, &c); err != nil {
+		return Config{}, fmt.Errorf("%w: %s", ErrInvalidConfig, err)
+	}
+
+	if err := c.Validate(); err != nil {
+		return Config{}, err
+	}
+
+	return c, nil
+}
+
+// Validate checks chains, addresses and names of the config.
+func (c Config) Validate() error {
+	chains := map[string]bool{NativeChain: true}
+	for _, v := range c.EVM {
+		if v.Name == "" {
+			return fmt.Errorf("%w: empty evm chain name", ErrInvalidConfig)
+		}
+		if chains[v.Name] {
+			return fmt.Errorf("%w: duplicated chain name %s", ErrInvalidConfig, v.Name)
+		}
+		chains[v.Name] = true
+
+		if v.RPCURL == "" {
+			return fmt.Errorf("%w: %s has empty rpc url", ErrInvalidConfig, v.Name)
+		}
+		if !common.IsHexAddress(v.Token) {
+			return fmt.Errorf("%w: %s has invalid token address %s", ErrInvalidConfig, v.Name, v.Token)
+		}
+		if v.Decimals < 0 || v.Decimals > maxDecimals {
+			return fmt.Errorf("%w: %s has invalid decimals %d", ErrInvalidConfig, v.Name, v.Decimals)
+		}
+	}
+
+	names := make(map[string]bool)
+	checkName := func(name string) error {
+		if name == "" {
+			return fmt.Errorf("%w: empty excluded account name", ErrInvalidConfig)
+		}
+		if names[name] {
+			return fmt.Errorf("%w: duplicated excluded account name %s", ErrInvalidConfig, name)
+		}
+		names[name] = true
+		return nil
+	}
+
+	for _, chain := range c.EVM {
+		for _, v := range chain.Excluded {
+			if err := checkName(v.Name); err != nil {
+				return err
+			}
+
+			if v.Module != "" || !common.IsHexAddress(v.Address) {
+				return fmt.Errorf("%w: %s should have evm address", ErrInvalidConfig, v.Name)
+			}
+		}
+	}
+
+	for _, v := range c.Native.Excluded {
+		if err := checkName(v.Name); err != nil {
+			return err
+		}
+
+		if (v.Address == "") == (v.Module == "") {
+			return fmt.Errorf("%w: %s should have either address or module", ErrInvalidConfig, v.Name)
+		}
+
+		if v.Address != "" {
+			if _, err := sdk.AccAddressFromBech32(v.Address); err != nil {
+				return fmt.Errorf("%w: %s has invalid address: %s", ErrInvalidConfig, v.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// nativeAddress returns bech32 address of the native account.
+func (a ExcludedAccount) nativeAddress() string {
+	if a.Module != "" {
+		return authtypes.NewModuleAddress(a.Module).String()
+	}
+	return a.Address
+}
@@ -0,0 +1,129 @@
+package supply
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig(t *testing.T) {
+	require.NoError(t, DefaultConfig("https://mainnet.infura.io").Validate())
+
+	c, err := LoadConfig("../../configs/supply.yaml")
+	require.NoError(t, err)
+	require.Len(t, c.EVM, 1)
+	require.Equal(t, "ethereum", c.EVM[0].Name)
+	require.Equal(t, 18, c.EVM[0].Decimals)
+	require.Len(t, c.EVM[0].Excluded, 2)
+	require.Equal(t, []ExcludedAccount{{Name: "community pool", Module: "distribution"}}, c.Native.Excluded)
+
+	path := filepath.Join(t.TempDir(), "supply.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("evm:\n  - name: ethereum\n    rpc_url: http://localhost\n    token: x\n"), 0600))
+	_, err = LoadConfig(path)
+	require.True(t, errors.Is(err, ErrInvalidConfig))
+
+	require.NoError(t, os.WriteFile(path, []byte("unknown: 1\n"), 0600))
+	_, err = LoadConfig(path)
+	require.True(t, errors.Is(err, ErrInvalidConfig))
+}
+
+func TestConfig_Validate(t *testing.T) {
+	const token = "0x30f271c9e86d2b7d00a6376cd96a1cfbd5f0b9b3"
+
+	evm := func(name string, excluded ...ExcludedAccount) EVMConfig {
+		return EVMConfig{Name: name, RPCURL: "http://localhost", Token: token, Decimals: 18, Excluded: excluded}
+	}
+
+	tt := []struct {
+		name  string
+		c     Config
+		valid bool
+	}{
+		{
+			name: "valid",
+			c: Config{
+				EVM:    []EVMConfig{evm("ethereum", ExcludedAccount{Name: "a", Address: token}), evm("bsc")},
+				Native: NativeConfig{Excluded: []ExcludedAccount{{Name: "pool", Module: "distribution"}}},
+			},
+			valid: true,
+		},
+		{
+			name:  "no evm chains",
+			c:     Config{},
+			valid: true,
+		},
+		{
+			name: "empty chain name",
+			c:    Config{EVM: []EVMConfig{evm("")}},
+		},
+		{
+			name: "duplicated chain name",
+			c:    Config{EVM: []EVMConfig{evm("ethereum"), evm("ethereum")}},
+		},
+		{
+			name: "native chain name",
+			c:    Config{EVM: []EVMConfig{evm(NativeChain)}},
+		},
+		{
+			name: "empty rpc url",
+			c:    Config{EVM: []EVMConfig{{Name: "ethereum", Token: token, Decimals: 18}}},
+		},
+		{
+			name: "invalid token",
+			c:    Config{EVM: []EVMConfig{{Name: "ethereum", RPCURL: "http://localhost", Token: "token", Decimals: 18}}},
+		},
+		{
+			name: "invalid decimals",
+			c:    Config{EVM: []EVMConfig{{Name: "ethereum", RPCURL: "http://localhost", Token: token, Decimals: -1}}},
+		},
+		{
+			name: "empty name",
+			c:    Config{EVM: []EVMConfig{evm("ethereum", ExcludedAccount{Address: token})}},
+		},
+		{
+			name: "duplicated name",
+			c: Config{
+				EVM:    []EVMConfig{evm("ethereum", ExcludedAccount{Name: "a", Address: token})},
+				Native: NativeConfig{Excluded: []ExcludedAccount{{Name: "a", Module: "distribution"}}},
+			},
+		},
+		{
+			name: "duplicated name across chains",
+			c: Config{EVM: []EVMConfig{
+				evm("ethereum", ExcludedAccount{Name: "a", Address: token}),
+				evm("bsc", ExcludedAccount{Name: "a", Address: token}),
+			}},
+		},
+		{
+			name: "evm module",
+			c:    Config{EVM: []EVMConfig{evm("ethereum", ExcludedAccount{Name: "a", Module: "distribution"})}},
+		},
+		{
+			name: "native address and module",
+			c: Config{Native: NativeConfig{Excluded: []ExcludedAccount{
+				{Name: "a", Module: "distribution", Address: "furya1qu2zzt3mfp2kymmu3xt28v9aett7fu07dcmk7w"},
+			}}},
+		},
+		{
+			name: "native invalid address",
+			c: Config{Native: NativeConfig{Excluded: []ExcludedAccount{
+				{Name: "a", Address: token},
+			}}},
+		},
+	}
+
+	for i := range tt {
+		tc := tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.c.Validate()
+			if tc.valid {
+				require.NoError(t, err)
+			} else {
+				require.True(t, errors.Is(err, ErrInvalidConfig))
+			}
+		})
+	}
+}
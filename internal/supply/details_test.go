@@ -0,0 +1,222 @@
+package supply
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+func TestDetails(t *testing.T) {
+	d := Details{
+		Native: sdk.NewInt(1000),
+		EVM: []ChainSupply{
+			{Name: "ethereum", Total: sdk.NewInt(400)},
+			{Name: "bsc", Total: sdk.NewInt(100)},
+		},
+		Excluded: []ExcludedBalance{
+			{Name: "locked", Chain: "ethereum", Amount: sdk.NewInt(100)},
+			{Name: "community pool", Chain: NativeChain, Amount: sdk.NewInt(50)},
+		},
+	}
+
+	require.Equal(t, "500", d.ERC20().String())
+	require.Equal(t, "1500", d.Total().String())
+	require.Equal(t, "1350", d.Circulating().String())
+}
+
+func Test_toUfury(t *testing.T) {
+	v, _ := new(big.Int).SetString("1234567890123456789", 10)
+	require.Equal(t, "1234567", toUfury(v, 18).String())
+	require.Equal(t, "1234567890123456789", toUfury(v, 6).String())
+	require.Equal(t, "12345678901234567890", toUfury(v, 5).String())
+
+	// amounts above int64 don't overflow
+	v, _ = new(big.Int).SetString("100000000000000000000000000000000", 10)
+	require.Equal(t, "100000000000000000000", toUfury(v, 18).String())
+}
+
+func TestFormatAmount(t *testing.T) {
+	require.Equal(t, "1.5", FormatAmount(sdk.NewInt(1500000), 6))
+	require.Equal(t, "1", FormatAmount(sdk.NewInt(1000000), 6))
+	require.Equal(t, "0.000001", FormatAmount(sdk.NewInt(1), 6))
+	require.Equal(t, "0", FormatAmount(sdk.ZeroInt(), 6))
+	require.Equal(t, "150", FormatAmount(sdk.NewInt(150), 0))
+}
+
+func TestSupply_GetDetails(t *testing.T) {
+	s := New(nil, Config{EVM: []EVMConfig{{Name: "ethereum"}, {Name: "bsc"}}}, time.Hour, nil)
+
+	_, err := s.GetDetails()
+	require.True(t, errors.Is(err, ErrUnavailable))
+	require.False(t, s.GetStatus().Available)
+
+	now := time.Now()
+	for i, src := range s.sources {
+		src.result = &sourceResult{
+			total:     sdk.NewInt(int64(i + 1)),
+			excluded:  []ExcludedBalance{{Name: src.name, Chain: src.name, Amount: sdk.OneInt()}},
+			updatedAt: now,
+		}
+	}
+
+	d, err := s.GetDetails()
+	require.NoError(t, err)
+	require.Equal(t, "1", d.Native.String())
+	require.Equal(t, []ChainSupply{
+		{Name: "ethereum", Total: sdk.NewInt(2), UpdatedAt: now},
+		{Name: "bsc", Total: sdk.NewInt(3), UpdatedAt: now},
+	}, d.EVM)
+	require.Len(t, d.Excluded, 3)
+	require.Equal(t, "3", d.Circulating().String())
+	require.True(t, s.GetStatus().Available)
+
+	// a failed source keeps its last result
+	bsc := s.sources[2]
+	bsc.lastError = errors.New("node is down")
+	_, err = s.GetDetails()
+	require.NoError(t, err)
+
+	// a stale source is flagged, but its last result is still served
+	bsc.result.updatedAt = now.Add(-2 * time.Hour)
+	d, err = s.GetDetails()
+	require.NoError(t, err)
+	require.Equal(t, ChainSupply{Name: "bsc", Total: sdk.NewInt(3), UpdatedAt: bsc.result.updatedAt, Stale: true}, d.EVM[1])
+	require.False(t, d.EVM[0].Stale)
+	require.Equal(t, "3", d.Circulating().String())
+	require.Equal(t, bsc.result.updatedAt, d.UpdatedAt)
+
+	status := s.GetStatus()
+	require.False(t, status.Available)
+	require.EqualError(t, status.LastError, "bsc: node is down")
+	require.Equal(t, bsc.result.updatedAt, status.UpdatedAt)
+	require.Len(t, status.Sources, 3)
+	require.True(t, status.Sources[1].Available)
+	require.False(t, status.Sources[2].Available)
+	require.EqualError(t, status.Sources[2].LastError, "node is down")
+
+	// the stale native supply isn't served
+	s.sources[0].result.updatedAt = now.Add(-2 * time.Hour)
+	_, err = s.GetDetails()
+	require.True(t, errors.Is(err, ErrStale))
+}
+
+func TestSupply_GetCirculatingSupply(t *testing.T) {
+	s := New(nil, Config{}, 0, nil)
+
+	_, err := s.GetCirculatingSupply()
+	require.True(t, errors.Is(err, ErrUnavailable))
+
+	s.sources[0].result = &sourceResult{
+		total:     sdk.NewInt(1000500000),
+		updatedAt: time.Now(),
+	}
+
+	amount, err := s.GetCirculatingSupply()
+	require.NoError(t, err)
+	require.Equal(t, "1000.5", amount)
+}
+
+func TestSupply_GetDetails_EVMUnavailable(t *testing.T) {
+	s := New(nil, Config{EVM: []EVMConfig{{Name: "ethereum"}, {Name: "bsc"}}}, time.Hour, nil)
+
+	now := time.Now()
+	s.sources[0].result = &sourceResult{total: sdk.NewInt(10), updatedAt: now}
+	s.sources[1].result = &sourceResult{total: sdk.NewInt(5), updatedAt: now}
+	s.sources[2].lastError = errors.New("node is down")
+
+	// a chain which has never been fetched has no value to serve
+	_, err := s.GetDetails()
+	require.True(t, errors.Is(err, ErrUnavailable))
+	require.Contains(t, err.Error(), "bsc hasn't been fetched")
+	require.False(t, s.GetStatus().Available)
+
+	s.sources[2].result = &sourceResult{total: sdk.NewInt(3), updatedAt: now}
+	d, err := s.GetDetails()
+	require.NoError(t, err)
+	require.Equal(t, "18", d.Total().String())
+
+	// the native supply is required
+	s.sources[0].result = nil
+	_, err = s.GetDetails()
+	require.True(t, errors.Is(err, ErrUnavailable))
+}
+
+func TestSupply_Ping(t *testing.T) {
+	s := New(nil, Config{EVM: []EVMConfig{{Name: "bsc"}}}, time.Hour, nil)
+
+	now := time.Now()
+	s.sources[0].result = &sourceResult{total: sdk.OneInt(), updatedAt: now}
+	s.sources[1].lastError = errors.New("node is down")
+
+	err := s.Ping(context.Background())
+	require.True(t, errors.Is(err, ErrUnavailable))
+	require.Contains(t, err.Error(), "bsc: node is down")
+
+	require.Equal(t, map[string]interface{}{
+		"sources": map[string]interface{}{
+			NativeChain: map[string]interface{}{
+				"available": true,
+				"updatedAt": now.UTC(),
+			},
+			"bsc": map[string]interface{}{
+				"available": false,
+				"lastError": "node is down",
+			},
+		},
+	}, s.Details())
+
+	// the stale chain is served, but the check fails
+	s.sources[1].result = &sourceResult{total: sdk.OneInt(), updatedAt: now.Add(-2 * time.Hour)}
+	_, err = s.GetDetails()
+	require.NoError(t, err)
+	require.True(t, errors.Is(s.Ping(context.Background()), ErrStale))
+
+	s.sources[1].result.updatedAt = now
+	require.NoError(t, s.Ping(context.Background()))
+
+	s.sources[0].result = nil
+	require.True(t, errors.Is(s.Ping(context.Background()), ErrUnavailable))
+}
+
+type failingBankClient struct {
+	banktypes.QueryClient
+}
+
+func (failingBankClient) SupplyOf(context.Context, *banktypes.QuerySupplyOfRequest,
+	...grpc.CallOption) (*banktypes.QuerySupplyOfResponse, error) {
+	return nil, errors.New("node is down")
+}
+
+func TestSupply_Run(t *testing.T) {
+	s := New(failingBankClient{}, Config{}, time.Hour, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx, 10*time.Millisecond, time.Second)
+		close(done)
+	}()
+
+	require.Eventually(t, func() bool {
+		return s.GetStatus().LastError != nil
+	}, time.Second, 10*time.Millisecond)
+
+	_, err := s.GetDetails()
+	require.True(t, errors.Is(err, ErrUnavailable))
+
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run didn't stop")
+	}
+}
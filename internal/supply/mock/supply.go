@@ -5,8 +5,12 @@
 package mock
 
 import (
+	context "context"
+	storage "github.com/TessorNetwork/vulcan/internal/storage"
+	supply "github.com/TessorNetwork/vulcan/internal/supply"
 	gomock "github.com/golang/mock/gomock"
 	reflect "reflect"
+	time "time"
 )
 
 // MockSupply is a mock of Supply interface
@@ -33,10 +37,10 @@ func (m *MockSupply) EXPECT() *MockSupplyMockRecorder {
 }
 
 // GetCirculatingSupply mocks base method
-func (m *MockSupply) GetCirculatingSupply() (int64, error) {
+func (m *MockSupply) GetCirculatingSupply() (string, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetCirculatingSupply")
-	ret0, _ := ret[0].(int64)
+	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -46,3 +50,47 @@ func (mr *MockSupplyMockRecorder) GetCirculatingSupply() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCirculatingSupply", reflect.TypeOf((*MockSupply)(nil).GetCirculatingSupply))
 }
+
+// GetDetails mocks base method
+func (m *MockSupply) GetDetails() (supply.Details, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDetails")
+	ret0, _ := ret[0].(supply.Details)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDetails indicates an expected call of GetDetails
+func (mr *MockSupplyMockRecorder) GetDetails() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDetails", reflect.TypeOf((*MockSupply)(nil).GetDetails))
+}
+
+// GetStatus mocks base method
+func (m *MockSupply) GetStatus() supply.Status {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStatus")
+	ret0, _ := ret[0].(supply.Status)
+	return ret0
+}
+
+// GetStatus indicates an expected call of GetStatus
+func (mr *MockSupplyMockRecorder) GetStatus() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStatus", reflect.TypeOf((*MockSupply)(nil).GetStatus))
+}
+
+// GetHistory mocks base method
+func (m *MockSupply) GetHistory(ctx context.Context, from, to time.Time, interval time.Duration) ([]*storage.SupplySnapshot, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHistory", ctx, from, to, interval)
+	ret0, _ := ret[0].([]*storage.SupplySnapshot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHistory indicates an expected call of GetHistory
+func (mr *MockSupplyMockRecorder) GetHistory(ctx, from, to, interval interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHistory", reflect.TypeOf((*MockSupply)(nil).GetHistory), ctx, from, to, interval)
+}
@@ -6,161 +6,556 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"strings"
+	"sync"
 	"time"
 
+	sdk "github.com/cosmos/cosmos-sdk/types"
 	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethclient"
 	log "github.com/sirupsen/logrus"
-	"golang.org/x/sync/errgroup"
 
 	"github.com/TessorNetwork/furya/config"
+
+	"github.com/TessorNetwork/vulcan/internal/metrics"
+	"github.com/TessorNetwork/vulcan/internal/storage"
 )
 
 //go:generate mockgen -destination=./mock/supply.go -package=mock -source=supply.go
 
-const ufuryDenominator = 1e6
-
-// nolint
-var (
-	erc20TokenAddr       = common.HexToAddress("0x30f271c9e86d2b7d00a6376cd96a1cfbd5f0b9b3")
-	erc20LockedTokenAddr = common.HexToAddress("0x91b028C41b0268d346E78209Eb5EF5579487b639")
+const (
+	ufuryDecimals = 6
+	// maxDecimals is the max ERC20 decimals, it is uint8 in the token contract.
+	maxDecimals = 255
 )
 
+// ErrUnavailable is returned when some source hasn't been fetched yet.
+var ErrUnavailable = fmt.Errorf("supply is unavailable")
+
+// ErrStale is returned when the native supply hasn't been updated for longer than the staleness limit.
+var ErrStale = fmt.Errorf("supply is stale")
+
+// NativeChain is the chain of the native supply, EVM chains are named by the config.
+const NativeChain = "native"
+
+// ExcludedBalance is a balance of the account excluded from the circulating supply.
+type ExcludedBalance struct {
+	Name    string
+	Chain   string
+	Address string
+	Amount  sdk.Int
+}
+
+// ChainSupply is the ERC20 token total supply on an EVM chain.
+type ChainSupply struct {
+	Name      string
+	Total     sdk.Int
+	UpdatedAt time.Time
+	// Stale is true when Total is the last fetched value which is older than the staleness limit.
+	Stale bool
+}
+
+// Details is a supply breakdown. All amounts are in Denom with Decimals decimals.
+type Details struct {
+	Denom    string
+	Decimals int
+	// Native is the native chain supply.
+	Native sdk.Int
+	// EVM are the ERC20 token total supplies in the config order.
+	EVM []ChainSupply
+	// Excluded are balances which are not circulating.
+	Excluded []ExcludedBalance
+	// UpdatedAt is the time the oldest source was fetched at.
+	UpdatedAt time.Time
+}
+
+// ERC20 returns ERC20 token supply of all EVM chains.
+func (d Details) ERC20() sdk.Int {
+	v := sdk.ZeroInt()
+	for _, c := range d.EVM {
+		v = v.Add(c.Total)
+	}
+	return v
+}
+
+// Total returns native and ERC20 supply.
+func (d Details) Total() sdk.Int {
+	return d.Native.Add(d.ERC20())
+}
+
+// Circulating returns total supply without excluded balances.
+func (d Details) Circulating() sdk.Int {
+	v := d.Total()
+	for _, e := range d.Excluded {
+		v = v.Sub(e.Amount)
+	}
+	return v
+}
+
+// SourceStatus describes availability of a supply source, i.e. the native chain or an EVM chain.
+type SourceStatus struct {
+	Name      string
+	Available bool
+	// UpdatedAt is the last successful update time.
+	UpdatedAt time.Time
+	// LastError is the error of the last update if it failed.
+	LastError error
+}
+
+// Status describes availability of the supply.
+type Status struct {
+	// Available is true when all sources are available.
+	Available bool
+	// UpdatedAt is the last successful update time of the oldest source.
+	UpdatedAt time.Time
+	// LastError joins errors of the sources whose last update failed.
+	LastError error
+	Sources   []SourceStatus
+}
+
 // Supply ...
 type Supply interface {
-	GetCirculatingSupply() (int64, error)
+	// GetCirculatingSupply returns circulating supply as a decimal string in tokens.
+	GetCirculatingSupply() (string, error)
+	// GetDetails returns the supply breakdown, a failed EVM chain keeps its last fetched value and is flagged stale.
+	// It fails with ErrUnavailable until every source is fetched and with ErrStale if the native supply is stale.
+	GetDetails() (Details, error)
+	// GetStatus returns the supply availability.
+	GetStatus() Status
+	// GetHistory returns the last supply snapshot of each interval within [from, to).
+	GetHistory(ctx context.Context, from, to time.Time, interval time.Duration) ([]*storage.SupplySnapshot, error)
+}
+
+// sourceResult is a supply fetched from a source.
+type sourceResult struct {
+	total     sdk.Int
+	excluded  []ExcludedBalance
+	updatedAt time.Time
+}
+
+// source is polled independently of other sources, so a failed source keeps its last result.
+type source struct {
+	name string
+	poll func(ctx context.Context) (*sourceResult, error)
+
+	// result and lastError are guarded by supply.mu
+	result    *sourceResult
+	lastError error
 }
 
 type supply struct {
 	nativeBankClient banktypes.QueryClient
-	erc20NodeURL     string
+	staleness        time.Duration
+	storage          storage.Storage
+
+	// native is the first source followed by evm sources
+	sources []*source
+	evm     []*evmSource
 
-	circulatingSupply int64
+	mu sync.RWMutex
 }
 
-// New returns new instance of supply.
-func New(nativeBankClient banktypes.QueryClient, erc20NodeURL string) *supply { // nolint
+// New returns new instance of supply. The supply is unavailable until Run fetches every source.
+// A source which isn't updated for longer than staleness is stale, zero staleness means no limit.
+// Every update of all sources is stored as a snapshot.
+func New(nativeBankClient banktypes.QueryClient, config Config, staleness time.Duration,
+	storage storage.Storage) *supply { // nolint
 	s := &supply{
 		nativeBankClient: nativeBankClient,
-		erc20NodeURL:     erc20NodeURL,
+		staleness:        staleness,
+		storage:          storage,
 	}
 
-	s.startPolling()
+	native := config.Native
+	s.sources = append(s.sources, &source{
+		name: NativeChain,
+		poll: func(ctx context.Context) (*sourceResult, error) {
+			return s.pollNative(ctx, native)
+		},
+	})
+
+	for _, c := range config.EVM {
+		e := &evmSource{config: c}
+		s.evm = append(s.evm, e)
+		s.sources = append(s.sources, &source{
+			name: c.Name,
+			poll: e.poll,
+		})
+	}
 
 	return s
 }
 
-func (s *supply) PingContext(_ context.Context) error {
-	if _, err := s.GetCirculatingSupply(); err != nil {
-		return fmt.Errorf("invalid circulating supply: %w", err)
+// Ping fails when the supply can't be served or some source is stale,
+// the error contains errors of the failed sources.
+func (s *supply) Ping(_ context.Context) error {
+	status := s.GetStatus()
+
+	err := ErrStale
+	if _, dErr := s.GetDetails(); dErr != nil {
+		err = dErr
+	} else if status.Available {
+		return nil
+	}
+
+	if status.LastError != nil {
+		return fmt.Errorf("invalid circulating supply: %w: %s", err, status.LastError)
+	}
+	return fmt.Errorf("invalid circulating supply: %w", err)
+}
+
+// Details returns the status of every source for the health check.
+func (s *supply) Details() map[string]interface{} {
+	status := s.GetStatus()
+
+	sources := make(map[string]interface{}, len(status.Sources))
+	for _, v := range status.Sources {
+		details := map[string]interface{}{
+			"available": v.Available,
+		}
+		if !v.UpdatedAt.IsZero() {
+			details["updatedAt"] = v.UpdatedAt.UTC()
+		}
+		if v.LastError != nil {
+			details["lastError"] = v.LastError.Error()
+		}
+		sources[v.Name] = details
+	}
+
+	return map[string]interface{}{
+		"sources": sources,
 	}
-	return nil
 }
 
-func (s *supply) GetCirculatingSupply() (int64, error) {
-	if s.circulatingSupply == 0 {
-		return 0, errors.New("circulating supply is unavailable")
+func (s *supply) GetCirculatingSupply() (string, error) {
+	d, err := s.GetDetails()
+	if err != nil {
+		return "", err
 	}
-	return s.circulatingSupply, nil
+	return FormatAmount(d.Circulating(), d.Decimals), nil
 }
 
-func (s *supply) startPolling() {
-	refresh := func() {
-		val, err := s.poll()
-		if err != nil {
-			log.WithError(err).Error("failed to get circulating")
-			return
+func (s *supply) GetDetails() (Details, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	d := Details{
+		Denom:    config.DefaultBondDenom,
+		Decimals: ufuryDecimals,
+	}
+
+	// a stale EVM chain keeps its last value, so a single chain doesn't make the whole supply unavailable,
+	// but there is no value to serve for a chain which has never been fetched
+	for i, src := range s.sources {
+		if src.result == nil {
+			return Details{}, fmt.Errorf("%w: %s hasn't been fetched", ErrUnavailable, src.name)
+		}
+
+		stale := s.isStale(src.result)
+		if i == 0 {
+			if stale {
+				return Details{}, fmt.Errorf("%w: %s updated at %s", ErrStale, src.name,
+					src.result.updatedAt.Format(time.RFC3339))
+			}
+			d.Native = src.result.total
+		} else {
+			d.EVM = append(d.EVM, ChainSupply{
+				Name:      src.name,
+				Total:     src.result.total,
+				UpdatedAt: src.result.updatedAt,
+				Stale:     stale,
+			})
 		}
-		s.circulatingSupply = val
+
+		d.Excluded = append(d.Excluded, src.result.excluded...)
+
+		if d.UpdatedAt.IsZero() || src.result.updatedAt.Before(d.UpdatedAt) {
+			d.UpdatedAt = src.result.updatedAt
+		}
+	}
+
+	return d, nil
+}
+
+func (s *supply) GetStatus() Status {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	status := Status{Available: true}
+
+	var errs []string
+	for _, src := range s.sources {
+		ss := SourceStatus{
+			Name:      src.name,
+			LastError: src.lastError,
+		}
+		if src.result != nil {
+			ss.UpdatedAt = src.result.updatedAt
+			ss.Available = !s.isStale(src.result)
+		}
+
+		if src.lastError != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", src.name, src.lastError))
+		}
+
+		if status.UpdatedAt.IsZero() || ss.UpdatedAt.Before(status.UpdatedAt) {
+			status.UpdatedAt = ss.UpdatedAt
+		}
+		status.Available = status.Available && ss.Available
+		status.Sources = append(status.Sources, ss)
+	}
+
+	if len(errs) > 0 {
+		status.LastError = errors.New(strings.Join(errs, "; ")) // nolint: goerr113
 	}
 
-	refresh()
+	return status
+}
+
+func (s *supply) isStale(r *sourceResult) bool {
+	return s.staleness > 0 && time.Since(r.updatedAt) > s.staleness
+}
 
-	ticker := time.NewTicker(time.Hour)
-	go func() {
-		for range ticker.C {
-			refresh()
+// Run refreshes the supply every interval until the context is done.
+// Every refresh is limited by the timeout.
+func (s *supply) Run(ctx context.Context, interval, timeout time.Duration) {
+	defer func() {
+		for _, e := range s.evm {
+			e.closeClient()
 		}
 	}()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.refresh(ctx, timeout)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
 }
 
-func (s *supply) poll() (int64, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+// refresh polls all sources concurrently. A snapshot is stored only if all sources are updated.
+func (s *supply) refresh(ctx context.Context, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	gr, ctx := errgroup.WithContext(ctx)
+	results := make([]*sourceResult, len(s.sources))
+	errs := make([]error, len(s.sources))
 
-	var native, erc20 int64
-	gr.Go(func() error {
-		v, err := s.getNativeCirculatingSupply(ctx)
-		if err != nil {
-			return fmt.Errorf("failed to get native circulating: %w", err)
+	var wg sync.WaitGroup
+	for i := range s.sources {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = s.sources[i].poll(ctx)
+		}(i)
+	}
+	wg.Wait()
+
+	failed := false
+
+	s.mu.Lock()
+	for i, src := range s.sources {
+		src.lastError = errs[i]
+		if errs[i] != nil {
+			failed = true
+			metrics.IncSupplyPollErrors(src.name)
+			log.WithError(errs[i]).WithField("source", src.name).Error("failed to get supply")
+			continue
 		}
-		native = v
+		src.result = results[i]
+		metrics.SetSupplyUpdatedAt(src.name, src.result.updatedAt)
+	}
+	s.mu.Unlock()
 
-		return nil
-	})
-	gr.Go(func() error {
-		v, err := s.getERC20CirculatingSupply(ctx)
-		if err != nil {
-			return fmt.Errorf("failed to get erc20 circulating: %w", err)
-		}
-		erc20 = v
+	if failed {
+		return
+	}
 
-		return nil
-	})
+	d, err := s.GetDetails()
+	if err != nil {
+		log.WithError(err).Error("failed to get supply details")
+		return
+	}
+
+	s.saveSnapshot(ctx, d)
+}
 
-	if err := gr.Wait(); err != nil {
-		return 0, err
+func (s *supply) GetHistory(ctx context.Context, from, to time.Time,
+	interval time.Duration) ([]*storage.SupplySnapshot, error) {
+	return s.storage.GetSupplyHistory(ctx, from, to, interval)
+}
+
+func (s *supply) saveSnapshot(ctx context.Context, d Details) {
+	if err := s.storage.CreateSupplySnapshot(ctx, storage.SupplySnapshot{
+		CreatedAt:   d.UpdatedAt,
+		Native:      d.Native,
+		ERC20:       d.ERC20(),
+		Total:       d.Total(),
+		Circulating: d.Circulating(),
+	}); err != nil {
+		log.WithError(err).Error("failed to save supply snapshot")
 	}
+}
 
-	return native + erc20, nil
+func (s *supply) pollNative(ctx context.Context, c NativeConfig) (*sourceResult, error) {
+	total, err := s.getNativeCirculatingSupply(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get native circulating: %w", err)
+	}
+
+	excluded, err := s.getNativeExcluded(ctx, c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get native excluded: %w", err)
+	}
+
+	return &sourceResult{
+		total:     total,
+		excluded:  excluded,
+		updatedAt: time.Now().UTC(),
+	}, nil
 }
 
-func (s supply) getNativeCirculatingSupply(ctx context.Context) (int64, error) {
+func (s *supply) getNativeCirculatingSupply(ctx context.Context) (sdk.Int, error) {
 	resp, err := s.nativeBankClient.SupplyOf(ctx, &banktypes.QuerySupplyOfRequest{
 		Denom: config.DefaultBondDenom,
 	})
 	if err != nil {
-		return 0, fmt.Errorf("failed to get supply: %w", err)
+		return sdk.Int{}, fmt.Errorf("failed to get supply: %w", err)
+	}
+
+	return resp.Amount.Amount, nil
+}
+
+func (s *supply) getNativeExcluded(ctx context.Context, c NativeConfig) ([]ExcludedBalance, error) {
+	out := make([]ExcludedBalance, len(c.Excluded))
+	for i, v := range c.Excluded {
+		address := v.nativeAddress()
+
+		resp, err := s.nativeBankClient.Balance(ctx, &banktypes.QueryBalanceRequest{
+			Address: address,
+			Denom:   config.DefaultBondDenom,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s balance: %w", v.Name, err)
+		}
+
+		out[i] = ExcludedBalance{
+			Name:    v.Name,
+			Chain:   NativeChain,
+			Address: address,
+			Amount:  resp.Balance.Amount,
+		}
 	}
 
-	return resp.Amount.Amount.Int64() / ufuryDenominator, nil
+	return out, nil
+}
+
+// evmSource is the ERC20 token on an EVM chain.
+type evmSource struct {
+	config EVMConfig
+
+	// eth is used only by the polling loop, nil means it should be dialed
+	eth *ethclient.Client
 }
 
-func (s supply) getERC20CirculatingSupply(ctx context.Context) (int64, error) {
-	client, err := ethclient.DialContext(ctx, s.erc20NodeURL)
+// poll returns ERC20 total supply and excluded balances converted to ufury.
+func (e *evmSource) poll(ctx context.Context) (*sourceResult, error) {
+	client, err := e.getClient(ctx)
 	if err != nil {
-		return 0, fmt.Errorf("failed to create ethclient: %w", err)
+		return nil, err
 	}
 
-	instance, err := NewFurya(erc20TokenAddr, client)
+	total, excluded, err := e.getSupply(ctx, client)
 	if err != nil {
-		return 0, fmt.Errorf("failed to create token instance: %w", err)
+		// the connection may be broken, so it is re-dialed on the next poll
+		e.closeClient()
+		return nil, fmt.Errorf("failed to get %s circulating: %w", e.config.Name, err)
 	}
 
-	total, err := instance.TotalSupply(&bind.CallOpts{Context: ctx})
+	return &sourceResult{
+		total:     total,
+		excluded:  excluded,
+		updatedAt: time.Now().UTC(),
+	}, nil
+}
+
+func (e *evmSource) getSupply(ctx context.Context, client *ethclient.Client) (sdk.Int, []ExcludedBalance, error) {
+	instance, err := NewFurya(common.HexToAddress(e.config.Token), client)
 	if err != nil {
-		return 0, fmt.Errorf("failed to get total supply: %w", err)
+		return sdk.Int{}, nil, fmt.Errorf("failed to create token instance: %w", err)
 	}
 
-	reserved, err := instance.BalanceOf(&bind.CallOpts{Context: ctx}, erc20TokenAddr)
+	total, err := instance.TotalSupply(&bind.CallOpts{Context: ctx})
 	if err != nil {
-		return 0, fmt.Errorf("failed to get reserved: %w", err)
+		return sdk.Int{}, nil, fmt.Errorf("failed to get total supply: %w", err)
 	}
 
-	locked, err := instance.BalanceOf(&bind.CallOpts{Context: ctx}, erc20LockedTokenAddr)
+	out := make([]ExcludedBalance, len(e.config.Excluded))
+	for i, v := range e.config.Excluded {
+		balance, err := instance.BalanceOf(&bind.CallOpts{Context: ctx}, common.HexToAddress(v.Address))
+		if err != nil {
+			return sdk.Int{}, nil, fmt.Errorf("failed to get %s balance: %w", v.Name, err)
+		}
+
+		out[i] = ExcludedBalance{
+			Name:    v.Name,
+			Chain:   e.config.Name,
+			Address: v.Address,
+			Amount:  toUfury(balance, e.config.Decimals),
+		}
+	}
+
+	return toUfury(total, e.config.Decimals), out, nil
+}
+
+func (e *evmSource) getClient(ctx context.Context) (*ethclient.Client, error) {
+	if e.eth != nil {
+		return e.eth, nil
+	}
+
+	client, err := ethclient.DialContext(ctx, e.config.RPCURL)
 	if err != nil {
-		return 0, fmt.Errorf("failed to get reserved: %w", err)
+		return nil, fmt.Errorf("failed to create %s ethclient: %w", e.config.Name, err)
 	}
+	e.eth = client
 
-	var denom = big.NewInt(10)
-	denom.Exp(denom, big.NewInt(18), nil)
+	return client, nil
+}
 
-	supply := total.Sub(total, reserved)
-	supply = supply.Sub(supply, locked)
+func (e *evmSource) closeClient() {
+	if e.eth != nil {
+		e.eth.Close()
+		e.eth = nil
+	}
+}
 
-	return supply.Quo(supply, denom).Int64(), nil
+// toUfury converts ERC20 amount with the given decimals to ufury truncating the amount less than 1ufury.
+func toUfury(v *big.Int, decimals int) sdk.Int {
+	if decimals < ufuryDecimals {
+		mul := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(ufuryDecimals-decimals)), nil)
+		return sdk.NewIntFromBigInt(new(big.Int).Mul(v, mul))
+	}
+
+	denom := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals-ufuryDecimals)), nil)
+
+	return sdk.NewIntFromBigInt(new(big.Int).Quo(v, denom))
+}
+
+// FormatAmount formats the amount with the given decimals as a decimal number, e.g. 1500000 with 6 decimals is 1.5.
+func FormatAmount(amount sdk.Int, decimals int) string {
+	s := sdk.NewFurFromIntWithPrec(amount, int64(decimals)).String()
+
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+
+	return s
 }
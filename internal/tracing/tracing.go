@@ -0,0 +1,154 @@
+// Package tracing contains code for OpenTelemetry tracing.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/TessorNetwork/vulcan"
+
+// RequestIDHeader is the response header set by api.RequestIDMiddleware.
+const RequestIDHeader = "X-Request-ID"
+
+// RequestIDKey is the span attribute containing the id of the HTTP request the span belongs to.
+const RequestIDKey = attribute.Key("request_id")
+
+type requestIDKey struct{}
+
+// Config ...
+type Config struct {
+	// Endpoint is the host:port of the OTLP/HTTP collector, tracing is disabled if it's empty.
+	Endpoint string
+	Insecure bool
+	// SampleRatio is the ratio of sampled root spans, child spans follow the parent decision.
+	SampleRatio float64
+
+	ServiceName string
+	Version     string
+}
+
+// Init sets up the global tracer provider exporting spans to the OTLP/HTTP collector
+// and the W3C trace context propagator. It returns the function flushing and stopping the exporter.
+// Spans are no-op if the endpoint is empty.
+func Init(ctx context.Context, c Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	if c.Endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(c.Endpoint)}
+	if c.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create exporter: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(c.SampleRatio))),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL,
+			semconv.ServiceNameKey.String(c.ServiceName),
+			semconv.ServiceVersionKey.String(c.Version),
+		)),
+	)
+
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// NewInMemoryExporter sets up the global tracer provider exporting spans synchronously to the returned exporter.
+// The returned function restores the previous provider. It's used by tests.
+func NewInMemoryExporter() (*tracetest.InMemoryExporter, func()) {
+	prev := otel.GetTracerProvider()
+
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+
+	return exporter, func() {
+		otel.SetTracerProvider(prev)
+	}
+}
+
+// WithRequestID returns the context with the request id, it's added to all spans started with the context.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// GetRequestID returns the request id from the context or an empty string.
+func GetRequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// Start starts the span, it has the request_id attribute if the context has the request id.
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	if id := GetRequestID(ctx); id != "" {
+		opts = append(opts, trace.WithAttributes(RequestIDKey.String(id)))
+	}
+
+	return otel.Tracer(instrumentationName).Start(ctx, name, opts...)
+}
+
+// End records the error if it's not nil and ends the span.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
+
+// Middleware starts the server span of the request. The span continues the trace of the incoming traceparent header.
+// It should be used after api.RequestIDMiddleware to put the request id into the context.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		if id := w.Header().Get(RequestIDHeader); id != "" {
+			ctx = WithRequestID(ctx, id)
+		}
+
+		ctx, span := Start(ctx, "HTTP "+r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(semconv.HTTPServerAttributesFromHTTPRequest("", "", r)...),
+		)
+		defer span.End()
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		next.ServeHTTP(ww, r.WithContext(ctx))
+
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			span.SetName(r.Method + " " + rctx.RoutePattern())
+			span.SetAttributes(semconv.HTTPRouteKey.String(rctx.RoutePattern()))
+		}
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		span.SetAttributes(semconv.HTTPAttributesFromHTTPStatusCode(status)...)
+		span.SetStatus(semconv.SpanStatusFromHTTPStatusCodeAndSpanKind(status, trace.SpanKindServer))
+	})
+}
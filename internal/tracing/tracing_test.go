@@ -0,0 +1,103 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/TessorNetwork/go-api"
+)
+
+func getAttribute(span tracetest.SpanStub, key attribute.Key) attribute.Value {
+	for _, v := range span.Attributes {
+		if v.Key == key {
+			return v.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestMiddleware(t *testing.T) {
+	exporter, restore := NewInMemoryExporter()
+	defer restore()
+
+	r := chi.NewRouter()
+	r.Use(api.RequestIDMiddleware, Middleware)
+	r.Get("/v1/code/{address}", func(w http.ResponseWriter, r *http.Request) {
+		_, span := Start(r.Context(), "child")
+		End(span, errors.New("not found"))
+
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/code/a", nil))
+
+	id := w.Header().Get(RequestIDHeader)
+	require.NotEmpty(t, id)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+
+	child, server := spans[0], spans[1]
+
+	require.Equal(t, "child", child.Name)
+	require.Equal(t, codes.Error, child.Status.Code)
+	require.Equal(t, id, getAttribute(child, RequestIDKey).AsString())
+	require.Equal(t, server.SpanContext.SpanID(), child.Parent.SpanID())
+
+	require.Equal(t, "GET /v1/code/{address}", server.Name)
+	require.Equal(t, id, getAttribute(server, RequestIDKey).AsString())
+	require.Equal(t, int64(http.StatusNotFound), getAttribute(server, "http.status_code").AsInt64())
+	// 4xx aren't server errors
+	require.Equal(t, codes.Unset, server.Status.Code)
+}
+
+func TestMiddleware_Traceparent(t *testing.T) {
+	exporter, restore := NewInMemoryExporter()
+	defer restore()
+
+	_, err := Init(context.Background(), Config{})
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.Use(Middleware)
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[0].SpanContext.TraceID().String())
+	require.Equal(t, "00f067aa0ba902b7", spans[0].Parent.SpanID().String())
+	require.Equal(t, codes.Error, spans[0].Status.Code)
+}
+
+func TestStart(t *testing.T) {
+	exporter, restore := NewInMemoryExporter()
+	defer restore()
+
+	_, span := Start(context.Background(), "no request")
+	End(span, nil)
+
+	_, span = Start(WithRequestID(context.Background(), "id"), "request")
+	End(span, nil)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	require.Equal(t, attribute.INVALID, getAttribute(spans[0], RequestIDKey).Type())
+	require.Equal(t, "id", getAttribute(spans[1], RequestIDKey).AsString())
+	require.Equal(t, codes.Unset, spans[1].Status.Code)
+}
@@ -0,0 +1,261 @@
+// Package webhooks delivers referral events to partners' webhook subscriptions.
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/TessorNetwork/vulcan/internal/events"
+	"github.com/TessorNetwork/vulcan/internal/metrics"
+	"github.com/TessorNetwork/vulcan/internal/storage"
+)
+
+// Events are event types partners can subscribe to.
+// nolint:gochecknoglobals
+var Events = []events.Type{events.ReferralInstalled, events.RewardPaid}
+
+// ErrUnexpectedStatus is returned when the webhook responds with non-2xx status.
+var ErrUnexpectedStatus = fmt.Errorf("unexpected status")
+
+// maxErrorLength limits the stored error, the partner's response may be long.
+const maxErrorLength = 512
+
+type sink struct {
+	storage storage.Storage
+}
+
+// NewSink returns the sink creating deliveries of referral events for matching subscriptions.
+// Other events are ignored.
+func NewSink(s storage.Storage) events.Sink {
+	return sink{storage: s}
+}
+
+func (s sink) Name() string {
+	return "partner_webhooks"
+}
+
+func (s sink) Send(ctx context.Context, e events.Envelope) error {
+	var sender string
+	switch v := e.Data.(type) {
+	case events.ReferralInstalledEvent:
+		sender = v.Sender
+	case events.RewardPaidEvent:
+		sender = v.Sender
+	default:
+		return nil
+	}
+
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	n, err := s.storage.CreateWebhookDeliveries(ctx, e.ID, string(e.Type), sender, payload)
+	if err != nil {
+		return fmt.Errorf("failed to create webhook deliveries: %w", err)
+	}
+
+	if n > 0 {
+		log.WithFields(log.Fields{
+			"event":      e.ID,
+			"type":       e.Type,
+			"deliveries": n,
+		}).Debug("webhook deliveries created")
+	}
+
+	return nil
+}
+
+// Config ...
+type Config struct {
+	// BatchSize is the maximal number of deliveries sent concurrently.
+	BatchSize int
+	// MaxAttempts is the number of attempts after which the delivery becomes dead.
+	MaxAttempts int
+	// MinBackoff is the delay after the first failed attempt, it doubles with every next one.
+	MinBackoff time.Duration
+	// MaxBackoff limits the delay between attempts.
+	MaxBackoff time.Duration
+	// Timeout is the webhook request timeout.
+	Timeout time.Duration
+}
+
+// Dispatcher sends pending deliveries and retries failed ones with exponential backoff.
+type Dispatcher struct {
+	storage storage.Storage
+	client  *http.Client
+	config  Config
+}
+
+// NewDispatcher returns new instance of Dispatcher.
+func NewDispatcher(s storage.Storage, config Config) *Dispatcher {
+	return &Dispatcher{
+		storage: s,
+		client:  &http.Client{Timeout: config.Timeout},
+		config:  config,
+	}
+}
+
+// Run dispatches deliveries every interval until the context is done.
+func (d *Dispatcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := d.dispatch(ctx); err != nil {
+			log.WithError(err).Error("failed to dispatch webhook deliveries")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// dispatch sends due deliveries batch by batch until there are no more.
+func (d *Dispatcher) dispatch(ctx context.Context) error {
+	for ctx.Err() == nil {
+		// the lease covers the whole batch since deliveries are sent concurrently
+		deliveries, err := d.storage.ClaimWebhookDeliveries(ctx, d.config.BatchSize, 2*d.config.Timeout)
+		if err != nil {
+			return fmt.Errorf("failed to claim deliveries: %w", err)
+		}
+
+		if len(deliveries) == 0 {
+			return nil
+		}
+
+		subscriptions, err := d.getSubscriptions(ctx)
+		if err != nil {
+			return err
+		}
+
+		var wg sync.WaitGroup
+		for _, v := range deliveries {
+			sub, ok := subscriptions[v.SubscriptionID]
+			if !ok {
+				// deleted meanwhile, deliveries are deleted with it
+				continue
+			}
+
+			wg.Add(1)
+			go func(delivery *storage.WebhookDelivery) {
+				defer wg.Done()
+				d.deliver(ctx, sub, delivery)
+			}(v)
+		}
+		wg.Wait()
+
+		if len(deliveries) < d.config.BatchSize {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func (d *Dispatcher) getSubscriptions(ctx context.Context) (map[int]*storage.WebhookSubscription, error) {
+	list, err := d.storage.GetWebhookSubscriptions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subscriptions: %w", err)
+	}
+
+	m := make(map[int]*storage.WebhookSubscription, len(list))
+	for _, v := range list {
+		m[v.ID] = v
+	}
+
+	return m, nil
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, sub *storage.WebhookSubscription, delivery *storage.WebhookDelivery) {
+	logger := log.WithFields(log.Fields{
+		"delivery":     delivery.ID,
+		"subscription": sub.ID,
+		"event":        delivery.EventID,
+		"attempt":      delivery.Attempts + 1,
+	})
+
+	sendErr := d.send(ctx, sub, delivery)
+	if sendErr == nil {
+		if err := d.storage.SetWebhookDeliveryDelivered(ctx, delivery.ID); err != nil {
+			logger.WithError(err).Error("failed to mark webhook delivery delivered")
+		}
+		metrics.IncWebhookDeliveries(metrics.OutcomeSuccess)
+		return
+	}
+
+	attempts := delivery.Attempts + 1
+
+	var nextAttemptAt sql.NullTime
+	if attempts < d.config.MaxAttempts {
+		nextAttemptAt = sql.NullTime{Valid: true, Time: time.Now().UTC().Add(d.backoff(attempts))}
+		metrics.IncWebhookDeliveries(metrics.OutcomeError)
+		logger.WithError(sendErr).Warn("failed to deliver webhook")
+	} else {
+		metrics.IncWebhookDeliveries(metrics.OutcomeDead)
+		logger.WithError(sendErr).Error("webhook delivery is dead")
+	}
+
+	msg := sendErr.Error()
+	if len(msg) > maxErrorLength {
+		msg = msg[:maxErrorLength]
+	}
+
+	if err := d.storage.SetWebhookDeliveryFailed(ctx, delivery.ID, msg, nextAttemptAt); err != nil {
+		logger.WithError(err).Error("failed to mark webhook delivery failed")
+	}
+}
+
+// backoff returns the delay after the given number of failed attempts.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.config.MinBackoff
+	for i := 1; i < attempts && delay < d.config.MaxBackoff; i++ {
+		delay *= 2
+	}
+
+	if delay > d.config.MaxBackoff {
+		delay = d.config.MaxBackoff
+	}
+
+	return delay
+}
+
+func (d *Dispatcher) send(ctx context.Context, sub *storage.WebhookSubscription, delivery *storage.WebhookDelivery) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(events.SignatureHeader, "sha256="+events.Sign([]byte(sub.Secret), delivery.Payload))
+	req.Header.Set(events.EventHeader, delivery.EventType)
+	req.Header.Set(events.DeliveryHeader, strconv.Itoa(delivery.ID))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close() // nolint: errcheck
+
+	// drain the body to reuse the connection
+	io.Copy(ioutil.Discard, resp.Body) // nolint: errcheck
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("%w: %d", ErrUnexpectedStatus, resp.StatusCode)
+	}
+
+	return nil
+}
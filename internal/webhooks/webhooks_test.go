@@ -0,0 +1,123 @@
+package webhooks
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/TessorNetwork/vulcan/internal/events"
+	"github.com/TessorNetwork/vulcan/internal/storage"
+	storagemock "github.com/TessorNetwork/vulcan/internal/storage/mock"
+)
+
+var testConfig = Config{ // nolint:gochecknoglobals
+	BatchSize:   10,
+	MaxAttempts: 3,
+	MinBackoff:  time.Minute,
+	MaxBackoff:  3 * time.Minute,
+	Timeout:     time.Second,
+}
+
+func TestSink_Send(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	st := storagemock.NewMockStorage(ctrl)
+	s := NewSink(st)
+
+	e := events.NewEnvelope(events.RewardPaidEvent{
+		Sender:         "sender",
+		Receiver:       "receiver",
+		SenderReward:   sdk.NewInt(2),
+		ReceiverReward: sdk.NewInt(1),
+	})
+	st.EXPECT().CreateWebhookDeliveries(gomock.Any(), e.ID, "reward_paid", "sender", gomock.Any()).
+		DoAndReturn(func(_ context.Context, _, _, _ string, payload []byte) (int, error) {
+			var got events.Envelope
+			got.Data = &events.RewardPaidEvent{}
+			require.NoError(t, json.Unmarshal(payload, &got))
+			require.Equal(t, e.ID, got.ID)
+			require.Equal(t, "receiver", got.Data.(*events.RewardPaidEvent).Receiver)
+			return 1, nil
+		})
+
+	require.NoError(t, s.Send(context.Background(), e))
+
+	// not a referral event
+	require.NoError(t, s.Send(context.Background(), events.NewEnvelope(events.DLoanCreatedEvent{})))
+}
+
+func TestDispatcher_dispatch(t *testing.T) {
+	payload := []byte(`{"id":"1"}`)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.Equal(t, payload, body)
+		require.Equal(t, "sha256="+events.Sign([]byte("secret"), body), r.Header.Get(events.SignatureHeader))
+		require.Equal(t, "reward_paid", r.Header.Get(events.EventHeader))
+
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer srv.Close()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	st := storagemock.NewMockStorage(ctrl)
+	d := NewDispatcher(st, testConfig)
+
+	delivery := func(id, subscriptionID, attempts int) *storage.WebhookDelivery {
+		return &storage.WebhookDelivery{
+			ID:             id,
+			SubscriptionID: subscriptionID,
+			EventType:      "reward_paid",
+			Payload:        payload,
+			Attempts:       attempts,
+		}
+	}
+
+	st.EXPECT().ClaimWebhookDeliveries(gomock.Any(), 10, 2*time.Second).Return([]*storage.WebhookDelivery{
+		delivery(1, 1, 0),
+		delivery(2, 2, 0),
+		delivery(3, 2, 2),
+		delivery(4, 3, 0), // subscription is deleted
+	}, nil)
+	st.EXPECT().GetWebhookSubscriptions(gomock.Any()).Return([]*storage.WebhookSubscription{
+		{ID: 1, URL: srv.URL, Secret: "secret"},
+		{ID: 2, URL: srv.URL + "/fail", Secret: "secret"},
+	}, nil)
+
+	st.EXPECT().SetWebhookDeliveryDelivered(gomock.Any(), 1).Return(nil)
+	st.EXPECT().SetWebhookDeliveryFailed(gomock.Any(), 2, "unexpected status: 502", gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ int, _ string, next sql.NullTime) error {
+			require.True(t, next.Valid)
+			require.WithinDuration(t, time.Now().Add(time.Minute), next.Time, 5*time.Second)
+			require.Equal(t, time.UTC, next.Time.Location())
+			return nil
+		})
+	// the last attempt
+	st.EXPECT().SetWebhookDeliveryFailed(gomock.Any(), 3, "unexpected status: 502", sql.NullTime{}).Return(nil)
+
+	require.NoError(t, d.dispatch(context.Background()))
+}
+
+func TestDispatcher_backoff(t *testing.T) {
+	d := NewDispatcher(nil, testConfig)
+
+	require.Equal(t, time.Minute, d.backoff(1))
+	require.Equal(t, 2*time.Minute, d.backoff(2))
+	require.Equal(t, 3*time.Minute, d.backoff(3))
+	require.Equal(t, 3*time.Minute, d.backoff(100))
+}